@@ -7,9 +7,12 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/utils/clock"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/app/options"
 	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	"github.com/GBA-BI/tes-scheduler/pkg/debug"
+	"github.com/GBA-BI/tes-scheduler/pkg/healthz"
 	"github.com/GBA-BI/tes-scheduler/pkg/leaderelection"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler"
 	"github.com/GBA-BI/tes-scheduler/pkg/server"
@@ -34,6 +37,10 @@ func newSchedulerCommand(opts *options.Options) *cobra.Command {
 			defer applog.Sync()
 
 			cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+				if _, ok := flag.Annotations[consts.SensitiveFlagAnnotation]; ok && flag.Value.String() != "" {
+					applog.Infow("FLAG", flag.Name, "<redacted>")
+					return
+				}
 				applog.Infow("FLAG", flag.Name, flag.Value)
 			})
 
@@ -46,10 +53,24 @@ func run(opts *options.Options) error {
 	applog.Infow("run veTES scheduler")
 	ctx := genericapiserver.SetupSignalContext()
 
-	sche, err := scheduler.NewScheduler(opts.Scheduler, vetesclient.NewClient(opts.VeTESClient))
+	clk := clock.RealClock{}
+	vetesClients, err := vetesclient.NewClients(opts.VeTESClient, clk)
 	if err != nil {
 		return err
 	}
+	sche, err := scheduler.NewScheduler(opts.Scheduler, vetesClients, clk)
+	if err != nil {
+		return err
+	}
+	for _, checker := range sche.HealthCheckers() {
+		healthz.RegisterChecker(checker)
+	}
+	for _, checker := range sche.ReadinessCheckers() {
+		healthz.RegisterReadinessChecker(checker)
+	}
+	for name, handler := range sche.DebugHandlers() {
+		debug.RegisterHandler(name, handler)
+	}
 
 	if err = leaderelection.Init(opts.LeaderElection); err != nil {
 		return err
@@ -70,6 +91,7 @@ func NewSchedulerCommand() (*cobra.Command, error) {
 	opts.AddFlags(cmd.Flags())
 	version.AddFlags(cmd.Flags())
 	cmd.Flags().AddFlag(pflag.Lookup(viper.ConfigFlagName))
+	cmd.AddCommand(newSimulateCommand(opts))
 	if err := viper.LoadConfig(opts); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	applog "github.com/GBA-BI/tes-scheduler/pkg/log"
+	"github.com/spf13/cobra"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/app/options"
+	"github.com/GBA-BI/tes-scheduler/pkg/simulator"
+)
+
+func newSimulateCommand(opts *options.Options) *cobra.Command {
+	simOpts := simulator.NewOptions()
+	cmd := &cobra.Command{
+		Use:          "simulate",
+		Short:        "Replay a trace of tasks against the scheduler in simulated time",
+		Long:         "Replay a trace of tasks against the scheduler in simulated time, and report wait time, utilisation, quota rejections and makespan",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Log.Validate(); err != nil {
+				return err
+			}
+			if err := opts.Scheduler.Validate(); err != nil {
+				return err
+			}
+			if err := simOpts.Validate(); err != nil {
+				return err
+			}
+
+			applog.RegisterLogger(opts.Log)
+			defer applog.Sync()
+
+			sim, err := simulator.New(simOpts, opts.Scheduler)
+			if err != nil {
+				return err
+			}
+			report, err := sim.Run()
+			if err != nil {
+				return err
+			}
+			return simulator.WriteReport(report, simOpts.Output)
+		},
+	}
+
+	opts.Log.AddFlags(cmd.Flags())
+	opts.Scheduler.AddFlags(cmd.Flags())
+	simOpts.AddFlags(cmd.Flags())
+	return cmd
+}
@@ -27,3 +27,25 @@ const (
 	DefaultPageSize = 256
 	MaximumPageSize = 2048
 )
+
+// TaskDeadlineTag is the tag key of task deadline. The value is either a RFC3339
+// timestamp or a duration after the creation time of task, such as "4h".
+const TaskDeadlineTag = "deadline"
+
+// SensitiveFlagAnnotation is the pflag annotation of flags whose values must not be logged
+const SensitiveFlagAnnotation = "sensitive"
+
+// FederatedIDSeparator separates the endpoint name and the ID of task or cluster
+// in federation, such as "region-a/task-xxxx".
+const FederatedIDSeparator = "/"
+
+// reasons of unschedulable tasks, reported as the reason label of metrics
+const (
+	ReasonCount    = "Count"
+	ReasonCPUCores = "CPUCores"
+	ReasonRamGB    = "RamGB"
+	ReasonDiskGB   = "DiskGB"
+	ReasonGPUCount = "GPUCount"
+	ReasonGPUType  = "GPUType"
+	ReasonOther    = "Other"
+)
@@ -0,0 +1,17 @@
+package debug
+
+import (
+	"net/http"
+)
+
+var handlers = make(map[string]http.Handler)
+
+// RegisterHandler registers a debug handler, which is served at name under debug path
+func RegisterHandler(name string, handler http.Handler) {
+	handlers[name] = handler
+}
+
+// Handlers returns all registered debug handlers, name -> handler
+func Handlers() map[string]http.Handler {
+	return handlers
+}
@@ -10,23 +10,50 @@ import (
 
 var checkers []healthz.HealthChecker
 
-// RegisterChecker ...
+// readinessCheckers are only checked for readiness, such as freshness of data
+// from dependencies, which should not restart the process
+var readinessCheckers []healthz.HealthChecker
+
+// RegisterChecker registers a liveness checker, which is also checked for readiness
 func RegisterChecker(checker healthz.HealthChecker) {
 	checkers = append(checkers, checker)
 }
 
-// Handler ...
+// RegisterReadinessChecker registers a checker only for readiness
+func RegisterReadinessChecker(checker healthz.HealthChecker) {
+	readinessCheckers = append(readinessCheckers, checker)
+}
+
+// Handler serves liveness. Failures of readiness checkers are reported as degraded
+// with status 200, so that an outage of dependencies does not restart the process.
 func Handler(w http.ResponseWriter, req *http.Request) {
-	var errs []error
-	for _, checker := range checkers {
-		if err := checker.Check(req); err != nil {
-			errs = append(errs, fmt.Errorf("%s: %w", checker.Name(), err))
-		}
+	if errs := check(req, checkers); len(errs) > 0 {
+		http.Error(w, utilerrors.NewAggregate(errs).Error(), http.StatusServiceUnavailable)
+		return
 	}
 
-	if len(errs) > 0 {
+	if errs := check(req, readinessCheckers); len(errs) > 0 {
+		fmt.Fprintf(w, "degraded: %s\n", utilerrors.NewAggregate(errs).Error())
+	}
+	fmt.Fprint(w, "ok")
+}
+
+// ReadinessHandler serves readiness
+func ReadinessHandler(w http.ResponseWriter, req *http.Request) {
+	if errs := check(req, append(append([]healthz.HealthChecker{}, checkers...), readinessCheckers...)); len(errs) > 0 {
 		http.Error(w, utilerrors.NewAggregate(errs).Error(), http.StatusServiceUnavailable)
+		return
 	}
 
 	fmt.Fprint(w, "ok")
 }
+
+func check(req *http.Request, checkers []healthz.HealthChecker) []error {
+	var errs []error
+	for _, checker := range checkers {
+		if err := checker.Check(req); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", checker.Name(), err))
+		}
+	}
+	return errs
+}
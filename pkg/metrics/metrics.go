@@ -1 +1,116 @@
 package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const namespace = "tes_scheduler"
+
+// DeadlineAtRiskTasks is the number of unschedulable tasks close to their deadline in last schedule cycle
+var DeadlineAtRiskTasks = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "deadline_at_risk_tasks",
+	Help:      "Number of queued tasks close to their deadline but still unschedulable in last schedule cycle.",
+})
+
+// ExtraPriorityAffectedTasks is the number of queued tasks matched by active extraPriorities in last schedule cycle,
+// summed up by account. Counts of each extraPriority are logged instead, as they have no bounded identifier.
+var ExtraPriorityAffectedTasks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "extra_priority_affected_tasks",
+	Help:      "Number of queued tasks matched by active extra priorities in last schedule cycle.",
+}, []string{"account_id"})
+
+// ScheduleCycleDuration is the duration of schedule cycles
+var ScheduleCycleDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "schedule_cycle_duration_seconds",
+	Help:      "Duration of schedule cycles.",
+	Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+})
+
+// ScheduleCycleTasks is the number of tasks by result in last schedule cycle, one of attempted, scheduled, unschedulable,
+// conflict and rejected
+var ScheduleCycleTasks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "schedule_cycle_tasks",
+	Help:      "Number of queued tasks attempted, scheduled, unschedulable, conflicted and rejected in last schedule cycle.",
+}, []string{"result"})
+
+// ScheduleAttempts counts tasks by result in all schedule cycles, same results as ScheduleCycleTasks
+var ScheduleAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "schedule_attempts_total",
+	Help:      "Number of queued tasks attempted, scheduled, unschedulable, conflicted and rejected in all schedule cycles.",
+}, []string{"result"})
+
+// UnschedulableTasks counts rejections of tasks by plugin and reason, a task rejected for several reasons counts once for each
+var UnschedulableTasks = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "unschedulable_tasks_total",
+	Help:      "Number of times tasks are found unschedulable by plugin and reason.",
+}, []string{"plugin", "reason"})
+
+// QueueDepth is the number of unfinished tasks in last schedule cycle
+var QueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "queue_depth",
+	Help:      "Number of unfinished tasks by state, account and GPU type in last schedule cycle.",
+}, []string{"state", "account_id", "gpu_type"})
+
+// TaskScheduleLatency is the time from creation to assignment of scheduled tasks
+var TaskScheduleLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "task_schedule_latency_seconds",
+	Help:      "Time from creation to assignment of scheduled tasks.",
+	Buckets:   prometheus.ExponentialBuckets(1, 2, 16),
+})
+
+// ControllerRescheduledTasks counts tasks requeued by the controller as their clusters are gone or not ready
+var ControllerRescheduledTasks = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "controller_rescheduled_tasks_total",
+	Help:      "Number of tasks requeued by the controller as their clusters are deleted or not ready.",
+})
+
+// ControllerFailedTasks counts tasks marked failed by the controller as no cluster limits match
+var ControllerFailedTasks = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "controller_failed_tasks_total",
+	Help:      "Number of tasks marked failed by the controller as no cluster limits match their resources.",
+})
+
+// JobLastRunTimestamp is the unix time of the last finished run of each job
+var JobLastRunTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "job_last_run_timestamp_seconds",
+	Help:      "Unix time of the last finished run of each periodic job.",
+}, []string{"job"})
+
+// JobLastRunDuration is the duration of the last finished run of each job
+var JobLastRunDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "job_last_run_duration_seconds",
+	Help:      "Duration of the last finished run of each periodic job.",
+}, []string{"job"})
+
+// JobRuns counts runs of each job by result, one of success, error, panic and skipped
+var JobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "job_runs_total",
+	Help:      "Number of runs of each periodic job by result, skipped if the last run is not finished.",
+}, []string{"job", "result"})
+
+func init() {
+	prometheus.MustRegister(DeadlineAtRiskTasks)
+	prometheus.MustRegister(ExtraPriorityAffectedTasks)
+	prometheus.MustRegister(ScheduleCycleDuration)
+	prometheus.MustRegister(ScheduleCycleTasks)
+	prometheus.MustRegister(ScheduleAttempts)
+	prometheus.MustRegister(UnschedulableTasks)
+	prometheus.MustRegister(QueueDepth)
+	prometheus.MustRegister(TaskScheduleLatency)
+	prometheus.MustRegister(ControllerRescheduledTasks)
+	prometheus.MustRegister(ControllerFailedTasks)
+	prometheus.MustRegister(JobLastRunTimestamp)
+	prometheus.MustRegister(JobLastRunDuration)
+	prometheus.MustRegister(JobRuns)
+}
@@ -1,33 +1,115 @@
 package cache
 
-import "github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
+import (
+	"context"
+	"fmt"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/clock"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/runner"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
+)
 
 // Cache ...
 type Cache struct {
 	ClusterCache       ClusterCache
 	TaskCache          TaskCache
+	UsageCache         UsageCache
 	ExtraPriorityCache ExtraPriorityCache
 	QuotaCache         QuotaCache
+	RuntimeEstimator   RuntimeEstimator
+
+	// Snapshot is taken by the scheduler at the start of each scheduling cycle,
+	// plugins should read from it instead of caches above.
+	Snapshot *Snapshot
+
+	// Endpoints are names of federated endpoints, empty if not federated
+	Endpoints []string
+	// Clock is the clock of caches, the scheduler, the controller and plugins
+	Clock clock.PassiveClock
+
+	// maxStaleness is the max duration since last successful sync, 0 means unlimited
+	maxStaleness time.Duration
 }
 
 // NewCache ...
-func NewCache(vetesClient vetesclient.Client, opts *Options) (*Cache, error) {
-	clusterCache, err := NewClusterCache(vetesClient, opts)
+func NewCache(vetesClient vetesclient.Client, opts *Options, clk clock.PassiveClock, jobs runner.Registerer) (*Cache, error) {
+	checkpoint := loadCheckpoint(context.Background(), opts.CheckpointPath)
+
+	clusterCache, err := NewClusterCache(vetesClient, opts, checkpoint, clk, jobs)
 	if err != nil {
 		return nil, err
 	}
-	taskCache, err := NewTaskCache(vetesClient, opts)
+	runtimeEstimator := NewRuntimeEstimator()
+	taskCache, err := NewTaskCache(vetesClient, runtimeEstimator, opts, checkpoint, clk, jobs)
 	if err != nil {
 		return nil, err
 	}
-	extraPriorityCache, err := NewExtraPriorityCache(vetesClient, opts)
+	extraPriorityCache, err := NewExtraPriorityCache(vetesClient, opts, checkpoint, clk, jobs)
 	if err != nil {
 		return nil, err
 	}
-	quotaCache := NewQuotaCache(vetesClient, opts)
-	return &Cache{ClusterCache: clusterCache,
+	quotaCache, err := NewQuotaCache(vetesClient, opts, clk, jobs)
+	if err != nil {
+		return nil, err
+	}
+	cache := &Cache{ClusterCache: clusterCache,
 		TaskCache:          taskCache,
+		UsageCache:         taskCache,
 		ExtraPriorityCache: extraPriorityCache,
 		QuotaCache:         quotaCache,
-	}, nil
+		RuntimeEstimator:   runtimeEstimator,
+		Clock:              clk,
+		maxStaleness:       opts.MaxStaleness,
+	}
+
+	if opts.CheckpointPath != "" {
+		if err = jobs.Register("cache-checkpoint", opts.CheckpointPeriod, func() error {
+			cache.saveCheckpoint(context.Background(), opts.CheckpointPath)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return cache, nil
+}
+
+// EndpointOf returns the endpoint of a federated task or cluster ID, empty if not federated
+func (c *Cache) EndpointOf(id string) string {
+	if len(c.Endpoints) == 0 {
+		return ""
+	}
+	endpoint, _ := SplitFederatedID(id)
+	return endpoint
+}
+
+// Synced returns whether all caches have been synced with vetes-api
+func (c *Cache) Synced() bool {
+	return c.ClusterCache.SyncStatus().Synced && c.TaskCache.SyncStatus().Synced && c.ExtraPriorityCache.SyncStatus().Synced
+}
+
+// Stale returns error if any cache is not synced yet, or not synced successfully
+// for more than maxStaleness. Scheduling and controlling should be skipped then.
+func (c *Cache) Stale() error {
+	var errs []error
+	for _, item := range []struct {
+		name  string
+		cache interface{ SyncStatus() SyncStatus }
+	}{
+		{name: "cluster", cache: c.ClusterCache},
+		{name: "task", cache: c.TaskCache},
+		{name: "extraPriority", cache: c.ExtraPriorityCache},
+	} {
+		status := item.cache.SyncStatus()
+		if !status.Synced {
+			errs = append(errs, fmt.Errorf("%s cache not synced with vetes-api yet", item.name))
+			continue
+		}
+		if c.maxStaleness > 0 && c.Clock.Since(status.LastSyncTime) > c.maxStaleness {
+			errs = append(errs, fmt.Errorf("%s cache last synced at %s, failed %d times since then", item.name, status.LastSyncTime.Format(time.RFC3339), status.ConsecutiveFailures))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
 }
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/onsi/gomega"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/runner"
+	vetesclientfake "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/fake"
+)
+
+func TestStale(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	clusterCache := &clusterCacheImpl{syncRecorder: syncRecorder{clock: fakeClock}}
+	taskCache := &taskCacheImpl{syncRecorder: syncRecorder{clock: fakeClock}}
+	extraPriorityCache := &extraPriorityCacheImpl{syncRecorder: syncRecorder{clock: fakeClock}}
+	c := &Cache{
+		ClusterCache:       clusterCache,
+		TaskCache:          taskCache,
+		ExtraPriorityCache: extraPriorityCache,
+		Clock:              fakeClock,
+		maxStaleness:       time.Minute,
+	}
+
+	// restored from checkpoint
+	g.Expect(c.Synced()).To(gomega.BeFalse())
+	g.Expect(c.Stale()).To(gomega.HaveOccurred())
+
+	clusterCache.recordSuccess()
+	taskCache.recordSuccess()
+	extraPriorityCache.recordSuccess()
+	g.Expect(c.Synced()).To(gomega.BeTrue())
+	g.Expect(c.Stale()).NotTo(gomega.HaveOccurred())
+
+	// failures within maxStaleness are tolerated
+	taskCache.recordFailure()
+	g.Expect(c.Stale()).NotTo(gomega.HaveOccurred())
+
+	fakeClock.Step(2 * time.Minute)
+	clusterCache.recordSuccess()
+	extraPriorityCache.recordSuccess()
+	taskCache.recordFailure()
+	err := c.Stale()
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("task cache"))
+	g.Expect(err.Error()).To(gomega.ContainSubstring("failed 2 times"))
+
+	// recover automatically
+	taskCache.recordSuccess()
+	g.Expect(c.Stale()).NotTo(gomega.HaveOccurred())
+	g.Expect(taskCache.SyncStatus().ConsecutiveFailures).To(gomega.BeZero())
+
+	// unlimited
+	fakeClock.Step(time.Hour)
+	c.maxStaleness = 0
+	g.Expect(c.Stale()).NotTo(gomega.HaveOccurred())
+}
+
+func TestStaleAfterRestore(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClient.EXPECT().ListClusters(gomock.Any(), gomock.Any()).Return(nil, errors.New("unavailable")).AnyTimes()
+	fakeVeTESClient.EXPECT().ListTasks(gomock.Any(), gomock.Any()).Return(nil, errors.New("unavailable")).AnyTimes()
+	fakeVeTESClient.EXPECT().ListExtraPriority(gomock.Any(), gomock.Any()).Return(nil, errors.New("unavailable")).AnyTimes()
+
+	opts := &Options{SyncPeriod: time.Second}
+	checkpoint := &Checkpoint{Time: fakeClock.Now()}
+	jobs := runner.New(&runner.Options{}, fakeClock)
+	clusterCache, err := NewClusterCache(fakeVeTESClient, opts, checkpoint, fakeClock, jobs)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	taskCache, err := NewTaskCache(fakeVeTESClient, nil, opts, checkpoint, fakeClock, jobs)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	extraPriorityCache, err := NewExtraPriorityCache(fakeVeTESClient, opts, checkpoint, fakeClock, jobs)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	c := &Cache{
+		ClusterCache:       clusterCache,
+		TaskCache:          taskCache,
+		ExtraPriorityCache: extraPriorityCache,
+		Clock:              fakeClock,
+	}
+
+	// stale until a sync succeeds, even if staleness is unlimited
+	for _, maxStaleness := range []time.Duration{0, time.Hour} {
+		c.maxStaleness = maxStaleness
+		jobs.RunDue()
+		g.Expect(c.Stale()).To(gomega.HaveOccurred())
+	}
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/log"
+
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+)
+
+// Checkpoint is the state of caches persisted on disk, so that scheduler can
+// start without vetes-api.
+type Checkpoint struct {
+	Time            time.Time
+	Tasks           []*schemodels.TaskInfo
+	Clusters        []*schemodels.ClusterInfo
+	ExtraPriorities []*schemodels.ExtraPriorityInfo
+}
+
+// LoadCheckpoint returns nil if the checkpoint file does not exist.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := new(Checkpoint)
+	if err = json.Unmarshal(content, checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint: %w", err)
+	}
+	return checkpoint, nil
+}
+
+// SaveCheckpoint writes to a temp file and renames it, so the checkpoint file
+// is never partially written.
+func SaveCheckpoint(path string, checkpoint *Checkpoint) error {
+	content, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }() // no-op after rename
+	if _, err = tmpFile.Write(content); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Sync(); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
+
+func (c *Cache) checkpoint() *Checkpoint {
+	tasks, _ := c.UsageCache.ListTasksWithUsages()
+	return &Checkpoint{
+		Time:            c.Clock.Now(),
+		Tasks:           tasks,
+		Clusters:        c.ClusterCache.ListClusters(),
+		ExtraPriorities: c.ExtraPriorityCache.ListExtraPriorities(),
+	}
+}
+
+func (c *Cache) saveCheckpoint(ctx context.Context, path string) {
+	// do not overwrite checkpoint with the restored one
+	if !c.Synced() {
+		return
+	}
+	if err := SaveCheckpoint(path, c.checkpoint()); err != nil {
+		log.CtxErrorw(ctx, "failed to save checkpoint", "path", path, "err", err)
+	}
+}
+
+func loadCheckpoint(ctx context.Context, path string) *Checkpoint {
+	if path == "" {
+		return nil
+	}
+	checkpoint, err := LoadCheckpoint(path)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to load checkpoint, ignore it", "path", path, "err", err)
+		return nil
+	}
+	if checkpoint != nil {
+		log.CtxInfow(ctx, "restore cache from checkpoint, not ready until synced", "path", path, "checkpointTime", checkpoint.Time)
+	}
+	return checkpoint
+}
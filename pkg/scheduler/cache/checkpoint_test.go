@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/utils"
+)
+
+func TestCheckpoint(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	checkpoint, err := LoadCheckpoint(path)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(checkpoint).To(gomega.BeNil())
+
+	now := time.Now().UTC().Truncate(time.Second)
+	checkpoint = &Checkpoint{
+		Time: now,
+		Tasks: []*schemodels.TaskInfo{{
+			ID:           "task-01",
+			State:        consts.TaskRunning,
+			ClusterID:    "cluster-01",
+			CreationTime: now,
+			Resources:    &schemodels.Resources{CPUCores: 1, GPU: &schemodels.GPUResource{Type: "GPU-A", Count: 1}},
+			BioosInfo:    &schemodels.BioosInfo{AccountID: "account-01"},
+		}},
+		Clusters: []*schemodels.ClusterInfo{{
+			ID:                 "cluster-01",
+			HeartbeatTimestamp: now,
+			Capacity:           &schemodels.Capacity{CPUCores: utils.Point(10)},
+		}},
+		ExtraPriorities: []*schemodels.ExtraPriorityInfo{{AccountID: "account-01", ExtraPriorityValue: 100}},
+	}
+	g.Expect(SaveCheckpoint(path, checkpoint)).To(gomega.Succeed())
+	loaded, err := LoadCheckpoint(path)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(loaded).To(gomega.Equal(checkpoint))
+
+	// no temp file left
+	entries, err := os.ReadDir(filepath.Dir(path))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(entries).To(gomega.HaveLen(1))
+
+	g.Expect(os.WriteFile(path, []byte("{"), 0o600)).To(gomega.Succeed())
+	_, err = LoadCheckpoint(path)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
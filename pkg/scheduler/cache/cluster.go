@@ -6,9 +6,10 @@ import (
 	"time"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/log"
+	"k8s.io/utils/clock"
 
-	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/crontab"
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/runner"
 	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
 	clientmodels "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
 )
@@ -16,11 +17,13 @@ import (
 // ClusterCache caches cluster info
 type ClusterCache interface {
 	ListClusters() []*schemodels.ClusterInfo
+	SyncStatus() SyncStatus
 }
 
 // clusterCacheImpl ...
 type clusterCacheImpl struct {
 	vetesClient vetesclient.Client
+	syncRecorder
 
 	mutex    sync.RWMutex
 	clusters []*schemodels.ClusterInfo
@@ -28,19 +31,25 @@ type clusterCacheImpl struct {
 
 var _ ClusterCache = (*clusterCacheImpl)(nil)
 
-// NewClusterCache ...
-func NewClusterCache(vetesClient vetesclient.Client, opts *Options) (ClusterCache, error) {
+// NewClusterCache restores from checkpoint if not nil, or syncs at once.
+func NewClusterCache(vetesClient vetesclient.Client, opts *Options, checkpoint *Checkpoint, clk clock.PassiveClock, jobs runner.Registerer) (ClusterCache, error) {
 	cache := &clusterCacheImpl{
-		vetesClient: vetesClient,
+		vetesClient:  vetesClient,
+		syncRecorder: syncRecorder{clock: clk},
 	}
-	if err := cache.syncClusters(context.Background()); err != nil {
+	if checkpoint != nil {
+		cache.clusters = checkpoint.Clusters
+	} else if err := cache.syncClusters(context.Background()); err != nil {
 		return nil, err
 	}
-	if err := crontab.RegisterCron(opts.SyncPeriod, func() {
-		ctx := context.Background()
-		if err := cache.syncClusters(ctx); err != nil {
-			log.CtxErrorw(ctx, "failed to sync clusters", "err", err)
+	if err := jobs.Register("cluster-cache-sync", opts.SyncPeriod, func() error {
+		// failures are reported by staleness on readiness instead of the job
+		// health, so that a vetes-api outage does not restart the scheduler
+		if err := cache.syncClusters(context.Background()); err != nil {
+			cache.recordFailure()
+			log.Warnw("failed to sync clusters", "err", err)
 		}
+		return nil
 	}); err != nil {
 		return nil, err
 	}
@@ -67,6 +76,7 @@ func (i *clusterCacheImpl) syncClusters(ctx context.Context) error {
 	i.mutex.Lock()
 	defer i.mutex.Unlock()
 	i.clusters = clusters
+	i.recordSuccess()
 	return nil
 }
 
@@ -78,6 +88,7 @@ func clientClusterToClusterInfo(ctx context.Context, cluster *clientmodels.Clust
 		ID:       cluster.ID,
 		Capacity: clientClusterCapacityToClusterInfoCapacity(cluster.Capacity),
 		Limits:   clientClusterLimitsToClusterInfoLimits(cluster.Limits),
+		Price:    clientClusterPriceToClusterInfoPrice(cluster.Price),
 	}
 	if cluster.HeartbeatTimestamp != "" {
 		var err error
@@ -117,3 +128,15 @@ func clientClusterLimitsToClusterInfoLimits(limits *clientmodels.Limits) *schemo
 	}
 	return res
 }
+
+func clientClusterPriceToClusterInfoPrice(price *clientmodels.Price) *schemodels.Price {
+	if price == nil {
+		return nil
+	}
+	return &schemodels.Price{
+		CPUCoreHour: price.CPUCoreHour,
+		RamGBHour:   price.RamGBHour,
+		DiskGBHour:  price.DiskGBHour,
+		GPUHour:     price.GPUHour,
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/onsi/gomega"
+	"k8s.io/utils/clock"
 
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 	"github.com/GBA-BI/tes-scheduler/pkg/utils"
@@ -39,9 +40,15 @@ func TestSyncClusters(t *testing.T) {
 				RamGB:    utils.Point[float64](10),
 				GPULimit: &clientmodels.GPULimit{GPU: map[string]float64{"type-01": 1}},
 			},
+			Price: &clientmodels.Price{
+				CPUCoreHour: 0.1,
+				RamGBHour:   0.01,
+				DiskGBHour:  0.001,
+				GPUHour:     map[string]float64{"type-01": 2},
+			},
 		}}, nil)
 
-	i := &clusterCacheImpl{vetesClient: fakeVeTESClient, clusters: make([]*schemodels.ClusterInfo, 0)}
+	i := &clusterCacheImpl{vetesClient: fakeVeTESClient, syncRecorder: syncRecorder{clock: clock.RealClock{}}, clusters: make([]*schemodels.ClusterInfo, 0)}
 	err := i.syncClusters(context.TODO())
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 	g.Expect(i.clusters).To(gomega.BeEquivalentTo([]*schemodels.ClusterInfo{{
@@ -59,6 +66,12 @@ func TestSyncClusters(t *testing.T) {
 			RamGB:    utils.Point[float64](10),
 			GPULimit: &schemodels.GPULimit{GPU: map[string]float64{"type-01": 1}},
 		},
+		Price: &schemodels.Price{
+			CPUCoreHour: 0.1,
+			RamGBHour:   0.01,
+			DiskGBHour:  0.001,
+			GPUHour:     map[string]float64{"type-01": 2},
+		},
 	}}))
 }
 
@@ -3,11 +3,13 @@ package cache
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/log"
+	"k8s.io/utils/clock"
 
-	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/crontab"
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/runner"
 	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
 	clientmodels "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
 )
@@ -15,31 +17,39 @@ import (
 // ExtraPriorityCache ...
 type ExtraPriorityCache interface {
 	ListExtraPriorities() []*schemodels.ExtraPriorityInfo
+	GetExtraPriorityIndex() *schemodels.ExtraPriorityIndex
+	SyncStatus() SyncStatus
 }
 
 // extraPriorityCacheImpl ...
 type extraPriorityCacheImpl struct {
 	vetesClient vetesclient.Client
+	syncRecorder
 
-	mutex           sync.RWMutex
-	extraPriorities []*schemodels.ExtraPriorityInfo
+	mutex sync.RWMutex
+	index *schemodels.ExtraPriorityIndex
 }
 
 var _ ExtraPriorityCache = (*extraPriorityCacheImpl)(nil)
 
-// NewExtraPriorityCache ...
-func NewExtraPriorityCache(vetesClient vetesclient.Client, opts *Options) (ExtraPriorityCache, error) {
+// NewExtraPriorityCache restores from checkpoint if not nil, or syncs at once.
+func NewExtraPriorityCache(vetesClient vetesclient.Client, opts *Options, checkpoint *Checkpoint, clk clock.PassiveClock, jobs runner.Registerer) (ExtraPriorityCache, error) {
 	cache := &extraPriorityCacheImpl{
-		vetesClient: vetesClient,
+		vetesClient:  vetesClient,
+		syncRecorder: syncRecorder{clock: clk},
 	}
-	if err := cache.syncExtraPriorities(context.Background()); err != nil {
+	if checkpoint != nil {
+		cache.index = schemodels.NewExtraPriorityIndex(checkpoint.ExtraPriorities)
+	} else if err := cache.syncExtraPriorities(context.Background()); err != nil {
 		return nil, err
 	}
-	if err := crontab.RegisterCron(opts.SyncPeriod, func() {
-		ctx := context.Background()
-		if err := cache.syncExtraPriorities(ctx); err != nil {
-			log.CtxErrorw(ctx, "failed to sync extraPriorities", "err", err)
+	if err := jobs.Register("extra-priority-cache-sync", opts.SyncPeriod, func() error {
+		// failures are reported by staleness on readiness, like cluster cache
+		if err := cache.syncExtraPriorities(context.Background()); err != nil {
+			cache.recordFailure()
+			log.Warnw("failed to sync extraPriorities", "err", err)
 		}
+		return nil
 	}); err != nil {
 		return nil, err
 	}
@@ -50,34 +60,61 @@ func NewExtraPriorityCache(vetesClient vetesclient.Client, opts *Options) (Extra
 func (i *extraPriorityCacheImpl) ListExtraPriorities() []*schemodels.ExtraPriorityInfo {
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
-	return i.extraPriorities
+	return i.index.List()
+}
+
+// GetExtraPriorityIndex ...
+func (i *extraPriorityCacheImpl) GetExtraPriorityIndex() *schemodels.ExtraPriorityIndex {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.index
 }
 
 func (i *extraPriorityCacheImpl) syncExtraPriorities(ctx context.Context) error {
-	extraPriorities := make([]*schemodels.ExtraPriorityInfo, 0, len(i.extraPriorities))
 	resp, err := i.vetesClient.ListExtraPriority(ctx, &clientmodels.ListExtraPriorityRequest{})
 	if err != nil {
 		return err
 	}
+	extraPriorities := make([]*schemodels.ExtraPriorityInfo, 0, len(*resp))
 	for _, extraPriority := range *resp {
-		extraPriorities = append(extraPriorities, clientExtraPriorityToExtraPriorityInfo(extraPriority))
+		if info := clientExtraPriorityToExtraPriorityInfo(ctx, extraPriority); info != nil {
+			extraPriorities = append(extraPriorities, info)
+		}
 	}
 
 	i.mutex.Lock()
 	defer i.mutex.Unlock()
-	i.extraPriorities = extraPriorities
+	i.index = schemodels.NewExtraPriorityIndex(extraPriorities)
+	i.recordSuccess()
 	return nil
 }
 
-func clientExtraPriorityToExtraPriorityInfo(extraPriority *clientmodels.ExtraPriority) *schemodels.ExtraPriorityInfo {
+// clientExtraPriorityToExtraPriorityInfo returns nil if the validity cannot be parsed,
+// because applying it without bounds may be unexpected.
+func clientExtraPriorityToExtraPriorityInfo(ctx context.Context, extraPriority *clientmodels.ExtraPriority) *schemodels.ExtraPriorityInfo {
 	if extraPriority == nil {
 		return nil
 	}
-	return &schemodels.ExtraPriorityInfo{
+	res := &schemodels.ExtraPriorityInfo{
 		AccountID:          extraPriority.AccountID,
 		UserID:             extraPriority.UserID,
 		SubmissionID:       extraPriority.SubmissionID,
 		RunID:              extraPriority.RunID,
 		ExtraPriorityValue: extraPriority.ExtraPriorityValue,
+		Reason:             extraPriority.Reason,
+	}
+	var err error
+	if extraPriority.StartTime != "" {
+		if res.StartTime, err = time.Parse(time.RFC3339, extraPriority.StartTime); err != nil {
+			log.CtxErrorw(ctx, "parse start time of extraPriority, ignore it", "extraPriority", extraPriority, "err", err)
+			return nil
+		}
+	}
+	if extraPriority.ExpireTime != "" {
+		if res.ExpireTime, err = time.Parse(time.RFC3339, extraPriority.ExpireTime); err != nil {
+			log.CtxErrorw(ctx, "parse expire time of extraPriority, ignore it", "extraPriority", extraPriority, "err", err)
+			return nil
+		}
 	}
+	return res
 }
@@ -3,9 +3,11 @@ package cache
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/onsi/gomega"
+	"k8s.io/utils/clock"
 
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 	vetesclientfake "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/fake"
@@ -23,20 +25,40 @@ func TestSyncExtraPriorities(t *testing.T) {
 		Return(&clientmodels.ListExtraPriorityResponse{{
 			SubmissionID:       "submission-01",
 			ExtraPriorityValue: -100,
+		}, {
+			RunID:              "run-01",
+			ExtraPriorityValue: 100,
+			StartTime:          "2024-01-01T00:00:00Z",
+			ExpireTime:         "2024-01-02T00:00:00Z",
+			Reason:             "urgent",
+		}, {
+			RunID:              "run-02",
+			ExtraPriorityValue: 100,
+			ExpireTime:         "invalid",
 		}}, nil)
 
-	i := &extraPriorityCacheImpl{vetesClient: fakeVeTESClient, extraPriorities: make([]*schemodels.ExtraPriorityInfo, 0)}
+	i := &extraPriorityCacheImpl{vetesClient: fakeVeTESClient, syncRecorder: syncRecorder{clock: clock.RealClock{}}}
 	err := i.syncExtraPriorities(context.TODO())
 	g.Expect(err).NotTo(gomega.HaveOccurred())
-	g.Expect(i.extraPriorities).To(gomega.BeEquivalentTo([]*schemodels.ExtraPriorityInfo{{
+	// invalid validity is ignored
+	g.Expect(i.ListExtraPriorities()).To(gomega.BeEquivalentTo([]*schemodels.ExtraPriorityInfo{{
 		SubmissionID:       "submission-01",
 		ExtraPriorityValue: -100,
+	}, {
+		RunID:              "run-01",
+		ExtraPriorityValue: 100,
+		StartTime:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ExpireTime:         time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Reason:             "urgent",
 	}}))
+	g.Expect(i.GetExtraPriorityIndex().EffectivePriorityAt(&schemodels.TaskInfo{
+		BioosInfo: &schemodels.BioosInfo{SubmissionID: "submission-01"},
+	}, time.Now())).To(gomega.Equal(-100))
 }
 
 func TestListExtraPriorities(t *testing.T) {
 	g := gomega.NewWithT(t)
-	i := &extraPriorityCacheImpl{extraPriorities: []*schemodels.ExtraPriorityInfo{{RunID: "run-01", ExtraPriorityValue: 10}}}
+	i := &extraPriorityCacheImpl{index: schemodels.NewExtraPriorityIndex([]*schemodels.ExtraPriorityInfo{{RunID: "run-01", ExtraPriorityValue: 10}})}
 	resp := i.ListExtraPriorities()
 	g.Expect(resp).To(gomega.BeEquivalentTo([]*schemodels.ExtraPriorityInfo{{RunID: "run-01", ExtraPriorityValue: 10}}))
 }
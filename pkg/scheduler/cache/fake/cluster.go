@@ -7,6 +7,7 @@ package fake
 import (
 	reflect "reflect"
 
+	cache "github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
 	models "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -47,3 +48,17 @@ func (mr *FakeClusterCacheMockRecorder) ListClusters() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClusters", reflect.TypeOf((*FakeClusterCache)(nil).ListClusters))
 }
+
+// SyncStatus mocks base method.
+func (m *FakeClusterCache) SyncStatus() cache.SyncStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncStatus")
+	ret0, _ := ret[0].(cache.SyncStatus)
+	return ret0
+}
+
+// SyncStatus indicates an expected call of SyncStatus.
+func (mr *FakeClusterCacheMockRecorder) SyncStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncStatus", reflect.TypeOf((*FakeClusterCache)(nil).SyncStatus))
+}
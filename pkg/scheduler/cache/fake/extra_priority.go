@@ -7,6 +7,7 @@ package fake
 import (
 	reflect "reflect"
 
+	cache "github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
 	models "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,6 +35,20 @@ func (m *FakeExtraPriorityCache) EXPECT() *FakeExtraPriorityCacheMockRecorder {
 	return m.recorder
 }
 
+// GetExtraPriorityIndex mocks base method.
+func (m *FakeExtraPriorityCache) GetExtraPriorityIndex() *models.ExtraPriorityIndex {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExtraPriorityIndex")
+	ret0, _ := ret[0].(*models.ExtraPriorityIndex)
+	return ret0
+}
+
+// GetExtraPriorityIndex indicates an expected call of GetExtraPriorityIndex.
+func (mr *FakeExtraPriorityCacheMockRecorder) GetExtraPriorityIndex() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExtraPriorityIndex", reflect.TypeOf((*FakeExtraPriorityCache)(nil).GetExtraPriorityIndex))
+}
+
 // ListExtraPriorities mocks base method.
 func (m *FakeExtraPriorityCache) ListExtraPriorities() []*models.ExtraPriorityInfo {
 	m.ctrl.T.Helper()
@@ -47,3 +62,17 @@ func (mr *FakeExtraPriorityCacheMockRecorder) ListExtraPriorities() *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExtraPriorities", reflect.TypeOf((*FakeExtraPriorityCache)(nil).ListExtraPriorities))
 }
+
+// SyncStatus mocks base method.
+func (m *FakeExtraPriorityCache) SyncStatus() cache.SyncStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncStatus")
+	ret0, _ := ret[0].(cache.SyncStatus)
+	return ret0
+}
+
+// SyncStatus indicates an expected call of SyncStatus.
+func (mr *FakeExtraPriorityCacheMockRecorder) SyncStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncStatus", reflect.TypeOf((*FakeExtraPriorityCache)(nil).SyncStatus))
+}
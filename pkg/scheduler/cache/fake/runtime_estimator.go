@@ -0,0 +1,63 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/scheduler/cache/runtime_estimator.go
+
+// Package fake is a generated GoMock package.
+package fake
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// FakeRuntimeEstimator is a mock of RuntimeEstimator interface.
+type FakeRuntimeEstimator struct {
+	ctrl     *gomock.Controller
+	recorder *FakeRuntimeEstimatorMockRecorder
+}
+
+// FakeRuntimeEstimatorMockRecorder is the mock recorder for FakeRuntimeEstimator.
+type FakeRuntimeEstimatorMockRecorder struct {
+	mock *FakeRuntimeEstimator
+}
+
+// NewFakeRuntimeEstimator creates a new mock instance.
+func NewFakeRuntimeEstimator(ctrl *gomock.Controller) *FakeRuntimeEstimator {
+	mock := &FakeRuntimeEstimator{ctrl: ctrl}
+	mock.recorder = &FakeRuntimeEstimatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *FakeRuntimeEstimator) EXPECT() *FakeRuntimeEstimatorMockRecorder {
+	return m.recorder
+}
+
+// Estimate mocks base method.
+func (m *FakeRuntimeEstimator) Estimate(task *models.TaskInfo) (time.Duration, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Estimate", task)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Estimate indicates an expected call of Estimate.
+func (mr *FakeRuntimeEstimatorMockRecorder) Estimate(task interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Estimate", reflect.TypeOf((*FakeRuntimeEstimator)(nil).Estimate), task)
+}
+
+// Observe mocks base method.
+func (m *FakeRuntimeEstimator) Observe(task *models.TaskInfo, duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Observe", task, duration)
+}
+
+// Observe indicates an expected call of Observe.
+func (mr *FakeRuntimeEstimatorMockRecorder) Observe(task, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Observe", reflect.TypeOf((*FakeRuntimeEstimator)(nil).Observe), task, duration)
+}
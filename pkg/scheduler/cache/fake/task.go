@@ -8,6 +8,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	cache "github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
 	models "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,6 +36,34 @@ func (m *FakeTaskCache) EXPECT() *FakeTaskCacheMockRecorder {
 	return m.recorder
 }
 
+// BatchUpdateTasks mocks base method.
+func (m *FakeTaskCache) BatchUpdateTasks(ctx context.Context, updates []*cache.TaskUpdate) map[string]error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchUpdateTasks", ctx, updates)
+	ret0, _ := ret[0].(map[string]error)
+	return ret0
+}
+
+// BatchUpdateTasks indicates an expected call of BatchUpdateTasks.
+func (mr *FakeTaskCacheMockRecorder) BatchUpdateTasks(ctx, updates interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchUpdateTasks", reflect.TypeOf((*FakeTaskCache)(nil).BatchUpdateTasks), ctx, updates)
+}
+
+// GetUsage mocks base method.
+func (m *FakeTaskCache) GetUsage(key cache.UsageKey) *models.Usage {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", key)
+	ret0, _ := ret[0].(*models.Usage)
+	return ret0
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *FakeTaskCacheMockRecorder) GetUsage(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*FakeTaskCache)(nil).GetUsage), key)
+}
+
 // ListScheduledTasks mocks base method.
 func (m *FakeTaskCache) ListScheduledTasks() []*models.TaskInfo {
 	m.ctrl.T.Helper()
@@ -77,6 +106,35 @@ func (mr *FakeTaskCacheMockRecorder) ListTasks(clusterID interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasks", reflect.TypeOf((*FakeTaskCache)(nil).ListTasks), clusterID)
 }
 
+// ListTasksWithUsages mocks base method.
+func (m *FakeTaskCache) ListTasksWithUsages() ([]*models.TaskInfo, map[cache.UsageKey]*models.Usage) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTasksWithUsages")
+	ret0, _ := ret[0].([]*models.TaskInfo)
+	ret1, _ := ret[1].(map[cache.UsageKey]*models.Usage)
+	return ret0, ret1
+}
+
+// ListTasksWithUsages indicates an expected call of ListTasksWithUsages.
+func (mr *FakeTaskCacheMockRecorder) ListTasksWithUsages() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasksWithUsages", reflect.TypeOf((*FakeTaskCache)(nil).ListTasksWithUsages))
+}
+
+// SyncStatus mocks base method.
+func (m *FakeTaskCache) SyncStatus() cache.SyncStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncStatus")
+	ret0, _ := ret[0].(cache.SyncStatus)
+	return ret0
+}
+
+// SyncStatus indicates an expected call of SyncStatus.
+func (mr *FakeTaskCacheMockRecorder) SyncStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncStatus", reflect.TypeOf((*FakeTaskCache)(nil).SyncStatus))
+}
+
 // UpdateTask mocks base method.
 func (m *FakeTaskCache) UpdateTask(ctx context.Context, id string, state, clusterID, message *string) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,313 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/utils/clock"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/runner"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
+)
+
+// FederatedID returns the ID of task or cluster namespaced by endpoint
+func FederatedID(endpoint, id string) string {
+	return endpoint + consts.FederatedIDSeparator + id
+}
+
+// SplitFederatedID returns the endpoint and the ID in the endpoint, empty endpoint
+// if id is not namespaced.
+func SplitFederatedID(id string) (string, string) {
+	endpoint, localID, ok := strings.Cut(id, consts.FederatedIDSeparator)
+	if !ok {
+		return "", id
+	}
+	return endpoint, localID
+}
+
+// NewFederatedCache has task and cluster caches of each endpoint, whose task and
+// cluster IDs are namespaced by endpoint. Quotas and extra priorities are read
+// from the primary endpoint.
+func NewFederatedCache(vetesClients map[string]vetesclient.Client, opts *Options, clk clock.PassiveClock, jobs runner.Registerer) (*Cache, error) {
+	if opts.CheckpointPath != "" {
+		return nil, fmt.Errorf("checkpoint is not supported in federation")
+	}
+	f := newFederation(vetesClients)
+	primary := opts.PrimaryEndpoint
+	if primary == "" {
+		primary = f.endpoints[0]
+	}
+	if _, ok := vetesClients[primary]; !ok {
+		return nil, fmt.Errorf("unknown primary endpoint: %s", primary)
+	}
+
+	runtimeEstimator := NewRuntimeEstimator()
+	clusterCache := &federatedClusterCache{federation: f, members: make(map[string]ClusterCache, len(f.endpoints))}
+	taskCache := &federatedTaskCache{federation: f, members: make(map[string]TaskCache, len(f.endpoints))}
+	for _, endpoint := range f.endpoints {
+		var err error
+		endpointJobs := runner.WithPrefix(jobs, endpoint)
+		if clusterCache.members[endpoint], err = NewClusterCache(vetesClients[endpoint], opts, nil, clk, endpointJobs); err != nil {
+			return nil, fmt.Errorf("endpoint %s: %w", endpoint, err)
+		}
+		if taskCache.members[endpoint], err = NewTaskCache(vetesClients[endpoint], runtimeEstimator, opts, nil, clk, endpointJobs); err != nil {
+			return nil, fmt.Errorf("endpoint %s: %w", endpoint, err)
+		}
+	}
+	extraPriorityCache, err := NewExtraPriorityCache(vetesClients[primary], opts, nil, clk, jobs)
+	if err != nil {
+		return nil, err
+	}
+	quotaCache, err := NewQuotaCache(vetesClients[primary], opts, clk, jobs)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{
+		ClusterCache:       clusterCache,
+		TaskCache:          taskCache,
+		UsageCache:         taskCache,
+		ExtraPriorityCache: extraPriorityCache,
+		QuotaCache:         quotaCache,
+		RuntimeEstimator:   runtimeEstimator,
+		Endpoints:          f.endpoints,
+		Clock:              clk,
+		maxStaleness:       opts.MaxStaleness,
+	}, nil
+}
+
+// federation translates IDs between the federated caches and caches of endpoints.
+// Tasks are only placed on clusters of their own endpoint, so a cluster ID of task
+// is always namespaced by the task endpoint.
+type federation struct {
+	// endpoints are sorted
+	endpoints []string
+}
+
+func newFederation[T any](members map[string]T) *federation {
+	f := &federation{}
+	for endpoint := range members {
+		f.endpoints = append(f.endpoints, endpoint)
+	}
+	sort.Strings(f.endpoints)
+	return f
+}
+
+// federatedClusterID translates the cluster ID of a task of endpoint to the federated one
+func (f *federation) federatedClusterID(endpoint, clusterID string) string {
+	if clusterID == "" {
+		return ""
+	}
+	return FederatedID(endpoint, clusterID)
+}
+
+// localClusterID translates a federated cluster ID to the one of tasks of endpoint,
+// and returns false if the cluster is registered with another endpoint.
+func (f *federation) localClusterID(endpoint, clusterID string) (string, bool) {
+	if clusterID == "" {
+		return "", true
+	}
+	clusterEndpoint, localID := SplitFederatedID(clusterID)
+	return localID, clusterEndpoint == endpoint
+}
+
+func (f *federation) federatedTask(endpoint string, task *schemodels.TaskInfo) *schemodels.TaskInfo {
+	res := *task
+	res.ID = FederatedID(endpoint, task.ID)
+	res.ClusterID = f.federatedClusterID(endpoint, task.ClusterID)
+	return &res
+}
+
+func (f *federation) federatedTasks(endpoint string, tasks []*schemodels.TaskInfo) []*schemodels.TaskInfo {
+	res := make([]*schemodels.TaskInfo, 0, len(tasks))
+	for _, task := range tasks {
+		res = append(res, f.federatedTask(endpoint, task))
+	}
+	return res
+}
+
+// mergeSyncStatus is synced only if all are synced, and as old as the oldest one
+func mergeSyncStatus(statuses []SyncStatus) SyncStatus {
+	res := SyncStatus{Synced: true}
+	for i, status := range statuses {
+		res.Synced = res.Synced && status.Synced
+		if i == 0 || status.LastSyncTime.Before(res.LastSyncTime) {
+			res.LastSyncTime = status.LastSyncTime
+		}
+		if status.ConsecutiveFailures > res.ConsecutiveFailures {
+			res.ConsecutiveFailures = status.ConsecutiveFailures
+		}
+	}
+	return res
+}
+
+// federatedClusterCache ...
+type federatedClusterCache struct {
+	*federation
+	members map[string]ClusterCache
+}
+
+var _ ClusterCache = (*federatedClusterCache)(nil)
+
+// ListClusters ...
+func (c *federatedClusterCache) ListClusters() []*schemodels.ClusterInfo {
+	res := make([]*schemodels.ClusterInfo, 0)
+	for _, endpoint := range c.endpoints {
+		for _, cluster := range c.members[endpoint].ListClusters() {
+			federated := *cluster
+			federated.ID = FederatedID(endpoint, cluster.ID)
+			res = append(res, &federated)
+		}
+	}
+	return res
+}
+
+// SyncStatus ...
+func (c *federatedClusterCache) SyncStatus() SyncStatus {
+	statuses := make([]SyncStatus, 0, len(c.endpoints))
+	for _, endpoint := range c.endpoints {
+		statuses = append(statuses, c.members[endpoint].SyncStatus())
+	}
+	return mergeSyncStatus(statuses)
+}
+
+// federatedTaskCache ...
+type federatedTaskCache struct {
+	*federation
+	members map[string]TaskCache
+}
+
+var _ TaskCache = (*federatedTaskCache)(nil)
+
+// GetUsage sums up usages of all endpoints
+func (c *federatedTaskCache) GetUsage(key UsageKey) *schemodels.Usage {
+	res := &schemodels.Usage{}
+	for _, endpoint := range c.endpoints {
+		localKey := key
+		var ok bool
+		if localKey.ClusterID, ok = c.localClusterID(endpoint, key.ClusterID); !ok {
+			continue
+		}
+		res.Merge(c.members[endpoint].GetUsage(localKey))
+	}
+	return res
+}
+
+// ListTasksWithUsages ...
+func (c *federatedTaskCache) ListTasksWithUsages() ([]*schemodels.TaskInfo, map[UsageKey]*schemodels.Usage) {
+	tasks := make([]*schemodels.TaskInfo, 0)
+	usages := make(map[UsageKey]*schemodels.Usage)
+	for _, endpoint := range c.endpoints {
+		localTasks, localUsages := c.members[endpoint].ListTasksWithUsages()
+		tasks = append(tasks, c.federatedTasks(endpoint, localTasks)...)
+		for key, usage := range localUsages {
+			key.ClusterID = c.federatedClusterID(endpoint, key.ClusterID)
+			if _, ok := usages[key]; !ok {
+				usages[key] = &schemodels.Usage{}
+			}
+			usages[key].Merge(usage)
+		}
+	}
+	return tasks, usages
+}
+
+// ListTasks ...
+func (c *federatedTaskCache) ListTasks(clusterID string) []*schemodels.TaskInfo {
+	res := make([]*schemodels.TaskInfo, 0)
+	for _, endpoint := range c.endpoints {
+		localClusterID, ok := c.localClusterID(endpoint, clusterID)
+		if !ok {
+			continue
+		}
+		res = append(res, c.federatedTasks(endpoint, c.members[endpoint].ListTasks(localClusterID))...)
+	}
+	return res
+}
+
+// ListScheduledTasks ...
+func (c *federatedTaskCache) ListScheduledTasks() []*schemodels.TaskInfo {
+	res := make([]*schemodels.TaskInfo, 0)
+	for _, endpoint := range c.endpoints {
+		res = append(res, c.federatedTasks(endpoint, c.members[endpoint].ListScheduledTasks())...)
+	}
+	return res
+}
+
+// ListTaskClusterIDs ...
+func (c *federatedTaskCache) ListTaskClusterIDs() []string {
+	res := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, endpoint := range c.endpoints {
+		for _, clusterID := range c.members[endpoint].ListTaskClusterIDs() {
+			clusterID = c.federatedClusterID(endpoint, clusterID)
+			if _, ok := seen[clusterID]; ok {
+				continue
+			}
+			seen[clusterID] = struct{}{}
+			res = append(res, clusterID)
+		}
+	}
+	return res
+}
+
+// SyncStatus ...
+func (c *federatedTaskCache) SyncStatus() SyncStatus {
+	statuses := make([]SyncStatus, 0, len(c.endpoints))
+	for _, endpoint := range c.endpoints {
+		statuses = append(statuses, c.members[endpoint].SyncStatus())
+	}
+	return mergeSyncStatus(statuses)
+}
+
+// UpdateTask ...
+func (c *federatedTaskCache) UpdateTask(ctx context.Context, id string, state, clusterID, message *string) error {
+	endpoint, localID := SplitFederatedID(id)
+	member, ok := c.members[endpoint]
+	if !ok {
+		return fmt.Errorf("unknown endpoint of task %s", id)
+	}
+	if clusterID != nil {
+		localClusterID, ok := c.localClusterID(endpoint, *clusterID)
+		if !ok {
+			return fmt.Errorf("cluster %s is not registered with endpoint of task %s", *clusterID, id)
+		}
+		clusterID = &localClusterID
+	}
+	return member.UpdateTask(ctx, localID, state, clusterID, message)
+}
+
+// BatchUpdateTasks updates tasks of each endpoint in a batch
+func (c *federatedTaskCache) BatchUpdateTasks(ctx context.Context, updates []*TaskUpdate) map[string]error {
+	res := make(map[string]error)
+	localUpdates := make(map[string][]*TaskUpdate)
+	for _, update := range updates {
+		endpoint, localID := SplitFederatedID(update.ID)
+		if _, ok := c.members[endpoint]; !ok {
+			res[update.ID] = fmt.Errorf("unknown endpoint of task %s", update.ID)
+			continue
+		}
+		localUpdate := *update
+		localUpdate.ID = localID
+		if update.ClusterID != nil {
+			localClusterID, ok := c.localClusterID(endpoint, *update.ClusterID)
+			if !ok {
+				res[update.ID] = fmt.Errorf("cluster %s is not registered with endpoint of task %s", *update.ClusterID, update.ID)
+				continue
+			}
+			localUpdate.ClusterID = &localClusterID
+		}
+		localUpdates[endpoint] = append(localUpdates[endpoint], &localUpdate)
+	}
+	for _, endpoint := range c.endpoints {
+		if len(localUpdates[endpoint]) == 0 {
+			continue
+		}
+		for localID, err := range c.members[endpoint].BatchUpdateTasks(ctx, localUpdates[endpoint]) {
+			res[FederatedID(endpoint, localID)] = err
+		}
+	}
+	return res
+}
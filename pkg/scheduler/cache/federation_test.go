@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/onsi/gomega"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/utils"
+	vetesclientfake "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/fake"
+	clientmodels "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+func newFederationTestTaskCache(vetesClient *vetesclientfake.FakeClient, tasks ...*schemodels.TaskInfo) *taskCacheImpl {
+	i := &taskCacheImpl{
+		vetesClient: vetesClient,
+		data: &data{
+			tasks:          make(map[string]*schemodels.TaskInfo),
+			clusterIndexer: make(map[string]map[string]struct{}),
+			usages:         make(map[UsageKey]*schemodels.Usage),
+		},
+	}
+	for _, task := range tasks {
+		i.data.addTask(task)
+	}
+	return i
+}
+
+func TestSplitFederatedID(t *testing.T) {
+	g := gomega.NewWithT(t)
+	endpoint, id := SplitFederatedID(FederatedID("endpoint-a", "task-01"))
+	g.Expect(endpoint).To(gomega.Equal("endpoint-a"))
+	g.Expect(id).To(gomega.Equal("task-01"))
+	endpoint, id = SplitFederatedID("task-01")
+	g.Expect(endpoint).To(gomega.BeEmpty())
+	g.Expect(id).To(gomega.Equal("task-01"))
+}
+
+func TestFederatedTaskCache(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	bioosInfo := &schemodels.BioosInfo{AccountID: "account-01"}
+	resources := &schemodels.Resources{CPUCores: 1}
+	c := &federatedTaskCache{
+		federation: newFederation(map[string]struct{}{"endpoint-a": {}, "endpoint-b": {}}),
+		members: map[string]TaskCache{
+			"endpoint-a": newFederationTestTaskCache(nil,
+				&schemodels.TaskInfo{ID: "task-01", State: consts.TaskQueued},
+				&schemodels.TaskInfo{ID: "task-02", State: consts.TaskRunning, ClusterID: "cluster-01", BioosInfo: bioosInfo, Resources: resources},
+			),
+			"endpoint-b": newFederationTestTaskCache(nil,
+				&schemodels.TaskInfo{ID: "task-01", State: consts.TaskRunning, ClusterID: "cluster-01", BioosInfo: bioosInfo, Resources: resources},
+				&schemodels.TaskInfo{ID: "task-02", State: consts.TaskRunning, ClusterID: "cluster-01", BioosInfo: bioosInfo, Resources: resources},
+			),
+		},
+	}
+
+	g.Expect(c.ListTasks("")).To(gomega.BeEquivalentTo([]*schemodels.TaskInfo{{ID: "endpoint-a/task-01", State: consts.TaskQueued}}))
+	g.Expect(c.ListTasks("endpoint-a/cluster-01")).To(gomega.BeEquivalentTo([]*schemodels.TaskInfo{
+		{ID: "endpoint-a/task-02", State: consts.TaskRunning, ClusterID: "endpoint-a/cluster-01", BioosInfo: bioosInfo, Resources: resources},
+	}))
+	g.Expect(c.ListTasks("endpoint-b/cluster-01")).To(gomega.HaveLen(2))
+	g.Expect(c.ListScheduledTasks()).To(gomega.HaveLen(3))
+	g.Expect(c.ListTaskClusterIDs()).To(gomega.ConsistOf("endpoint-a/cluster-01", "endpoint-b/cluster-01"))
+
+	g.Expect(c.GetUsage(UsageKey{}).Count).To(gomega.Equal(3))
+	g.Expect(c.GetUsage(UsageKey{ClusterID: "endpoint-a/cluster-01"}).CPUCores).To(gomega.Equal(1))
+	g.Expect(c.GetUsage(UsageKey{ClusterID: "endpoint-b/cluster-01"}).CPUCores).To(gomega.Equal(2))
+	g.Expect(c.GetUsage(UsageKey{AccountID: "account-01"}).Count).To(gomega.Equal(3))
+	tasks, usages := c.ListTasksWithUsages()
+	g.Expect(tasks).To(gomega.HaveLen(4))
+	g.Expect(usages[UsageKey{ClusterID: "endpoint-a/cluster-01"}].Count).To(gomega.Equal(1))
+	g.Expect(usages[UsageKey{ClusterID: "endpoint-b/cluster-01"}].Count).To(gomega.Equal(2))
+	g.Expect(usages[UsageKey{AccountID: "account-01"}].Count).To(gomega.Equal(3))
+}
+
+func TestFederatedTaskCacheUpdate(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeVeTESClientA := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClientA.EXPECT().
+		UpdateTask(gomock.Any(), &clientmodels.UpdateTaskRequest{ID: "task-01", ClusterID: utils.Point("cluster-01"), ExpectedState: utils.Point(consts.TaskQueued)}).
+		Return(&clientmodels.UpdateTaskResponse{}, nil)
+	fakeVeTESClientB := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClientB.EXPECT().
+		BatchUpdateTasks(gomock.Any(), &clientmodels.BatchUpdateTasksRequest{Tasks: []*clientmodels.UpdateTaskRequest{
+			{ID: "task-01", ClusterID: utils.Point("cluster-01"), ExpectedState: utils.Point(consts.TaskQueued)},
+		}}).
+		Return(&clientmodels.BatchUpdateTasksResponse{Results: []*clientmodels.BatchUpdateTaskResult{
+			{ID: "task-01", StatusCode: http.StatusOK},
+		}}, nil)
+
+	c := &federatedTaskCache{
+		federation: newFederation(map[string]struct{}{"endpoint-a": {}, "endpoint-b": {}}),
+		members: map[string]TaskCache{
+			"endpoint-a": newFederationTestTaskCache(fakeVeTESClientA, &schemodels.TaskInfo{ID: "task-01", State: consts.TaskQueued}),
+			"endpoint-b": newFederationTestTaskCache(fakeVeTESClientB, &schemodels.TaskInfo{ID: "task-01", State: consts.TaskQueued}),
+		},
+	}
+
+	g.Expect(c.UpdateTask(context.Background(), "endpoint-a/task-01", nil, utils.Point("endpoint-a/cluster-01"), nil)).To(gomega.Succeed())
+	g.Expect(c.UpdateTask(context.Background(), "endpoint-c/task-01", nil, utils.Point("endpoint-a/cluster-01"), nil)).NotTo(gomega.Succeed())
+	// clusters of other endpoints are rejected without requests
+	g.Expect(c.UpdateTask(context.Background(), "endpoint-b/task-01", nil, utils.Point("endpoint-a/cluster-01"), nil)).NotTo(gomega.Succeed())
+
+	res := c.BatchUpdateTasks(context.Background(), []*TaskUpdate{
+		{ID: "endpoint-b/task-01", ClusterID: utils.Point("endpoint-b/cluster-01")},
+		{ID: "endpoint-b/task-02", ClusterID: utils.Point("endpoint-a/cluster-01")},
+		{ID: "task-01", ClusterID: utils.Point("endpoint-a/cluster-01")},
+	})
+	g.Expect(res).To(gomega.HaveLen(2))
+	g.Expect(res).To(gomega.HaveKey("endpoint-b/task-02"))
+	g.Expect(res).To(gomega.HaveKey("task-01"))
+	g.Expect(c.ListTasks("endpoint-a/cluster-01")).To(gomega.BeEquivalentTo([]*schemodels.TaskInfo{
+		{ID: "endpoint-a/task-01", State: consts.TaskQueued, ClusterID: "endpoint-a/cluster-01"},
+	}))
+	g.Expect(c.ListTasks("endpoint-b/cluster-01")).To(gomega.BeEquivalentTo([]*schemodels.TaskInfo{
+		{ID: "endpoint-b/task-01", State: consts.TaskQueued, ClusterID: "endpoint-b/cluster-01"},
+	}))
+}
+
+func TestFederatedClusterCache(t *testing.T) {
+	g := gomega.NewWithT(t)
+	now := time.Now()
+	c := &federatedClusterCache{
+		federation: newFederation(map[string]struct{}{"endpoint-a": {}, "endpoint-b": {}}),
+		members: map[string]ClusterCache{
+			"endpoint-a": &clusterCacheImpl{
+				syncRecorder: syncRecorder{status: SyncStatus{Synced: true, LastSyncTime: now}},
+				clusters:     []*schemodels.ClusterInfo{{ID: "cluster-01"}},
+			},
+			"endpoint-b": &clusterCacheImpl{
+				syncRecorder: syncRecorder{status: SyncStatus{Synced: true, LastSyncTime: now.Add(-time.Minute), ConsecutiveFailures: 2}},
+				clusters:     []*schemodels.ClusterInfo{{ID: "cluster-01"}},
+			},
+		},
+	}
+
+	g.Expect(c.ListClusters()).To(gomega.BeEquivalentTo([]*schemodels.ClusterInfo{{ID: "endpoint-a/cluster-01"}, {ID: "endpoint-b/cluster-01"}}))
+	g.Expect(c.SyncStatus()).To(gomega.Equal(SyncStatus{Synced: true, LastSyncTime: now.Add(-time.Minute), ConsecutiveFailures: 2}))
+}
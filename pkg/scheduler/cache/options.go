@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -9,21 +10,67 @@ import (
 // Options ...
 type Options struct {
 	SyncPeriod time.Duration `mapstructure:"syncPeriod"`
+	// IncrementalSync only lists tasks modified since last sync every SyncPeriod,
+	// and lists all non-finished tasks every FullSyncPeriod.
+	IncrementalSync bool          `mapstructure:"incrementalSync"`
+	FullSyncPeriod  time.Duration `mapstructure:"fullSyncPeriod"`
+	// HydrateConcurrency is the max concurrent GetTask of newly seen and finished tasks in sync
+	HydrateConcurrency int `mapstructure:"hydrateConcurrency"`
+	// QuotaPrefetch lists all quotas every SyncPeriod, so that no quota is got one by one
+	// in scheduling. It requires list quotas support of vetes-api.
+	QuotaPrefetch bool `mapstructure:"quotaPrefetch"`
+	// CheckpointPath is the file caches are saved to every CheckpointPeriod, and
+	// restored from at startup. Empty means disabled.
+	CheckpointPath   string        `mapstructure:"checkpointPath"`
+	CheckpointPeriod time.Duration `mapstructure:"checkpointPeriod"`
+	// MaxStaleness is the max duration since last successful sync of caches, scheduling
+	// and controlling are paused if exceeded. 0 means unlimited.
+	MaxStaleness time.Duration `mapstructure:"maxStaleness"`
+	// PrimaryEndpoint is the endpoint quotas and extra priorities are read from in
+	// federation, empty means the first endpoint by name.
+	PrimaryEndpoint string `mapstructure:"primaryEndpoint"`
 }
 
 // NewOptions ...
 func NewOptions() *Options {
 	return &Options{
-		SyncPeriod: time.Second * 10,
+		SyncPeriod:         time.Second * 10,
+		FullSyncPeriod:     time.Minute * 10,
+		HydrateConcurrency: 16,
+		CheckpointPeriod:   time.Minute,
+		MaxStaleness:       time.Minute * 5,
 	}
 }
 
 // Validate ...
 func (o *Options) Validate() error {
+	if o.IncrementalSync && o.FullSyncPeriod < o.SyncPeriod {
+		return fmt.Errorf("full sync period must be greater than sync period")
+	}
+	if o.HydrateConcurrency <= 0 {
+		return fmt.Errorf("hydrate concurrency must be positive")
+	}
+	if o.CheckpointPath != "" && o.CheckpointPeriod <= 0 {
+		return fmt.Errorf("checkpoint period must be positive")
+	}
+	if o.MaxStaleness < 0 {
+		return fmt.Errorf("max staleness cannot be negative")
+	}
+	if o.MaxStaleness > 0 && o.MaxStaleness <= o.SyncPeriod {
+		return fmt.Errorf("max staleness must be greater than sync period")
+	}
 	return nil
 }
 
 // AddFlags ...
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&o.SyncPeriod, "scheduler-cache-sync-period", o.SyncPeriod, "sync period of cache resources")
+	fs.BoolVar(&o.IncrementalSync, "scheduler-cache-incremental-sync", o.IncrementalSync, "only list tasks modified since last sync, requires modified_since support of vetes-api")
+	fs.DurationVar(&o.FullSyncPeriod, "scheduler-cache-full-sync-period", o.FullSyncPeriod, "period of listing all non-finished tasks when incremental sync is enabled")
+	fs.IntVar(&o.HydrateConcurrency, "scheduler-cache-hydrate-concurrency", o.HydrateConcurrency, "max concurrent requests of getting newly seen and finished tasks in sync")
+	fs.BoolVar(&o.QuotaPrefetch, "scheduler-cache-quota-prefetch", o.QuotaPrefetch, "list all quotas every sync period instead of getting one by one, requires list quotas support of vetes-api")
+	fs.StringVar(&o.CheckpointPath, "scheduler-cache-checkpoint-path", o.CheckpointPath, "file to save caches to and restore from at startup, empty means disabled")
+	fs.DurationVar(&o.CheckpointPeriod, "scheduler-cache-checkpoint-period", o.CheckpointPeriod, "period of saving caches to checkpoint")
+	fs.DurationVar(&o.MaxStaleness, "scheduler-cache-max-staleness", o.MaxStaleness, "max duration since last successful sync of caches, scheduling and controlling are paused if exceeded, 0 means unlimited")
+	fs.StringVar(&o.PrimaryEndpoint, "scheduler-cache-primary-endpoint", o.PrimaryEndpoint, "endpoint quotas and extra priorities are read from in federation, empty means the first endpoint by name")
 }
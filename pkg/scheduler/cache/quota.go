@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/log"
 	"github.com/coocood/freecache"
+	"k8s.io/utils/clock"
 
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/runner"
 	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
 	clientmodels "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
 )
@@ -28,18 +32,47 @@ type quotaCacheImpl struct {
 	vetesClient  vetesclient.Client
 	expireSecond int
 	quotaCache   *freecache.Cache
+	clock        clock.PassiveClock
+
+	// prefetchTime is the time of last successful prefetch, and prefetchedKeys
+	// are keys in its result. Before the cache expires, quotas not in the
+	// prefetch result are regarded as not found. They are swapped together.
+	mutex          sync.RWMutex
+	prefetchTime   time.Time
+	prefetchedKeys map[string]struct{}
 }
 
 var _ QuotaCache = (*quotaCacheImpl)(nil)
 
-// NewQuotaCache ...
-func NewQuotaCache(vetesClient vetesclient.Client, opts *Options) QuotaCache {
+// NewQuotaCache prefetches all quotas every SyncPeriod if enabled.
+func NewQuotaCache(vetesClient vetesclient.Client, opts *Options, clk clock.PassiveClock, jobs runner.Registerer) (QuotaCache, error) {
 	quotaCache := freecache.NewCache(quotaCacheSize)
-	return &quotaCacheImpl{
+	cache := &quotaCacheImpl{
 		vetesClient:  vetesClient,
 		expireSecond: int(opts.SyncPeriod.Seconds()),
 		quotaCache:   quotaCache,
+		clock:        clk,
 	}
+	if !opts.QuotaPrefetch {
+		return cache, nil
+	}
+	// prefetch runs every SyncPeriod plus jitter, prefetched quotas should not
+	// expire before the next one
+	cache.expireSecond = int((2 * opts.SyncPeriod).Seconds())
+	if err := cache.prefetchQuotas(context.Background()); err != nil {
+		// not fatal, quotas are got one by one until next prefetch succeeds
+		log.Warnw("failed to prefetch quotas", "err", err)
+	}
+	if err := jobs.Register("quota-cache-prefetch", opts.SyncPeriod, func() error {
+		// not fatal either, and vetes-api outages should not fail health check
+		if err := cache.prefetchQuotas(context.Background()); err != nil {
+			log.Warnw("failed to prefetch quotas", "err", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return cache, nil
 }
 
 // GetGlobalQuota ...
@@ -61,8 +94,9 @@ func (i *quotaCacheImpl) getQuota(ctx context.Context, global bool, accountID, u
 	key := quotaCacheKey(global, accountID, userID)
 	cache, err := i.quotaCache.Get(key)
 	if err == nil {
-		res := &schemodels.ResourceQuota{}
-		unmarshalErr := json.Unmarshal(cache, res)
+		// "null" is cached for no quota, and unmarshalled to nil
+		var res *schemodels.ResourceQuota
+		unmarshalErr := json.Unmarshal(cache, &res)
 		if unmarshalErr == nil {
 			return res, nil
 		}
@@ -71,21 +105,11 @@ func (i *quotaCacheImpl) getQuota(ctx context.Context, global bool, accountID, u
 	if !errors.Is(err, freecache.ErrNotFound) {
 		log.CtxErrorw(ctx, "failed to get resourceQuota from cache", "err", err)
 	}
-
-	var res *schemodels.ResourceQuota
-	defer func() {
-		if res == nil {
-			return
-		}
-		toCache, marshalErr := json.Marshal(res)
-		if marshalErr != nil {
-			log.CtxErrorw(ctx, "failed to marshal resourceQuota", "err", marshalErr)
-			return
-		}
-		if cacheErr := i.quotaCache.Set(key, toCache, i.expireSecond); cacheErr != nil {
-			log.CtxErrorw(ctx, "failed to set quota cache", "err", cacheErr)
-		}
-	}()
+	// freecache may evict prefetched quotas, only those not in the prefetch
+	// result are regarded as not found
+	if i.prefetchedNotFound(key) {
+		return nil, nil
+	}
 
 	resp, err := i.vetesClient.GetQuota(ctx, &clientmodels.GetQuotaRequest{
 		Global:    global,
@@ -94,14 +118,64 @@ func (i *quotaCacheImpl) getQuota(ctx context.Context, global bool, accountID, u
 	})
 	if err != nil {
 		if errors.Is(err, vetesclient.ErrNotFound) {
+			i.setQuota(ctx, key, nil)
 			return nil, nil
 		}
 		return nil, err
 	}
-	res = clientResourceQuotaToResourceQuotaInfo(resp.ResourceQuota)
+	res := clientResourceQuotaToResourceQuotaInfo(resp.ResourceQuota)
+	i.setQuota(ctx, key, res)
 	return res, nil
 }
 
+// setQuota caches quota of key, nil quota is cached as well
+func (i *quotaCacheImpl) setQuota(ctx context.Context, key []byte, quota *schemodels.ResourceQuota) {
+	toCache, err := json.Marshal(quota)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to marshal resourceQuota", "err", err)
+		return
+	}
+	if err = i.quotaCache.Set(key, toCache, i.expireSecond); err != nil {
+		log.CtxErrorw(ctx, "failed to set quota cache", "err", err)
+	}
+}
+
+// prefetchedNotFound returns whether the last prefetch has not expired and key
+// is not in its result
+func (i *quotaCacheImpl) prefetchedNotFound(key []byte) bool {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	if i.prefetchTime.IsZero() || i.clock.Since(i.prefetchTime) >= time.Duration(i.expireSecond)*time.Second {
+		return false
+	}
+	_, ok := i.prefetchedKeys[string(key)]
+	return !ok
+}
+
+func (i *quotaCacheImpl) prefetchQuotas(ctx context.Context) error {
+	// truncated as freecache expires by seconds, so that no prefetched quota expires before prefetchTime
+	now := i.clock.Now().Truncate(time.Second)
+	resp, err := i.vetesClient.ListQuotas(ctx, &clientmodels.ListQuotasRequest{})
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]struct{}, len(*resp))
+	for _, quota := range *resp {
+		if quota == nil {
+			continue
+		}
+		key := quotaCacheKey(quota.Global, quota.AccountID, quota.UserID)
+		keys[string(key)] = struct{}{}
+		i.setQuota(ctx, key, clientResourceQuotaToResourceQuotaInfo(quota.ResourceQuota))
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.prefetchTime = now
+	i.prefetchedKeys = keys
+	return nil
+}
+
 func clientResourceQuotaToResourceQuotaInfo(quota *clientmodels.ResourceQuota) *schemodels.ResourceQuota {
 	if quota == nil {
 		return nil
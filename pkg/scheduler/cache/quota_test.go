@@ -4,13 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/coocood/freecache"
 	"github.com/golang/mock/gomock"
 	"github.com/onsi/gomega"
+	testingclock "k8s.io/utils/clock/testing"
 
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/runner"
 	"github.com/GBA-BI/tes-scheduler/pkg/utils"
 	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
 	vetesclientfake "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/fake"
@@ -81,8 +85,35 @@ func TestGetGlobalQuotaDirectNotFound(t *testing.T) {
 	g.Expect(resp).To(gomega.BeNil())
 
 	cached, err := i.quotaCache.Get(quotaCacheKey(true, "", ""))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(cached).To(gomega.BeEquivalentTo([]byte("null")))
+
+	// not found is cached, no request any more
+	resp, err = i.GetGlobalQuota(context.Background())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp).To(gomega.BeNil())
+}
+
+func TestGetGlobalQuotaDirectError(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClient.EXPECT().
+		GetQuota(gomock.Any(), &clientmodels.GetQuotaRequest{Global: true}).
+		Return(nil, fmt.Errorf("internal error"))
+
+	i := &quotaCacheImpl{
+		vetesClient:  fakeVeTESClient,
+		expireSecond: 15,
+		quotaCache:   freecache.NewCache(quotaCacheSize),
+	}
+	_, err := i.GetGlobalQuota(context.Background())
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = i.quotaCache.Get(quotaCacheKey(true, "", ""))
 	g.Expect(errors.Is(err, freecache.ErrNotFound)).To(gomega.BeTrue())
-	g.Expect(cached).To(gomega.BeNil())
 }
 
 func TestGetGlobalQuotaFromCache(t *testing.T) {
@@ -126,3 +157,109 @@ func TestGetUserQuotaFromCache(t *testing.T) {
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 	g.Expect(resp).To(gomega.BeEquivalentTo(scheResourceQuota))
 }
+
+func TestPrefetchQuotas(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClient.EXPECT().
+		ListQuotas(gomock.Any(), &clientmodels.ListQuotasRequest{}).
+		Return(&clientmodels.ListQuotasResponse{
+			{Global: true, ResourceQuota: clientResourceQuota},
+			{AccountID: "account-01", ResourceQuota: clientResourceQuota},
+			{AccountID: "account-01", UserID: "user-01", ResourceQuota: clientResourceQuota},
+		}, nil)
+
+	i := &quotaCacheImpl{
+		vetesClient:  fakeVeTESClient,
+		expireSecond: 15,
+		quotaCache:   freecache.NewCache(quotaCacheSize),
+		clock:        testingclock.NewFakeClock(time.Now()),
+	}
+	g.Expect(i.prefetchQuotas(context.Background())).To(gomega.Succeed())
+
+	// no GetQuota expected, quotas not prefetched are regarded as not found
+	resp, err := i.GetGlobalQuota(context.Background())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp).To(gomega.BeEquivalentTo(scheResourceQuota))
+	resp, err = i.GetAccountQuota(context.Background(), "account-01")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp).To(gomega.BeEquivalentTo(scheResourceQuota))
+	resp, err = i.GetUserQuota(context.Background(), "account-01", "user-01")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp).To(gomega.BeEquivalentTo(scheResourceQuota))
+	resp, err = i.GetUserQuota(context.Background(), "account-01", "user-02")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp).To(gomega.BeNil())
+	resp, err = i.GetAccountQuota(context.Background(), "account-02")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp).To(gomega.BeNil())
+
+	// prefetched quotas evicted by freecache are got again
+	fakeVeTESClient.EXPECT().
+		GetQuota(gomock.Any(), &clientmodels.GetQuotaRequest{AccountID: "account-01"}).
+		Return(&clientmodels.GetQuotaResponse{
+			AccountID:     "account-01",
+			ResourceQuota: clientResourceQuota,
+		}, nil)
+	i.quotaCache.Del(quotaCacheKey(false, "account-01", ""))
+	resp, err = i.GetAccountQuota(context.Background(), "account-01")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp).To(gomega.BeEquivalentTo(scheResourceQuota))
+}
+
+func TestPrefetchQuotasExpired(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClient.EXPECT().
+		GetQuota(gomock.Any(), &clientmodels.GetQuotaRequest{AccountID: "account-01"}).
+		Return(&clientmodels.GetQuotaResponse{
+			AccountID:     "account-01",
+			ResourceQuota: clientResourceQuota,
+		}, nil)
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	i := &quotaCacheImpl{
+		vetesClient:    fakeVeTESClient,
+		expireSecond:   15,
+		quotaCache:     freecache.NewCache(quotaCacheSize),
+		clock:          fakeClock,
+		prefetchTime:   fakeClock.Now().Add(-time.Minute),
+		prefetchedKeys: map[string]struct{}{},
+	}
+
+	// not regarded as not found after the prefetch expires
+	resp, err := i.GetAccountQuota(context.Background(), "account-01")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp).To(gomega.BeEquivalentTo(scheResourceQuota))
+}
+
+func TestPrefetchQuotasOutlivePeriod(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClient.EXPECT().
+		ListQuotas(gomock.Any(), &clientmodels.ListQuotasRequest{}).
+		Return(&clientmodels.ListQuotasResponse{{Global: true, ResourceQuota: clientResourceQuota}}, nil)
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	jobs := runner.New(&runner.Options{Jitter: 0.1}, fakeClock)
+	c, err := NewQuotaCache(fakeVeTESClient, &Options{SyncPeriod: 15 * time.Second, QuotaPrefetch: true}, fakeClock, jobs)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// the next prefetch may be delayed by jitter, no GetQuota expected before it
+	fakeClock.Step(15*time.Second + 1500*time.Millisecond)
+	resp, err := c.GetAccountQuota(context.Background(), "account-01")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp).To(gomega.BeNil())
+	resp, err = c.GetGlobalQuota(context.Background())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp).To(gomega.BeEquivalentTo(scheResourceQuota))
+}
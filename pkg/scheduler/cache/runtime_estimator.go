@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	clientmodels "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+const (
+	// runtimeEWMAWeight is the weight of the latest observation
+	runtimeEWMAWeight = 0.3
+	// minRuntimeSamples is the least observations before estimating
+	minRuntimeSamples = 3
+)
+
+// RuntimeEstimator estimates running duration of tasks, learned from finished tasks.
+type RuntimeEstimator interface {
+	// Estimate returns the estimated running duration of task, false if there is no enough history.
+	Estimate(task *schemodels.TaskInfo) (time.Duration, bool)
+	// Observe records the running duration of a finished task.
+	Observe(task *schemodels.TaskInfo, duration time.Duration)
+}
+
+// runtimeEstimatorImpl keeps exponentially weighted moving average of running
+// duration keyed by account, executor image and task name prefix. If there is
+// no enough history of the account, it falls back to all accounts.
+type runtimeEstimatorImpl struct {
+	mutex sync.RWMutex
+	stats map[runtimeKey]*runtimeStat
+}
+
+type runtimeKey struct {
+	accountID  string
+	image      string
+	namePrefix string
+}
+
+type runtimeStat struct {
+	samples int
+	average float64 // seconds
+}
+
+var _ RuntimeEstimator = (*runtimeEstimatorImpl)(nil)
+
+// NewRuntimeEstimator ...
+func NewRuntimeEstimator() RuntimeEstimator {
+	return &runtimeEstimatorImpl{stats: make(map[runtimeKey]*runtimeStat)}
+}
+
+// Estimate ...
+func (r *runtimeEstimatorImpl) Estimate(task *schemodels.TaskInfo) (time.Duration, bool) {
+	if task == nil {
+		return 0, false
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, key := range runtimeKeys(task) {
+		if stat, ok := r.stats[key]; ok && stat.samples >= minRuntimeSamples {
+			return time.Duration(stat.average * float64(time.Second)), true
+		}
+	}
+	return 0, false
+}
+
+// Observe ...
+func (r *runtimeEstimatorImpl) Observe(task *schemodels.TaskInfo, duration time.Duration) {
+	if task == nil || duration <= 0 {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, key := range runtimeKeys(task) {
+		stat, ok := r.stats[key]
+		if !ok {
+			stat = &runtimeStat{}
+			r.stats[key] = stat
+		}
+		stat.samples++
+		if stat.samples == 1 {
+			stat.average = duration.Seconds()
+			continue
+		}
+		stat.average = runtimeEWMAWeight*duration.Seconds() + (1-runtimeEWMAWeight)*stat.average
+	}
+}
+
+// runtimeKeys returns keys from the most specific to the least
+func runtimeKeys(task *schemodels.TaskInfo) []runtimeKey {
+	key := runtimeKey{image: task.ExecutorImage, namePrefix: taskNamePrefix(task.Name)}
+	if task.BioosInfo == nil || task.BioosInfo.AccountID == "" {
+		return []runtimeKey{key}
+	}
+	accountKey := key
+	accountKey.accountID = task.BioosInfo.AccountID
+	return []runtimeKey{accountKey, key}
+}
+
+// taskNamePrefix trims trailing digits and separators, such as "align-sample-12" -> "align-sample"
+func taskNamePrefix(name string) string {
+	return strings.TrimRight(name, "0123456789-_.")
+}
+
+// taskRuntime returns the running duration of the last valid attempt of task
+func taskRuntime(logs []*clientmodels.TaskLog) (time.Duration, bool) {
+	for index := len(logs) - 1; index >= 0; index-- {
+		taskLog := logs[index]
+		if taskLog == nil || taskLog.StartTime == nil || taskLog.EndTime == nil {
+			continue
+		}
+		startTime, err := time.Parse(time.RFC3339, *taskLog.StartTime)
+		if err != nil {
+			continue
+		}
+		endTime, err := time.Parse(time.RFC3339, *taskLog.EndTime)
+		if err != nil || !endTime.After(startTime) {
+			continue
+		}
+		return endTime.Sub(startTime), true
+	}
+	return 0, false
+}
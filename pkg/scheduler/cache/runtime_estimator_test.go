@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/onsi/gomega"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/utils"
+	vetesclientfake "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/fake"
+	clientmodels "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+func TestRuntimeEstimator(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	estimator := NewRuntimeEstimator()
+	newTask := func(accountID, name string) *schemodels.TaskInfo {
+		return &schemodels.TaskInfo{
+			Name:          name,
+			ExecutorImage: "image-01",
+			BioosInfo:     &schemodels.BioosInfo{AccountID: accountID},
+		}
+	}
+
+	_, ok := estimator.Estimate(newTask("account-01", "align-1"))
+	g.Expect(ok).To(gomega.BeFalse())
+
+	estimator.Observe(newTask("account-01", "align-1"), time.Minute*10)
+	estimator.Observe(newTask("account-01", "align-2"), time.Minute*10)
+	_, ok = estimator.Estimate(newTask("account-01", "align-3"))
+	g.Expect(ok).To(gomega.BeFalse()) // not enough samples
+
+	estimator.Observe(newTask("account-01", "align_3"), time.Minute*20)
+	duration, ok := estimator.Estimate(newTask("account-01", "align-4"))
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(duration).To(gomega.Equal(time.Minute * 13))
+
+	// fall back to all accounts
+	duration, ok = estimator.Estimate(newTask("account-02", "align-5"))
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(duration).To(gomega.Equal(time.Minute * 13))
+
+	// different name prefix
+	_, ok = estimator.Estimate(newTask("account-01", "call-1"))
+	g.Expect(ok).To(gomega.BeFalse())
+}
+
+func TestTaskNamePrefix(t *testing.T) {
+	g := gomega.NewWithT(t)
+	g.Expect(taskNamePrefix("align-sample-12")).To(gomega.Equal("align-sample"))
+	g.Expect(taskNamePrefix("call_variants.3")).To(gomega.Equal("call_variants"))
+	g.Expect(taskNamePrefix("task")).To(gomega.Equal("task"))
+	g.Expect(taskNamePrefix("")).To(gomega.Equal(""))
+}
+
+func TestTaskRuntime(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	duration, ok := taskRuntime([]*clientmodels.TaskLog{{
+		StartTime: utils.Point(now.Format(time.RFC3339)),
+		EndTime:   utils.Point(now.Add(time.Hour).Format(time.RFC3339)),
+	}, {
+		StartTime: utils.Point(now.Add(2 * time.Hour).Format(time.RFC3339)),
+		EndTime:   utils.Point(now.Add(3 * time.Hour).Add(time.Minute).Format(time.RFC3339)),
+	}, {
+		SystemLogs: []string{"xxx"},
+	}})
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(duration).To(gomega.Equal(time.Hour + time.Minute))
+
+	// invalid logs are skipped
+	duration, ok = taskRuntime([]*clientmodels.TaskLog{{
+		StartTime: utils.Point(now.Format(time.RFC3339)),
+		EndTime:   utils.Point(now.Add(time.Hour).Format(time.RFC3339)),
+	}, {
+		StartTime: utils.Point(now.Add(2 * time.Hour).Format(time.RFC3339)),
+		EndTime:   utils.Point(now.Format(time.RFC3339)),
+	}, {
+		StartTime: utils.Point("invalid"),
+		EndTime:   utils.Point(now.Format(time.RFC3339)),
+	}})
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(duration).To(gomega.Equal(time.Hour))
+
+	_, ok = taskRuntime([]*clientmodels.TaskLog{{StartTime: utils.Point(now.Format(time.RFC3339))}})
+	g.Expect(ok).To(gomega.BeFalse())
+}
+
+func TestObserveFinishedTasks(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	newLogs := func() []*clientmodels.TaskLog {
+		return []*clientmodels.TaskLog{{
+			StartTime: utils.Point(now.Format(time.RFC3339)),
+			EndTime:   utils.Point(now.Add(time.Hour).Format(time.RFC3339)),
+		}}
+	}
+
+	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClient.EXPECT().GetTask(gomock.Any(), &clientmodels.GetTaskRequest{ID: "task-complete", View: consts.BasicView}).
+		Return(&clientmodels.GetTaskResponse{Task: &clientmodels.Task{ID: "task-complete", State: consts.TaskComplete, Logs: newLogs()}}, nil).Times(minRuntimeSamples)
+	fakeVeTESClient.EXPECT().GetTask(gomock.Any(), &clientmodels.GetTaskRequest{ID: "task-failed", View: consts.BasicView}).
+		Return(&clientmodels.GetTaskResponse{Task: &clientmodels.Task{ID: "task-failed", State: consts.TaskExecutorError, Logs: newLogs()}}, nil)
+	fakeVeTESClient.EXPECT().GetTask(gomock.Any(), &clientmodels.GetTaskRequest{ID: "task-empty", View: consts.BasicView}).
+		Return(nil, nil)
+
+	estimator := NewRuntimeEstimator()
+	i := &taskCacheImpl{vetesClient: fakeVeTESClient, runtimeEstimator: estimator, hydrateConcurrency: 2}
+	completeTask := &schemodels.TaskInfo{ID: "task-complete", Name: "task", ExecutorImage: "image-01"}
+	failedTask := &schemodels.TaskInfo{ID: "task-failed", Name: "task", ExecutorImage: "image-02"}
+	for index := 0; index < minRuntimeSamples; index++ {
+		i.observeFinishedTasks(context.Background(), []*schemodels.TaskInfo{completeTask})
+	}
+	i.observeFinishedTasks(context.Background(), []*schemodels.TaskInfo{failedTask, {ID: "task-empty"}})
+
+	duration, ok := estimator.Estimate(completeTask)
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(duration).To(gomega.Equal(time.Hour))
+	_, ok = estimator.Estimate(failedTask)
+	g.Expect(ok).To(gomega.BeFalse())
+}
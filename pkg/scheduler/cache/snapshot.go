@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"time"
+
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+)
+
+// Snapshot is a consistent view of cache taken at the start of a scheduling cycle.
+// Plugins read from it instead of the caches, which may be synced during the cycle.
+// It is not thread-safe, and only updated by the scheduler itself by AssignTask.
+type Snapshot struct {
+	// now is the time the snapshot is taken, extraPriorities are matched at it
+	now                time.Time
+	tasks              map[string]*schemodels.TaskInfo
+	clusters           []*schemodels.ClusterInfo
+	extraPriorityIndex *schemodels.ExtraPriorityIndex
+	usages             map[UsageKey]*schemodels.Usage
+	// priorities is taskID -> effective priority of unscheduled tasks, computed
+	// once here instead of in every comparison of sorting.
+	priorities map[string]int
+}
+
+// NewSnapshot takes the snapshot at now
+func NewSnapshot(now time.Time, tasks []*schemodels.TaskInfo, clusters []*schemodels.ClusterInfo, extraPriorities []*schemodels.ExtraPriorityInfo) *Snapshot {
+	usages := make(map[UsageKey]*schemodels.Usage)
+	for _, task := range tasks {
+		addUsage(usages, task)
+	}
+	return newSnapshot(now, tasks, clusters, schemodels.NewExtraPriorityIndex(extraPriorities), usages)
+}
+
+// TakeSnapshot ...
+func (c *Cache) TakeSnapshot() *Snapshot {
+	tasks, usages := c.UsageCache.ListTasksWithUsages()
+	return newSnapshot(c.Clock.Now(), tasks, c.ClusterCache.ListClusters(), c.ExtraPriorityCache.GetExtraPriorityIndex(), usages)
+}
+
+func newSnapshot(now time.Time, tasks []*schemodels.TaskInfo, clusters []*schemodels.ClusterInfo, extraPriorityIndex *schemodels.ExtraPriorityIndex, usages map[UsageKey]*schemodels.Usage) *Snapshot {
+	snapshot := &Snapshot{
+		now:                now,
+		tasks:              make(map[string]*schemodels.TaskInfo, len(tasks)),
+		clusters:           clusters,
+		extraPriorityIndex: extraPriorityIndex,
+		usages:             usages,
+		priorities:         make(map[string]int),
+	}
+	for _, task := range tasks {
+		snapshot.tasks[task.ID] = task
+		if task.ClusterID == "" {
+			snapshot.priorities[task.ID] = extraPriorityIndex.EffectivePriorityAt(task, now)
+		}
+	}
+	return snapshot
+}
+
+// Now returns the time the snapshot is taken, plugins should regard it as current time
+func (s *Snapshot) Now() time.Time {
+	return s.now
+}
+
+// ListTasks returns tasks scheduled to clusterID, empty clusterID means unscheduled
+func (s *Snapshot) ListTasks(clusterID string) []*schemodels.TaskInfo {
+	res := make([]*schemodels.TaskInfo, 0)
+	for _, task := range s.tasks {
+		if task.ClusterID == clusterID {
+			res = append(res, task)
+		}
+	}
+	return res
+}
+
+// ListAllTasks returns all unfinished tasks, scheduled or not
+func (s *Snapshot) ListAllTasks() []*schemodels.TaskInfo {
+	res := make([]*schemodels.TaskInfo, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		res = append(res, task)
+	}
+	return res
+}
+
+// ListClusters ...
+func (s *Snapshot) ListClusters() []*schemodels.ClusterInfo {
+	return s.clusters
+}
+
+// ListExtraPriorities ...
+func (s *Snapshot) ListExtraPriorities() []*schemodels.ExtraPriorityInfo {
+	return s.extraPriorityIndex.List()
+}
+
+// MatchExtraPriorities returns all extraPriorities matching the task
+func (s *Snapshot) MatchExtraPriorities(task *schemodels.TaskInfo) []*schemodels.ExtraPriorityInfo {
+	return s.extraPriorityIndex.MatchAt(task, s.now)
+}
+
+// EffectivePriority is the PriorityValue of task plus all matched ExtraPriorityValue,
+// precomputed for unscheduled tasks in the snapshot.
+func (s *Snapshot) EffectivePriority(task *schemodels.TaskInfo) int {
+	if value, ok := s.priorities[task.ID]; ok && s.tasks[task.ID] == task {
+		return value
+	}
+	return s.extraPriorityIndex.EffectivePriorityAt(task, s.now)
+}
+
+// GetUsage returns the usage of key, never nil
+func (s *Snapshot) GetUsage(key UsageKey) *schemodels.Usage {
+	if usage, ok := s.usages[key]; ok {
+		return usage
+	}
+	return &schemodels.Usage{}
+}
+
+// AssignTask records the scheduling result of the scheduler in this cycle
+func (s *Snapshot) AssignTask(taskID, clusterID string) {
+	oldTask, ok := s.tasks[taskID]
+	if !ok || oldTask.ClusterID == clusterID {
+		return
+	}
+	// copy, because the task may be shared with cache
+	newTask := new(schemodels.TaskInfo)
+	*newTask = *oldTask
+	newTask.ClusterID = clusterID
+
+	subUsage(s.usages, oldTask)
+	s.tasks[taskID] = newTask
+	addUsage(s.usages, newTask)
+}
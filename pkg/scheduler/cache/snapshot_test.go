@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+)
+
+func TestSnapshot(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	queued := &schemodels.TaskInfo{
+		ID:        "task-queued",
+		Resources: &schemodels.Resources{CPUCores: 2},
+		BioosInfo: &schemodels.BioosInfo{AccountID: "account-01", UserID: "user-02"},
+	}
+	snapshot := NewSnapshot(time.Now(), []*schemodels.TaskInfo{
+		queued,
+		{
+			ID:        "task-01",
+			ClusterID: "cluster-01",
+			Resources: &schemodels.Resources{CPUCores: 1, GPU: &schemodels.GPUResource{Type: "GPU-A", Count: 1}},
+			BioosInfo: &schemodels.BioosInfo{AccountID: "account-01", UserID: "user-01"},
+		},
+		{
+			ID:        "task-02",
+			ClusterID: "cluster-02",
+			Resources: &schemodels.Resources{RamGB: 4},
+		},
+	}, []*schemodels.ClusterInfo{{ID: "cluster-01"}, {ID: "cluster-02"}}, nil)
+
+	g.Expect(snapshot.ListTasks("")).To(gomega.Equal([]*schemodels.TaskInfo{queued}))
+	g.Expect(snapshot.ListClusters()).To(gomega.HaveLen(2))
+	g.Expect(snapshot.GetUsage(UsageKey{})).To(gomega.Equal(&schemodels.Usage{
+		Count: 2, CPUCores: 1, RamGB: 4, GPUCount: 1, GPU: map[string]float64{"GPU-A": 1},
+	}))
+	g.Expect(snapshot.GetUsage(UsageKey{ClusterID: "cluster-02"})).To(gomega.Equal(&schemodels.Usage{Count: 1, RamGB: 4}))
+	g.Expect(snapshot.GetUsage(UsageKey{AccountID: "account-01"}).Count).To(gomega.Equal(1))
+	g.Expect(snapshot.GetUsage(UsageKey{AccountID: "account-01", UserID: "user-02"}).IsZero()).To(gomega.BeTrue())
+
+	snapshot.AssignTask("task-queued", "cluster-02")
+	g.Expect(snapshot.ListTasks("")).To(gomega.BeEmpty())
+	g.Expect(queued.ClusterID).To(gomega.BeEmpty()) // not changed in place
+	g.Expect(snapshot.GetUsage(UsageKey{ClusterID: "cluster-02"})).To(gomega.Equal(&schemodels.Usage{Count: 2, CPUCores: 2, RamGB: 4}))
+	g.Expect(snapshot.GetUsage(UsageKey{AccountID: "account-01"}).Count).To(gomega.Equal(2))
+	g.Expect(snapshot.GetUsage(UsageKey{AccountID: "account-01", UserID: "user-02"}).CPUCores).To(gomega.Equal(2))
+
+	snapshot.AssignTask("task-not-exist", "cluster-01")
+	g.Expect(snapshot.GetUsage(UsageKey{ClusterID: "cluster-01"}).Count).To(gomega.Equal(1))
+}
+
+func TestSnapshotEffectivePriority(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	queued := &schemodels.TaskInfo{
+		ID:            "task-queued",
+		PriorityValue: 10,
+		BioosInfo:     &schemodels.BioosInfo{AccountID: "account-01", UserID: "user-01"},
+	}
+	snapshot := NewSnapshot(time.Now(), []*schemodels.TaskInfo{queued}, nil, []*schemodels.ExtraPriorityInfo{
+		{AccountID: "account-01", ExtraPriorityValue: 100},
+		{AccountID: "account-01", UserID: "user-02", ExtraPriorityValue: 1000},
+	})
+	g.Expect(snapshot.priorities).To(gomega.Equal(map[string]int{"task-queued": 110}))
+	g.Expect(snapshot.EffectivePriority(queued)).To(gomega.Equal(110))
+	g.Expect(snapshot.MatchExtraPriorities(queued)).To(gomega.HaveLen(1))
+
+	// task not in snapshot is computed directly
+	g.Expect(snapshot.EffectivePriority(&schemodels.TaskInfo{
+		ID:        "task-other",
+		BioosInfo: &schemodels.BioosInfo{AccountID: "account-01", UserID: "user-02"},
+	})).To(gomega.Equal(1100))
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// SyncStatus is the sync result of a cache with vetes-api
+type SyncStatus struct {
+	// Synced is false if the cache is restored from checkpoint and not synced yet
+	Synced              bool
+	LastSyncTime        time.Time
+	ConsecutiveFailures int
+}
+
+// syncRecorder records SyncStatus, it is embedded in caches.
+type syncRecorder struct {
+	// clock is also the clock of the cache embedding syncRecorder
+	clock clock.PassiveClock
+
+	mutex  sync.RWMutex
+	status SyncStatus
+}
+
+// SyncStatus ...
+func (r *syncRecorder) SyncStatus() SyncStatus {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.status
+}
+
+func (r *syncRecorder) recordSuccess() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.status = SyncStatus{Synced: true, LastSyncTime: r.clock.Now()}
+}
+
+func (r *syncRecorder) recordFailure() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.status.ConsecutiveFailures++
+}
@@ -2,14 +2,18 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/log"
+	"k8s.io/utils/clock"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/consts"
-	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/crontab"
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/runner"
+	"github.com/GBA-BI/tes-scheduler/pkg/utils"
 	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
 	clientmodels "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
 )
@@ -17,35 +21,84 @@ import (
 // schedulerName is for UpdateTask TaskLog ClusterID
 const schedulerName = "scheduler"
 
+// deltaSyncOverlap is subtracted from the watermark of delta sync, to tolerate
+// clock skew between scheduler and vetes-api.
+const deltaSyncOverlap = 30 * time.Second
+
 // TaskCache caches non-finished taskInfo
 type TaskCache interface {
+	UsageCache
 	ListTasks(clusterID string) []*schemodels.TaskInfo
 	ListScheduledTasks() []*schemodels.TaskInfo
 	ListTaskClusterIDs() []string
-	// UpdateTask update actual task and cache
+	SyncStatus() SyncStatus
+	// UpdateTask update actual task and cache. It is conditional on the cached state of task,
+	// vetesclient.ErrConflict is returned if the task has been changed, and the task is refetched.
 	UpdateTask(ctx context.Context, id string, state, clusterID, message *string) error
+	// BatchUpdateTasks updates tasks like UpdateTask in one batch, and returns the errors of
+	// failed tasks by task ID. Tasks not in the result are updated successfully.
+	BatchUpdateTasks(ctx context.Context, updates []*TaskUpdate) map[string]error
+}
+
+// TaskUpdate is the update of one task in BatchUpdateTasks
+type TaskUpdate struct {
+	ID        string
+	State     *string
+	ClusterID *string
+	Message   *string
 }
 
 // taskCacheImpl ...
 type taskCacheImpl struct {
-	vetesClient vetesclient.Client
+	vetesClient      vetesclient.Client
+	runtimeEstimator RuntimeEstimator
+	syncRecorder
+
+	incrementalSync    bool
+	fullSyncPeriod     time.Duration
+	hydrateConcurrency int
 
 	dataLock sync.RWMutex
 	data     *data
+	// lastFullSyncTime and watermark are the start time of last full sync and last sync
+	lastFullSyncTime time.Time
+	watermark        time.Time
+	// updateSeq increases on every UpdateTask, localUpdates is taskID -> updateSeq of
+	// its last UpdateTask since last sync. They are used by sync to skip tasks
+	// updated locally during listing, so the cache will not be rolled back.
+	updateSeq    uint64
+	localUpdates map[string]uint64
+	// hydrateFailedIDs are new tasks failed to get in last sync
+	hydrateFailedIDs map[string]struct{}
 }
 
 type data struct {
 	tasks map[string]*schemodels.TaskInfo
 	// clusterID -> taskID set. clusterID may be empty, which means not scheduled
 	clusterIndexer map[string]map[string]struct{}
+	// usages of scheduled tasks, maintained along with tasks
+	usages map[UsageKey]*schemodels.Usage
 }
 
 func (d *data) addTask(task *schemodels.TaskInfo) {
+	d.deleteTask(task.ID) // replace the old one if any
 	d.tasks[task.ID] = task
 	if _, ok := d.clusterIndexer[task.ClusterID]; !ok {
 		d.clusterIndexer[task.ClusterID] = make(map[string]struct{})
 	}
 	d.clusterIndexer[task.ClusterID][task.ID] = struct{}{}
+	d.addUsage(task)
+}
+
+func (d *data) addUsage(task *schemodels.TaskInfo) {
+	if d.usages == nil {
+		d.usages = make(map[UsageKey]*schemodels.Usage)
+	}
+	addUsage(d.usages, task)
+}
+
+func (d *data) subUsage(task *schemodels.TaskInfo) {
+	subUsage(d.usages, task)
 }
 
 func (d *data) updateTask(id string, state, clusterID *string) {
@@ -62,7 +115,9 @@ func (d *data) updateTask(id string, state, clusterID *string) {
 		newTask.State = *state
 	}
 	if clusterID != nil && newTask.ClusterID != *clusterID {
+		d.subUsage(oldTask)
 		newTask.ClusterID = *clusterID
+		d.addUsage(newTask)
 		delete(d.clusterIndexer[oldTask.ClusterID], id)
 		if len(d.clusterIndexer[oldTask.ClusterID]) == 0 {
 			delete(d.clusterIndexer, oldTask.ClusterID)
@@ -81,6 +136,7 @@ func (d *data) deleteTask(id string) {
 		return
 	}
 	delete(d.tasks, id)
+	d.subUsage(oldTask)
 	delete(d.clusterIndexer[oldTask.ClusterID], id)
 	if len(d.clusterIndexer[oldTask.ClusterID]) == 0 {
 		delete(d.clusterIndexer, oldTask.ClusterID)
@@ -89,23 +145,46 @@ func (d *data) deleteTask(id string) {
 
 var _ TaskCache = (*taskCacheImpl)(nil)
 
-// NewTaskCache ...
-func NewTaskCache(vetesClient vetesclient.Client, opts *Options) (TaskCache, error) {
+// NewTaskCache restores from checkpoint if not nil, or syncs at once.
+func NewTaskCache(vetesClient vetesclient.Client, runtimeEstimator RuntimeEstimator, opts *Options, checkpoint *Checkpoint, clk clock.PassiveClock, jobs runner.Registerer) (TaskCache, error) {
 	cache := &taskCacheImpl{
-		vetesClient: vetesClient,
+		vetesClient:        vetesClient,
+		syncRecorder:       syncRecorder{clock: clk},
+		runtimeEstimator:   runtimeEstimator,
+		incrementalSync:    opts.IncrementalSync,
+		fullSyncPeriod:     opts.FullSyncPeriod,
+		hydrateConcurrency: opts.HydrateConcurrency,
 		data: &data{
 			tasks:          make(map[string]*schemodels.TaskInfo),
 			clusterIndexer: make(map[string]map[string]struct{}),
 		},
 	}
-	if err := cache.initCache(context.Background()); err != nil {
+	if checkpoint != nil {
+		for _, task := range checkpoint.Tasks {
+			cache.data.addTask(task)
+		}
+	} else if err := cache.initCache(context.Background()); err != nil {
 		return nil, err
 	}
-	if err := crontab.RegisterCron(opts.SyncPeriod, func() {
+	if err := jobs.Register("task-cache-sync", opts.SyncPeriod, func() error {
+		// failures are reported by staleness on readiness, like cluster cache
 		ctx := context.Background()
-		if err := cache.syncTasks(ctx); err != nil {
-			log.CtxErrorw(ctx, "failed to sync tasks", "err", err)
+		if !cache.SyncStatus().Synced {
+			// restored from checkpoint, relist all in BASIC view to reconcile
+			if err := cache.initCache(ctx); err != nil {
+				cache.recordFailure()
+				log.CtxWarnw(ctx, "failed to init tasks", "err", err)
+			}
+			return nil
 		}
+		finishedTasks, err := cache.sync(ctx)
+		if err != nil {
+			cache.recordFailure()
+			log.CtxWarnw(ctx, "failed to sync tasks", "err", err)
+			return nil
+		}
+		cache.observeFinishedTasks(ctx, finishedTasks)
+		return nil
 	}); err != nil {
 		return nil, err
 	}
@@ -129,6 +208,29 @@ func (i *taskCacheImpl) ListTasks(clusterID string) []*schemodels.TaskInfo {
 	return res
 }
 
+// GetUsage ...
+func (i *taskCacheImpl) GetUsage(key UsageKey) *schemodels.Usage {
+	i.dataLock.RLock()
+	defer i.dataLock.RUnlock()
+
+	if usage, ok := i.data.usages[key]; ok {
+		return usage.DeepCopy()
+	}
+	return &schemodels.Usage{}
+}
+
+// ListTasksWithUsages ...
+func (i *taskCacheImpl) ListTasksWithUsages() ([]*schemodels.TaskInfo, map[UsageKey]*schemodels.Usage) {
+	i.dataLock.RLock()
+	defer i.dataLock.RUnlock()
+
+	tasks := make([]*schemodels.TaskInfo, 0, len(i.data.tasks))
+	for _, task := range i.data.tasks {
+		tasks = append(tasks, task)
+	}
+	return tasks, copyUsages(i.data.usages)
+}
+
 // ListScheduledTasks ...
 func (i *taskCacheImpl) ListScheduledTasks() []*schemodels.TaskInfo {
 	i.dataLock.RLock()
@@ -158,53 +260,208 @@ func (i *taskCacheImpl) ListTaskClusterIDs() []string {
 
 // UpdateTask ...
 func (i *taskCacheImpl) UpdateTask(ctx context.Context, taskID string, state, clusterID, message *string) error {
+	i.dataLock.RLock()
+	req := i.newUpdateTaskRequest(&TaskUpdate{ID: taskID, State: state, ClusterID: clusterID, Message: message})
+	i.dataLock.RUnlock()
+	if _, err := i.vetesClient.UpdateTask(ctx, req); err != nil {
+		i.handleUpdateError(ctx, taskID, err)
+		return err
+	}
+
+	i.dataLock.Lock()
+	defer i.dataLock.Unlock()
+
+	i.applyUpdate(taskID, state, clusterID)
+	return nil
+}
+
+// BatchUpdateTasks ...
+func (i *taskCacheImpl) BatchUpdateTasks(ctx context.Context, updates []*TaskUpdate) map[string]error {
+	if len(updates) == 0 {
+		return nil
+	}
+	req := &clientmodels.BatchUpdateTasksRequest{Tasks: make([]*clientmodels.UpdateTaskRequest, 0, len(updates))}
+	i.dataLock.RLock()
+	for _, update := range updates {
+		req.Tasks = append(req.Tasks, i.newUpdateTaskRequest(update))
+	}
+	i.dataLock.RUnlock()
+
+	res := make(map[string]error)
+	resp, err := i.vetesClient.BatchUpdateTasks(ctx, req)
+	if err != nil {
+		for _, update := range updates {
+			res[update.ID] = err
+		}
+		return res
+	}
+	for _, result := range resp.Results {
+		if result.Error != nil {
+			res[result.ID] = result.Error
+		}
+	}
+
+	i.dataLock.Lock()
+	for _, update := range updates {
+		if _, ok := res[update.ID]; !ok {
+			i.applyUpdate(update.ID, update.State, update.ClusterID)
+		}
+	}
+	i.dataLock.Unlock()
+
+	for taskID, err := range res {
+		i.handleUpdateError(ctx, taskID, err)
+	}
+	return res
+}
+
+// newUpdateTaskRequest must be called with dataLock held, the request is
+// conditional on the cached state of task
+func (i *taskCacheImpl) newUpdateTaskRequest(update *TaskUpdate) *clientmodels.UpdateTaskRequest {
 	req := &clientmodels.UpdateTaskRequest{
-		ID:        taskID,
-		ClusterID: clusterID,
-		State:     state,
+		ID:        update.ID,
+		ClusterID: update.ClusterID,
+		State:     update.State,
 	}
-	if message != nil {
+	if update.Message != nil {
 		req.Logs = []*clientmodels.TaskLog{{
 			ClusterID:  schedulerName,
-			SystemLogs: []string{*message},
+			SystemLogs: []string{*update.Message},
 		}}
 	}
-	if _, err := i.vetesClient.UpdateTask(ctx, req); err != nil {
+	if task, ok := i.data.tasks[update.ID]; ok {
+		req.ExpectedState = utils.Point(task.State)
+	}
+	return req
+}
+
+// applyUpdate must be called with dataLock held
+func (i *taskCacheImpl) applyUpdate(taskID string, state, clusterID *string) {
+	i.markLocalUpdate(taskID)
+
+	if state != nil && isFinished(*state) {
+		i.data.deleteTask(taskID)
+		return
+	}
+	i.data.updateTask(taskID, state, clusterID)
+}
+
+// handleUpdateError refetches the task if it has been changed
+func (i *taskCacheImpl) handleUpdateError(ctx context.Context, taskID string, err error) {
+	if !errors.Is(err, vetesclient.ErrConflict) {
+		return
+	}
+	if refetchErr := i.refetchTask(ctx, taskID); refetchErr != nil {
+		log.CtxErrorw(ctx, "failed to refetch task after conflict", "task", taskID, "err", refetchErr)
+	}
+}
+
+// markLocalUpdate must be called with dataLock held
+func (i *taskCacheImpl) markLocalUpdate(taskID string) {
+	i.updateSeq++
+	if i.localUpdates == nil {
+		i.localUpdates = make(map[string]uint64)
+	}
+	i.localUpdates[taskID] = i.updateSeq
+}
+
+// refetchTask updates state and clusterID of task in cache with the actual one
+func (i *taskCacheImpl) refetchTask(ctx context.Context, taskID string) error {
+	var task *clientmodels.Task
+	resp, err := i.vetesClient.GetTask(ctx, &clientmodels.GetTaskRequest{ID: taskID, View: consts.MinimalView})
+	switch {
+	case errors.Is(err, vetesclient.ErrNotFound):
+	case err != nil:
 		return err
+	default:
+		task = resp.Task
 	}
 
 	i.dataLock.Lock()
 	defer i.dataLock.Unlock()
 
-	if state != nil && isFinished(*state) {
+	i.markLocalUpdate(taskID)
+
+	if task == nil || isFinished(task.State) {
 		i.data.deleteTask(taskID)
 		return nil
 	}
-	i.data.updateTask(taskID, state, clusterID)
+	i.data.updateTask(taskID, &task.State, &task.ClusterID)
 	return nil
 }
 
+// initCache lists all non-finished tasks in BASIC view and replaces the cache
 func (i *taskCacheImpl) initCache(ctx context.Context) error {
-	tasks, err := i.listTasks(ctx, consts.BasicView, consts.DefaultPageSize)
+	startTime := i.clock.Now()
+	tasks, err := i.listTasks(ctx, &clientmodels.ListTasksRequest{
+		State:    nonFinishedStates,
+		View:     consts.BasicView,
+		PageSize: consts.DefaultPageSize,
+	})
 	if err != nil {
 		return err
 	}
+	newData := &data{
+		tasks:          make(map[string]*schemodels.TaskInfo, len(tasks)),
+		clusterIndexer: make(map[string]map[string]struct{}),
+	}
 	for _, task := range tasks {
-		i.data.addTask(clientTaskToTaskInfo(ctx, task))
+		newData.addTask(clientTaskToTaskInfo(ctx, task))
 	}
-	return nil
-}
 
-func (i *taskCacheImpl) syncTasks(ctx context.Context) error {
-	// We have to lock here, because if we lock after listTasks, the listTasks action may
-	// be before UpdateTask, and cache may be rolled back.
 	i.dataLock.Lock()
 	defer i.dataLock.Unlock()
+	i.data = newData
+	i.lastFullSyncTime = startTime
+	i.watermark = startTime
+	i.localUpdates = nil
+	i.recordSuccess()
+	return nil
+}
+
+// sync does delta sync if enabled, and full sync every fullSyncPeriod.
+// It returns tasks which are not non-finished any more.
+func (i *taskCacheImpl) sync(ctx context.Context) ([]*schemodels.TaskInfo, error) {
+	i.dataLock.RLock()
+	lastFullSyncTime := i.lastFullSyncTime
+	i.dataLock.RUnlock()
+
+	if i.incrementalSync && !lastFullSyncTime.IsZero() && i.clock.Since(lastFullSyncTime) < i.fullSyncPeriod {
+		return i.deltaSyncTasks(ctx)
+	}
+	return i.syncTasks(ctx)
+}
 
-	tasks, err := i.listTasks(ctx, consts.MinimalView, consts.MaximumPageSize)
+// syncTasks lists all non-finished tasks without holding dataLock, tasks updated
+// locally meanwhile keep their cached version. It returns tasks which are not
+// non-finished any more.
+func (i *taskCacheImpl) syncTasks(ctx context.Context) ([]*schemodels.TaskInfo, error) {
+	i.dataLock.RLock()
+	updateSeq := i.updateSeq
+	i.dataLock.RUnlock()
+
+	startTime := i.clock.Now()
+	tasks, err := i.listTasks(ctx, &clientmodels.ListTasksRequest{
+		State:    nonFinishedStates,
+		View:     consts.MinimalView,
+		PageSize: consts.MaximumPageSize,
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	newTaskIDs := make([]string, 0)
+	i.dataLock.RLock()
+	for _, task := range tasks {
+		if _, ok := i.data.tasks[task.ID]; !ok {
+			newTaskIDs = append(newTaskIDs, task.ID)
+		}
 	}
+	i.dataLock.RUnlock()
+	newTasks, failedIDs := i.hydrateTasks(ctx, newTaskIDs)
+
+	i.dataLock.Lock()
+	defer i.dataLock.Unlock()
 
 	newData := &data{
 		tasks:          make(map[string]*schemodels.TaskInfo, len(i.data.tasks)),
@@ -212,32 +469,194 @@ func (i *taskCacheImpl) syncTasks(ctx context.Context) error {
 	}
 
 	for _, task := range tasks {
-		if oldTask, ok := i.data.tasks[task.ID]; ok {
+		oldTask, ok := i.data.tasks[task.ID]
+		switch {
+		case i.localUpdates[task.ID] > updateSeq:
+			if ok {
+				newData.addTask(oldTask)
+			}
+		case ok:
 			newData.addTask(oldTask)
 			newData.updateTask(task.ID, &task.State, nil) // just change state
+		case newTasks[task.ID] != nil:
+			newData.addTask(newTasks[task.ID])
+		}
+	}
+
+	finishedTasks := make([]*schemodels.TaskInfo, 0)
+	for id, oldTask := range i.data.tasks {
+		if _, ok := newData.tasks[id]; ok {
 			continue
 		}
-		gotTask, err := i.vetesClient.GetTask(ctx, &clientmodels.GetTaskRequest{ID: task.ID, View: consts.BasicView})
-		if err != nil {
-			return err
+		if i.localUpdates[id] > updateSeq {
+			newData.addTask(oldTask)
+			continue
 		}
-		newData.addTask(clientTaskToTaskInfo(ctx, gotTask.Task))
+		finishedTasks = append(finishedTasks, oldTask)
 	}
 
 	i.data = newData
-	return nil
+	i.lastFullSyncTime = startTime
+	i.watermark = startTime
+	i.localUpdates = nil
+	i.hydrateFailedIDs = failedIDs
+	i.recordSuccess()
+	return finishedTasks, nil
+}
+
+// deltaSyncTasks only lists tasks modified since last sync, in any state. It does not
+// hold dataLock while listing, tasks updated locally meanwhile are skipped.
+func (i *taskCacheImpl) deltaSyncTasks(ctx context.Context) ([]*schemodels.TaskInfo, error) {
+	i.dataLock.RLock()
+	updateSeq := i.updateSeq
+	modifiedSince := i.watermark.Add(-deltaSyncOverlap)
+	i.dataLock.RUnlock()
+
+	startTime := i.clock.Now()
+	tasks, err := i.listTasks(ctx, &clientmodels.ListTasksRequest{
+		ModifiedSince: modifiedSince.UTC().Format(time.RFC3339),
+		View:          consts.MinimalView,
+		PageSize:      consts.MaximumPageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// tasks failed to hydrate last time will not be listed again, retry them here
+	newTaskIDSet := make(map[string]struct{})
+	i.dataLock.RLock()
+	for id := range i.hydrateFailedIDs {
+		newTaskIDSet[id] = struct{}{}
+	}
+	for _, task := range tasks {
+		if isFinished(task.State) {
+			delete(newTaskIDSet, task.ID)
+			continue
+		}
+		if _, ok := i.data.tasks[task.ID]; !ok {
+			newTaskIDSet[task.ID] = struct{}{}
+		}
+	}
+	i.dataLock.RUnlock()
+	newTaskIDs := make([]string, 0, len(newTaskIDSet))
+	for id := range newTaskIDSet {
+		newTaskIDs = append(newTaskIDs, id)
+	}
+	newTasks, failedIDs := i.hydrateTasks(ctx, newTaskIDs)
+
+	i.dataLock.Lock()
+	defer i.dataLock.Unlock()
+
+	finishedTasks := make([]*schemodels.TaskInfo, 0)
+	for _, task := range tasks {
+		if i.localUpdates[task.ID] > updateSeq {
+			continue
+		}
+		oldTask, ok := i.data.tasks[task.ID]
+		switch {
+		case ok && isFinished(task.State):
+			i.data.deleteTask(task.ID)
+			finishedTasks = append(finishedTasks, oldTask)
+		case ok:
+			i.data.updateTask(task.ID, &task.State, nil) // just change state
+		}
+	}
+	for id, task := range newTasks {
+		if _, ok := i.data.tasks[id]; ok || i.localUpdates[id] > updateSeq {
+			continue
+		}
+		i.data.addTask(task)
+	}
+
+	i.watermark = startTime
+	i.localUpdates = nil
+	i.hydrateFailedIDs = failedIDs
+	i.recordSuccess()
+	return finishedTasks, nil
+}
+
+// hydrateTasks gets tasks in BASIC view concurrently. Finished tasks are
+// ignored, and tasks failed to get are returned to be retried next sync.
+func (i *taskCacheImpl) hydrateTasks(ctx context.Context, ids []string) (map[string]*schemodels.TaskInfo, map[string]struct{}) {
+	tasks := make(map[string]*schemodels.TaskInfo, len(ids))
+	failedIDs := make(map[string]struct{})
+	i.getTasks(ctx, ids, func(id string, gotTask *clientmodels.GetTaskResponse, err error) {
+		switch {
+		case errors.Is(err, vetesclient.ErrNotFound):
+			log.CtxWarnw(ctx, "task not found when hydrating", "taskID", id)
+		case err != nil:
+			log.CtxErrorw(ctx, "failed to get task, retry next sync", "taskID", id, "err", err)
+			failedIDs[id] = struct{}{}
+		case gotTask == nil || gotTask.Task == nil:
+			log.CtxErrorw(ctx, "got empty task, retry next sync", "taskID", id)
+			failedIDs[id] = struct{}{}
+		case !isFinished(gotTask.Task.State):
+			tasks[id] = clientTaskToTaskInfo(ctx, gotTask.Task)
+		}
+	})
+	return tasks, failedIDs
 }
 
-func (i *taskCacheImpl) listTasks(ctx context.Context, view string, pageSize int) ([]*clientmodels.Task, error) {
+// observeFinishedTasks feeds running duration of completed tasks to runtimeEstimator
+func (i *taskCacheImpl) observeFinishedTasks(ctx context.Context, finishedTasks []*schemodels.TaskInfo) {
+	if i.runtimeEstimator == nil {
+		return
+	}
+	ids := make([]string, 0, len(finishedTasks))
+	finished := make(map[string]*schemodels.TaskInfo, len(finishedTasks))
+	for _, task := range finishedTasks {
+		ids = append(ids, task.ID)
+		finished[task.ID] = task
+	}
+	i.getTasks(ctx, ids, func(id string, gotTask *clientmodels.GetTaskResponse, err error) {
+		if err != nil {
+			log.CtxWarnw(ctx, "failed to get finished task", "task", id, "err", err)
+			return
+		}
+		if gotTask == nil || gotTask.Task == nil || gotTask.State != consts.TaskComplete {
+			return
+		}
+		if duration, ok := taskRuntime(gotTask.Logs); ok {
+			i.runtimeEstimator.Observe(finished[id], duration)
+		}
+	})
+}
+
+// getTasks gets tasks in BASIC view with at most hydrateConcurrency requests
+// in flight. handle is called serially.
+func (i *taskCacheImpl) getTasks(ctx context.Context, ids []string, handle func(id string, gotTask *clientmodels.GetTaskResponse, err error)) {
+	concurrency := i.hydrateConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+	for _, id := range ids {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(id string) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			gotTask, err := i.vetesClient.GetTask(ctx, &clientmodels.GetTaskRequest{ID: id, View: consts.BasicView})
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			handle(id, gotTask, err)
+		}(id)
+	}
+	wg.Wait()
+}
+
+// listTasks lists all pages of req
+func (i *taskCacheImpl) listTasks(ctx context.Context, req *clientmodels.ListTasksRequest) ([]*clientmodels.Task, error) {
 	res := make([]*clientmodels.Task, 0)
-	var pageToken string
+	pageReq := *req
 	for {
-		resp, err := i.vetesClient.ListTasks(ctx, &clientmodels.ListTasksRequest{
-			State:     nonFinishedStates,
-			View:      view,
-			PageSize:  pageSize,
-			PageToken: pageToken,
-		})
+		resp, err := i.vetesClient.ListTasks(ctx, &pageReq)
 		if err != nil {
 			return nil, err
 		}
@@ -245,7 +664,7 @@ func (i *taskCacheImpl) listTasks(ctx context.Context, view string, pageSize int
 		if resp.NextPageToken == "" {
 			break
 		}
-		pageToken = resp.NextPageToken
+		pageReq.PageToken = resp.NextPageToken
 	}
 	return res, nil
 }
@@ -256,20 +675,44 @@ func clientTaskToTaskInfo(ctx context.Context, task *clientmodels.Task) *schemod
 	}
 	res := &schemodels.TaskInfo{
 		ID:            task.ID,
+		Name:          task.Name,
 		State:         task.State,
 		ClusterID:     task.ClusterID,
 		Resources:     clientTaskResourcesToTaskInfoResources(task.Resources),
 		BioosInfo:     clientTaskBioosInfoToTaskInfoBioosInfo(task.BioosInfo),
 		PriorityValue: task.PriorityValue,
 	}
+	if len(task.Executors) > 0 && task.Executors[0] != nil {
+		res.ExecutorImage = task.Executors[0].Image
+	}
 	var err error
 	res.CreationTime, err = time.Parse(time.RFC3339, task.CreationTime)
 	if err != nil {
 		log.CtxErrorw(ctx, "parse CreationTime of task", "task", task.ID, "err", err)
 	}
+	if deadline, ok := task.Tags[consts.TaskDeadlineTag]; ok {
+		if res.Deadline, err = parseDeadline(deadline, res.CreationTime); err != nil {
+			log.CtxErrorw(ctx, "parse deadline of task", "task", task.ID, "err", err)
+		}
+	}
 	return res
 }
 
+// parseDeadline parses a RFC3339 timestamp, or a duration after creationTime.
+func parseDeadline(deadline string, creationTime time.Time) (time.Time, error) {
+	if res, err := time.Parse(time.RFC3339, deadline); err == nil {
+		return res, nil
+	}
+	duration, err := time.ParseDuration(deadline)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid deadline %q: neither RFC3339 timestamp nor duration", deadline)
+	}
+	if creationTime.IsZero() {
+		return time.Time{}, fmt.Errorf("invalid deadline %q: duration without creation time", deadline)
+	}
+	return creationTime.Add(duration), nil
+}
+
 func clientTaskResourcesToTaskInfoResources(taskResources *clientmodels.Resources) *schemodels.Resources {
 	if taskResources == nil {
 		return nil
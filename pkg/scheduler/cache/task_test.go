@@ -2,16 +2,21 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"sort"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/onsi/gomega"
+	"k8s.io/utils/clock"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/consts"
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 	"github.com/GBA-BI/tes-scheduler/pkg/utils"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
 	vetesclientfake "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/fake"
 	clientmodels "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
 )
@@ -54,15 +59,18 @@ func TestInitCache(t *testing.T) {
 		}, nil)
 
 	i := &taskCacheImpl{
-		vetesClient: fakeVeTESClient,
+		vetesClient:  fakeVeTESClient,
+		syncRecorder: syncRecorder{clock: clock.RealClock{}},
 		data: &data{
 			tasks:          make(map[string]*schemodels.TaskInfo),
 			clusterIndexer: make(map[string]map[string]struct{}),
 		},
 	}
 
+	g.Expect(i.SyncStatus().Synced).To(gomega.BeFalse())
 	err := i.initCache(context.TODO())
 	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(i.SyncStatus().Synced).To(gomega.BeTrue())
 	g.Expect(i.data.tasks).To(gomega.BeEquivalentTo(map[string]*schemodels.TaskInfo{
 		"task-xxxx": {
 			ID:           "task-xxxx",
@@ -130,6 +138,7 @@ func TestSyncTasks(t *testing.T) {
 				DiskGB:   3,
 			},
 			CreationTime: now.Format(time.RFC3339),
+			Tags:         map[string]string{consts.TaskDeadlineTag: "4h"},
 			BioosInfo: &clientmodels.BioosInfo{
 				AccountID:    "account-01",
 				UserID:       "user-01",
@@ -141,7 +150,8 @@ func TestSyncTasks(t *testing.T) {
 		}}, nil)
 
 	i := &taskCacheImpl{
-		vetesClient: fakeVeTESClient,
+		vetesClient:  fakeVeTESClient,
+		syncRecorder: syncRecorder{clock: clock.RealClock{}},
 		data: &data{
 			tasks: map[string]*schemodels.TaskInfo{
 				"task-no-change": {
@@ -179,8 +189,10 @@ func TestSyncTasks(t *testing.T) {
 		},
 	}
 
-	err := i.syncTasks(context.Background())
+	finishedTasks, err := i.syncTasks(context.Background())
 	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(finishedTasks).To(gomega.HaveLen(1))
+	g.Expect(finishedTasks[0].ID).To(gomega.Equal("task-not-exist"))
 	g.Expect(i.data.tasks).To(gomega.BeEquivalentTo(map[string]*schemodels.TaskInfo{
 		"task-no-change": {
 			ID:            "task-no-change",
@@ -217,6 +229,7 @@ func TestSyncTasks(t *testing.T) {
 				RunID:        "run-02",
 			},
 			PriorityValue: 1000,
+			Deadline:      now.Add(4 * time.Hour),
 		},
 	}))
 	g.Expect(i.data.clusterIndexer).To(gomega.BeEquivalentTo(map[string]map[string]struct{}{
@@ -225,6 +238,177 @@ func TestSyncTasks(t *testing.T) {
 	}))
 }
 
+func TestSyncTasksHydrateFailure(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClient.EXPECT().
+		ListTasks(gomock.Any(), gomock.Any()).
+		Return(&clientmodels.ListTasksResponse{
+			Tasks: []*clientmodels.Task{
+				{ID: "task-ok", State: consts.TaskQueued},
+				{ID: "task-failed", State: consts.TaskQueued},
+				{ID: "task-not-found", State: consts.TaskQueued},
+				{ID: "task-empty", State: consts.TaskQueued},
+			},
+		}, nil)
+	fakeVeTESClient.EXPECT().
+		GetTask(gomock.Any(), &clientmodels.GetTaskRequest{ID: "task-ok", View: consts.BasicView}).
+		Return(&clientmodels.GetTaskResponse{Task: &clientmodels.Task{ID: "task-ok", State: consts.TaskQueued}}, nil)
+	fakeVeTESClient.EXPECT().
+		GetTask(gomock.Any(), &clientmodels.GetTaskRequest{ID: "task-failed", View: consts.BasicView}).
+		Return(nil, fmt.Errorf("internal error"))
+	fakeVeTESClient.EXPECT().
+		GetTask(gomock.Any(), &clientmodels.GetTaskRequest{ID: "task-not-found", View: consts.BasicView}).
+		Return(nil, vetesclient.ErrNotFound)
+	fakeVeTESClient.EXPECT().
+		GetTask(gomock.Any(), &clientmodels.GetTaskRequest{ID: "task-empty", View: consts.BasicView}).
+		Return(&clientmodels.GetTaskResponse{}, nil)
+
+	i := &taskCacheImpl{
+		syncRecorder:       syncRecorder{clock: clock.RealClock{}},
+		vetesClient:        fakeVeTESClient,
+		hydrateConcurrency: 2,
+		data: &data{
+			tasks:          map[string]*schemodels.TaskInfo{},
+			clusterIndexer: map[string]map[string]struct{}{},
+		},
+	}
+	_, err := i.syncTasks(context.Background())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(i.data.tasks).To(gomega.HaveLen(1))
+	g.Expect(i.data.tasks).To(gomega.HaveKey("task-ok"))
+	g.Expect(i.hydrateFailedIDs).To(gomega.Equal(map[string]struct{}{"task-failed": {}, "task-empty": {}}))
+
+	// retried by delta sync, though not listed
+	fakeVeTESClient.EXPECT().
+		ListTasks(gomock.Any(), gomock.Any()).
+		Return(&clientmodels.ListTasksResponse{}, nil)
+	fakeVeTESClient.EXPECT().
+		GetTask(gomock.Any(), &clientmodels.GetTaskRequest{ID: "task-failed", View: consts.BasicView}).
+		Return(&clientmodels.GetTaskResponse{Task: &clientmodels.Task{ID: "task-failed", State: consts.TaskQueued}}, nil)
+	fakeVeTESClient.EXPECT().
+		GetTask(gomock.Any(), &clientmodels.GetTaskRequest{ID: "task-empty", View: consts.BasicView}).
+		Return(&clientmodels.GetTaskResponse{Task: &clientmodels.Task{ID: "task-empty", State: consts.TaskQueued}}, nil)
+	_, err = i.deltaSyncTasks(context.Background())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(i.data.tasks).To(gomega.HaveLen(3))
+	g.Expect(i.data.tasks).To(gomega.HaveKey("task-failed"))
+	g.Expect(i.hydrateFailedIDs).To(gomega.BeEmpty())
+}
+
+func TestDeltaSyncTasks(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	watermark := now.Add(-time.Minute)
+
+	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClient.EXPECT().
+		ListTasks(gomock.Any(), &clientmodels.ListTasksRequest{
+			ModifiedSince: watermark.Add(-deltaSyncOverlap).Format(time.RFC3339),
+			View:          consts.MinimalView,
+			PageSize:      consts.MaximumPageSize,
+		}).
+		Return(&clientmodels.ListTasksResponse{
+			Tasks: []*clientmodels.Task{{
+				ID:    "task-change",
+				State: consts.TaskCanceling,
+			}, {
+				ID:    "task-finished",
+				State: consts.TaskComplete,
+			}, {
+				ID:    "task-new",
+				State: consts.TaskQueued,
+			}, {
+				ID:    "task-new-finished",
+				State: consts.TaskExecutorError,
+			}},
+		}, nil)
+	fakeVeTESClient.EXPECT().
+		GetTask(gomock.Any(), &clientmodels.GetTaskRequest{
+			ID:   "task-new",
+			View: consts.BasicView,
+		}).
+		Return(&clientmodels.GetTaskResponse{Task: &clientmodels.Task{
+			ID:           "task-new",
+			State:        consts.TaskQueued,
+			CreationTime: now.Format(time.RFC3339),
+		}}, nil)
+
+	i := &taskCacheImpl{
+		vetesClient:  fakeVeTESClient,
+		syncRecorder: syncRecorder{clock: clock.RealClock{}},
+		watermark:    watermark,
+		data: &data{
+			tasks: map[string]*schemodels.TaskInfo{
+				"task-no-change": {ID: "task-no-change", State: consts.TaskQueued},
+				"task-change":    {ID: "task-change", State: consts.TaskRunning, ClusterID: "cluster-01"},
+				"task-finished":  {ID: "task-finished", State: consts.TaskRunning, ClusterID: "cluster-01"},
+			},
+			clusterIndexer: map[string]map[string]struct{}{
+				"":           {"task-no-change": {}},
+				"cluster-01": {"task-change": {}, "task-finished": {}},
+			},
+		},
+	}
+
+	finishedTasks, err := i.deltaSyncTasks(context.Background())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(finishedTasks).To(gomega.HaveLen(1))
+	g.Expect(finishedTasks[0].ID).To(gomega.Equal("task-finished"))
+	g.Expect(i.data.tasks).To(gomega.BeEquivalentTo(map[string]*schemodels.TaskInfo{
+		"task-no-change": {ID: "task-no-change", State: consts.TaskQueued},
+		"task-change":    {ID: "task-change", State: consts.TaskCanceling, ClusterID: "cluster-01"},
+		"task-new":       {ID: "task-new", State: consts.TaskQueued, CreationTime: now},
+	}))
+	g.Expect(i.data.clusterIndexer).To(gomega.BeEquivalentTo(map[string]map[string]struct{}{
+		"":           {"task-no-change": {}, "task-new": {}},
+		"cluster-01": {"task-change": {}},
+	}))
+	g.Expect(i.watermark.After(watermark)).To(gomega.BeTrue())
+}
+
+func TestDeltaSyncTasksSkipLocalUpdates(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	i := &taskCacheImpl{
+		syncRecorder: syncRecorder{clock: clock.RealClock{}},
+		data: &data{
+			tasks: map[string]*schemodels.TaskInfo{
+				"task-01": {ID: "task-01", State: consts.TaskQueued},
+			},
+			clusterIndexer: map[string]map[string]struct{}{
+				"": {"task-01": {}},
+			},
+		},
+	}
+
+	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClient.EXPECT().
+		ListTasks(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ *clientmodels.ListTasksRequest) (*clientmodels.ListTasksResponse, error) {
+			// task is scheduled during listing
+			g.Expect(i.UpdateTask(ctx, "task-01", nil, utils.Point("cluster-01"), nil)).To(gomega.Succeed())
+			return &clientmodels.ListTasksResponse{
+				Tasks: []*clientmodels.Task{{ID: "task-01", State: consts.TaskQueued}},
+			}, nil
+		})
+	fakeVeTESClient.EXPECT().UpdateTask(gomock.Any(), gomock.Any()).Return(&clientmodels.UpdateTaskResponse{}, nil)
+	i.vetesClient = fakeVeTESClient
+
+	_, err := i.deltaSyncTasks(context.Background())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(i.data.tasks["task-01"].ClusterID).To(gomega.Equal("cluster-01"))
+	g.Expect(i.localUpdates).To(gomega.BeEmpty())
+}
+
 func TestListTasks(t *testing.T) {
 	g := gomega.NewWithT(t)
 
@@ -339,9 +523,10 @@ func TestUpdateTaskChangeStateAndClusterID(t *testing.T) {
 	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
 	fakeVeTESClient.EXPECT().
 		UpdateTask(gomock.Any(), &clientmodels.UpdateTaskRequest{
-			ID:        "task-0001",
-			State:     utils.Point(consts.TaskQueued),
-			ClusterID: utils.Point(""),
+			ID:            "task-0001",
+			State:         utils.Point(consts.TaskQueued),
+			ClusterID:     utils.Point(""),
+			ExpectedState: utils.Point(consts.TaskRunning),
 		}).Return(&clientmodels.UpdateTaskResponse{}, nil)
 
 	i := &taskCacheImpl{
@@ -389,6 +574,7 @@ func TestUpdateTaskChangeStateFinishedWithMessage(t *testing.T) {
 				ClusterID:  schedulerName,
 				SystemLogs: []string{"message"},
 			}},
+			ExpectedState: utils.Point(consts.TaskCanceling),
 		}).Return(&clientmodels.UpdateTaskResponse{}, nil)
 
 	i := &taskCacheImpl{
@@ -420,8 +606,9 @@ func TestUpdateTaskChangeClusterID(t *testing.T) {
 	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
 	fakeVeTESClient.EXPECT().
 		UpdateTask(gomock.Any(), &clientmodels.UpdateTaskRequest{
-			ID:        "task-0001",
-			ClusterID: utils.Point("cluster-02"),
+			ID:            "task-0001",
+			ClusterID:     utils.Point("cluster-02"),
+			ExpectedState: utils.Point(consts.TaskQueued),
 		}).Return(&clientmodels.UpdateTaskResponse{}, nil)
 
 	i := taskCacheImpl{
@@ -454,3 +641,221 @@ func TestUpdateTaskChangeClusterID(t *testing.T) {
 		"cluster-02": {"task-0001": {}},
 	}))
 }
+
+func TestUpdateTaskConflict(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClient.EXPECT().
+		UpdateTask(gomock.Any(), &clientmodels.UpdateTaskRequest{
+			ID:            "task-0001",
+			ClusterID:     utils.Point("cluster-02"),
+			ExpectedState: utils.Point(consts.TaskQueued),
+		}).Return(nil, fmt.Errorf("state changed: %w", vetesclient.ErrConflict))
+	fakeVeTESClient.EXPECT().
+		GetTask(gomock.Any(), &clientmodels.GetTaskRequest{ID: "task-0001", View: consts.MinimalView}).
+		Return(&clientmodels.GetTaskResponse{Task: &clientmodels.Task{ID: "task-0001", State: consts.TaskCanceling}}, nil)
+	fakeVeTESClient.EXPECT().
+		UpdateTask(gomock.Any(), &clientmodels.UpdateTaskRequest{
+			ID:            "task-0002",
+			ClusterID:     utils.Point("cluster-02"),
+			ExpectedState: utils.Point(consts.TaskQueued),
+		}).Return(nil, fmt.Errorf("state changed: %w", vetesclient.ErrConflict))
+	fakeVeTESClient.EXPECT().
+		GetTask(gomock.Any(), &clientmodels.GetTaskRequest{ID: "task-0002", View: consts.MinimalView}).
+		Return(&clientmodels.GetTaskResponse{Task: &clientmodels.Task{ID: "task-0002", State: consts.TaskCanceled}}, nil)
+
+	i := taskCacheImpl{
+		vetesClient: fakeVeTESClient,
+		data: &data{
+			tasks: map[string]*schemodels.TaskInfo{
+				"task-0001": {ID: "task-0001", State: consts.TaskQueued},
+				"task-0002": {ID: "task-0002", State: consts.TaskQueued},
+			},
+			clusterIndexer: map[string]map[string]struct{}{
+				"": {"task-0001": {}, "task-0002": {}},
+			},
+		},
+	}
+	err := i.UpdateTask(context.Background(), "task-0001", nil, utils.Point("cluster-02"), nil)
+	g.Expect(errors.Is(err, vetesclient.ErrConflict)).To(gomega.BeTrue())
+	err = i.UpdateTask(context.Background(), "task-0002", nil, utils.Point("cluster-02"), nil)
+	g.Expect(errors.Is(err, vetesclient.ErrConflict)).To(gomega.BeTrue())
+
+	// refetched, and finished one is deleted
+	g.Expect(i.data.tasks).To(gomega.BeEquivalentTo(map[string]*schemodels.TaskInfo{
+		"task-0001": {ID: "task-0001", State: consts.TaskCanceling},
+	}))
+	g.Expect(i.localUpdates).To(gomega.HaveLen(2))
+}
+
+func TestBatchUpdateTasks(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClient.EXPECT().
+		BatchUpdateTasks(gomock.Any(), &clientmodels.BatchUpdateTasksRequest{Tasks: []*clientmodels.UpdateTaskRequest{
+			{ID: "task-0001", ClusterID: utils.Point("cluster-02"), ExpectedState: utils.Point(consts.TaskQueued)},
+			{ID: "task-0002", ClusterID: utils.Point("cluster-02"), ExpectedState: utils.Point(consts.TaskQueued)},
+			{ID: "task-0003", ClusterID: utils.Point("cluster-02"), ExpectedState: utils.Point(consts.TaskQueued)},
+		}}).
+		Return(&clientmodels.BatchUpdateTasksResponse{Results: []*clientmodels.BatchUpdateTaskResult{
+			{ID: "task-0001", StatusCode: http.StatusOK},
+			{ID: "task-0002", StatusCode: http.StatusConflict, Error: &vetesclient.APIError{StatusCode: http.StatusConflict}},
+			{ID: "task-0003", StatusCode: http.StatusInternalServerError, Error: &vetesclient.APIError{StatusCode: http.StatusInternalServerError}},
+		}}, nil)
+	fakeVeTESClient.EXPECT().
+		GetTask(gomock.Any(), &clientmodels.GetTaskRequest{ID: "task-0002", View: consts.MinimalView}).
+		Return(&clientmodels.GetTaskResponse{Task: &clientmodels.Task{ID: "task-0002", State: consts.TaskCanceling}}, nil)
+
+	i := taskCacheImpl{
+		vetesClient: fakeVeTESClient,
+		data: &data{
+			tasks: map[string]*schemodels.TaskInfo{
+				"task-0001": {ID: "task-0001", State: consts.TaskQueued},
+				"task-0002": {ID: "task-0002", State: consts.TaskQueued},
+				"task-0003": {ID: "task-0003", State: consts.TaskQueued},
+			},
+			clusterIndexer: map[string]map[string]struct{}{
+				"": {"task-0001": {}, "task-0002": {}, "task-0003": {}},
+			},
+		},
+	}
+	res := i.BatchUpdateTasks(context.Background(), []*TaskUpdate{
+		{ID: "task-0001", ClusterID: utils.Point("cluster-02")},
+		{ID: "task-0002", ClusterID: utils.Point("cluster-02")},
+		{ID: "task-0003", ClusterID: utils.Point("cluster-02")},
+	})
+	g.Expect(res).To(gomega.HaveLen(2))
+	g.Expect(errors.Is(res["task-0002"], vetesclient.ErrConflict)).To(gomega.BeTrue())
+	g.Expect(errors.Is(res["task-0003"], vetesclient.ErrServerError)).To(gomega.BeTrue())
+
+	// succeeded one is updated, conflicted one is refetched, failed one is unchanged
+	g.Expect(i.data.tasks).To(gomega.BeEquivalentTo(map[string]*schemodels.TaskInfo{
+		"task-0001": {ID: "task-0001", State: consts.TaskQueued, ClusterID: "cluster-02"},
+		"task-0002": {ID: "task-0002", State: consts.TaskCanceling},
+		"task-0003": {ID: "task-0003", State: consts.TaskQueued},
+	}))
+}
+
+func TestBatchUpdateTasksError(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeVeTESClient := vetesclientfake.NewFakeClient(ctrl)
+	fakeVeTESClient.EXPECT().BatchUpdateTasks(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("circuit open"))
+
+	i := taskCacheImpl{
+		vetesClient: fakeVeTESClient,
+		data:        &data{tasks: map[string]*schemodels.TaskInfo{}},
+	}
+	res := i.BatchUpdateTasks(context.Background(), []*TaskUpdate{
+		{ID: "task-0001", State: utils.Point(consts.TaskCanceled)},
+		{ID: "task-0002", State: utils.Point(consts.TaskCanceled)},
+	})
+	g.Expect(res).To(gomega.HaveLen(2))
+	g.Expect(res["task-0001"]).To(gomega.MatchError("circuit open"))
+	g.Expect(i.BatchUpdateTasks(context.Background(), nil)).To(gomega.BeEmpty())
+}
+
+func TestParseDeadline(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name         string
+		deadline     string
+		creationTime time.Time
+		expDeadline  time.Time
+		expErr       bool
+	}{
+		{
+			name:        "timestamp",
+			deadline:    now.Add(time.Hour).Format(time.RFC3339),
+			expDeadline: now.Add(time.Hour),
+		},
+		{
+			name:         "duration",
+			deadline:     "90m",
+			creationTime: now,
+			expDeadline:  now.Add(90 * time.Minute),
+		},
+		{
+			name:     "duration without creation time",
+			deadline: "90m",
+			expErr:   true,
+		},
+		{
+			name:         "invalid",
+			deadline:     "tomorrow",
+			creationTime: now,
+			expErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deadline, err := parseDeadline(test.deadline, test.creationTime)
+			if test.expErr {
+				g.Expect(err).To(gomega.HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(deadline.Equal(test.expDeadline)).To(gomega.BeTrue())
+		})
+	}
+}
+
+func TestDataUsages(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	d := &data{
+		tasks:          make(map[string]*schemodels.TaskInfo),
+		clusterIndexer: make(map[string]map[string]struct{}),
+	}
+	d.addTask(&schemodels.TaskInfo{
+		ID:        "task-01",
+		Resources: &schemodels.Resources{CPUCores: 2},
+		BioosInfo: &schemodels.BioosInfo{AccountID: "account-01", UserID: "user-01"},
+	})
+	d.addTask(&schemodels.TaskInfo{
+		ID:        "task-02",
+		ClusterID: "cluster-01",
+		Resources: &schemodels.Resources{CPUCores: 1},
+	})
+	g.Expect(d.usages).To(gomega.Equal(map[UsageKey]*schemodels.Usage{
+		{}:                        {Count: 1, CPUCores: 1},
+		{ClusterID: "cluster-01"}: {Count: 1, CPUCores: 1},
+	}))
+
+	d.updateTask("task-01", nil, utils.Point("cluster-02"))
+	g.Expect(d.usages).To(gomega.Equal(map[UsageKey]*schemodels.Usage{
+		{}:                        {Count: 2, CPUCores: 3},
+		{ClusterID: "cluster-01"}: {Count: 1, CPUCores: 1},
+		{ClusterID: "cluster-02"}: {Count: 1, CPUCores: 2},
+		{AccountID: "account-01"}: {Count: 1, CPUCores: 2},
+		{AccountID: "account-01", UserID: "user-01"}: {Count: 1, CPUCores: 2},
+	}))
+
+	d.updateTask("task-01", utils.Point(consts.TaskRunning), nil)
+	d.deleteTask("task-02")
+	g.Expect(d.usages).To(gomega.Equal(map[UsageKey]*schemodels.Usage{
+		{}:                        {Count: 1, CPUCores: 2},
+		{ClusterID: "cluster-02"}: {Count: 1, CPUCores: 2},
+		{AccountID: "account-01"}: {Count: 1, CPUCores: 2},
+		{AccountID: "account-01", UserID: "user-01"}: {Count: 1, CPUCores: 2},
+	}))
+
+	// re-add replaces the old one
+	d.addTask(&schemodels.TaskInfo{ID: "task-01", ClusterID: "cluster-02", Resources: &schemodels.Resources{CPUCores: 4}})
+	g.Expect(d.usages).To(gomega.Equal(map[UsageKey]*schemodels.Usage{
+		{}:                        {Count: 1, CPUCores: 4},
+		{ClusterID: "cluster-02"}: {Count: 1, CPUCores: 4},
+	}))
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+)
+
+// UsageCache caches usage of scheduled tasks. It is maintained incrementally
+// by TaskCache, so that plugins need not sum up all scheduled tasks.
+type UsageCache interface {
+	// GetUsage returns a copy of usage of key, never nil
+	GetUsage(key UsageKey) *schemodels.Usage
+	// ListTasksWithUsages returns all tasks and a copy of all usages, consistent with each other
+	ListTasksWithUsages() ([]*schemodels.TaskInfo, map[UsageKey]*schemodels.Usage)
+}
+
+// UsageKey indexes usage of scheduled tasks. Empty fields match all, so the
+// zero key is the global usage, {ClusterID} is the usage of a cluster, {AccountID}
+// is the usage of an account and {AccountID, UserID} is the usage of a user.
+type UsageKey struct {
+	ClusterID string
+	AccountID string
+	UserID    string
+}
+
+// usageKeys returns all keys which task is counted in, nil if task is not scheduled.
+func usageKeys(task *schemodels.TaskInfo) []UsageKey {
+	if task.ClusterID == "" {
+		return nil
+	}
+	keys := []UsageKey{{}, {ClusterID: task.ClusterID}}
+	if task.BioosInfo == nil || task.BioosInfo.AccountID == "" {
+		return keys
+	}
+	keys = append(keys, UsageKey{AccountID: task.BioosInfo.AccountID})
+	if task.BioosInfo.UserID != "" {
+		keys = append(keys, UsageKey{AccountID: task.BioosInfo.AccountID, UserID: task.BioosInfo.UserID})
+	}
+	return keys
+}
+
+func addUsage(usages map[UsageKey]*schemodels.Usage, task *schemodels.TaskInfo) {
+	for _, key := range usageKeys(task) {
+		usage, ok := usages[key]
+		if !ok {
+			usage = &schemodels.Usage{}
+			usages[key] = usage
+		}
+		usage.Add(task.Resources)
+	}
+}
+
+func subUsage(usages map[UsageKey]*schemodels.Usage, task *schemodels.TaskInfo) {
+	for _, key := range usageKeys(task) {
+		usage, ok := usages[key]
+		if !ok {
+			continue
+		}
+		usage.Sub(task.Resources)
+		if usage.IsZero() {
+			delete(usages, key)
+		}
+	}
+}
+
+func copyUsages(usages map[UsageKey]*schemodels.Usage) map[UsageKey]*schemodels.Usage {
+	res := make(map[UsageKey]*schemodels.Usage, len(usages))
+	for key, usage := range usages {
+		res[key] = usage.DeepCopy()
+	}
+	return res
+}
@@ -2,45 +2,61 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/log"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/clock"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	"github.com/GBA-BI/tes-scheduler/pkg/metrics"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
-	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/crontab"
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/runner"
 	"github.com/GBA-BI/tes-scheduler/pkg/utils"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
 )
 
 // Controller is some extra logic controlling task and cluster
 type Controller struct {
 	cache *cache.Cache
+	clock clock.PassiveClock
 
 	clusterRescheduleTimeout time.Duration
 }
 
 // Init ...
-func Init(opts *Options, cache *cache.Cache) error {
+func Init(opts *Options, cache *cache.Cache, jobs runner.Registerer) error {
 	c := &Controller{
 		cache:                    cache,
+		clock:                    cache.Clock,
 		clusterRescheduleTimeout: opts.ClusterRescheduleTimeout,
 	}
-	if err := crontab.RegisterCron(opts.Period, func() {
+	if err := jobs.Register("controller-reschedule", opts.Period, func() error {
 		ctx := context.Background()
+		if err := c.cache.Stale(); err != nil {
+			log.CtxWarnw(ctx, "cache is stale, skip rescheduling", "err", err)
+			return nil
+		}
 		if err := c.rescheduleTasks(ctx); err != nil {
-			log.CtxErrorw(ctx, "reschedule cluster failed", "err", err)
+			return fmt.Errorf("reschedule cluster failed: %w", err)
 		}
+		return nil
 	}); err != nil {
 		return err
 	}
-	if err := crontab.RegisterCron(opts.Period, func() {
+	if err := jobs.Register("controller-mark-failed", opts.Period, func() error {
 		ctx := context.Background()
+		if err := c.cache.Stale(); err != nil {
+			log.CtxWarnw(ctx, "cache is stale, skip marking tasks failed", "err", err)
+			return nil
+		}
 		if err := c.markTasksFailedNotMeetLimits(ctx); err != nil {
-			log.CtxErrorw(ctx, "mark tasks failed not meet limits failed", "err", err)
+			return fmt.Errorf("mark tasks failed not meet limits failed: %w", err)
 		}
+		return nil
 	}); err != nil {
 		return err
 	}
@@ -56,7 +72,7 @@ func (c *Controller) rescheduleTasks(ctx context.Context) error {
 	shouldRescheduleClusters := make([]string, 0)
 	for _, cluster := range clusters {
 		existClustersMap[cluster.ID] = struct{}{}
-		if time.Since(cluster.HeartbeatTimestamp) > c.clusterRescheduleTimeout {
+		if c.clock.Since(cluster.HeartbeatTimestamp) > c.clusterRescheduleTimeout {
 			shouldRescheduleClusters = append(shouldRescheduleClusters, cluster.ID)
 		}
 	}
@@ -66,31 +82,28 @@ func (c *Controller) rescheduleTasks(ctx context.Context) error {
 		}
 	}
 
-	var errs []error
+	updates := make([]*cache.TaskUpdate, 0)
 	for _, clusterID := range shouldRescheduleClusters {
 		tasks := c.cache.TaskCache.ListTasks(clusterID)
 		for _, task := range tasks {
-			if err := c.rescheduleTask(ctx, task); err != nil {
-				errs = append(errs, err)
-			}
+			updates = append(updates, rescheduleUpdate(task))
 		}
 	}
-	return utilerrors.NewAggregate(errs)
+	return c.batchUpdateTasks(ctx, updates, func(update *cache.TaskUpdate) {
+		if *update.State == consts.TaskCanceled {
+			log.CtxInfow(ctx, "directly cancel task need to be rescheduled", "task", update.ID)
+			return
+		}
+		metrics.ControllerRescheduledTasks.Inc()
+		log.CtxInfow(ctx, "reschedule task", "task", update.ID)
+	})
 }
 
-func (c *Controller) rescheduleTask(ctx context.Context, task *schemodels.TaskInfo) error {
+func rescheduleUpdate(task *schemodels.TaskInfo) *cache.TaskUpdate {
 	if task.State == consts.TaskCanceling {
-		if err := c.cache.TaskCache.UpdateTask(ctx, task.ID, utils.Point(consts.TaskCanceled), nil, nil); err != nil {
-			return err
-		}
-		log.CtxInfow(ctx, "directly cancel task need to be rescheduled", "task", task.ID)
-		return nil
-	}
-	if err := c.cache.TaskCache.UpdateTask(ctx, task.ID, utils.Point(consts.TaskQueued), utils.Point(""), nil); err != nil {
-		return err
+		return &cache.TaskUpdate{ID: task.ID, State: utils.Point(consts.TaskCanceled)}
 	}
-	log.CtxInfow(ctx, "reschedule task", "task", task.ID)
-	return nil
+	return &cache.TaskUpdate{ID: task.ID, State: utils.Point(consts.TaskQueued), ClusterID: utils.Point("")}
 }
 
 // markTasksFailedNotMeetLimits marks tasks that do not meet cluster limits as failed
@@ -103,7 +116,7 @@ func (c *Controller) markTasksFailedNotMeetLimits(ctx context.Context) error {
 		return nil
 	}
 
-	var errs []error
+	updates := make([]*cache.TaskUpdate, 0)
 	for _, task := range tasks {
 		if task.State != consts.TaskQueued {
 			continue
@@ -112,13 +125,49 @@ func (c *Controller) markTasksFailedNotMeetLimits(ctx context.Context) error {
 		if msg == "" {
 			continue
 		}
-		if err := c.cache.TaskCache.UpdateTask(ctx, task.ID, utils.Point(consts.TaskSystemError), nil, utils.Point(fmt.Sprintf("no cluster limits match task resources: %s", msg))); err != nil {
+		updates = append(updates, &cache.TaskUpdate{
+			ID:      task.ID,
+			State:   utils.Point(consts.TaskSystemError),
+			Message: utils.Point(fmt.Sprintf("no cluster limits match task resources: %s", msg)),
+		})
+	}
+	return c.batchUpdateTasks(ctx, updates, func(update *cache.TaskUpdate) {
+		metrics.ControllerFailedTasks.Inc()
+		log.CtxInfow(ctx, "mark task failed not meet limits", "task", update.ID)
+	})
+}
+
+// batchUpdateTasks updates tasks in one batch, onSuccess is called for each succeeded one,
+// and errors of failed ones are aggregated except conflicts.
+func (c *Controller) batchUpdateTasks(ctx context.Context, updates []*cache.TaskUpdate, onSuccess func(update *cache.TaskUpdate)) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	res := c.cache.TaskCache.BatchUpdateTasks(ctx, updates)
+	var errs []error
+	for _, update := range updates {
+		err, ok := res[update.ID]
+		if !ok {
+			onSuccess(update)
+			continue
+		}
+		if err = skipConflict(ctx, update.ID, err); err != nil {
 			errs = append(errs, err)
 		}
 	}
 	return utilerrors.NewAggregate(errs)
 }
 
+// skipConflict returns nil if the task has been changed by others, it is refetched
+// by cache and will be handled in next period if still needed.
+func skipConflict(ctx context.Context, taskID string, err error) error {
+	if errors.Is(err, vetesclient.ErrConflict) {
+		log.CtxInfow(ctx, "task changed concurrently, skip it", "task", taskID, "err", err)
+		return nil
+	}
+	return err
+}
+
 func taskMeetLimits(task *schemodels.TaskInfo, clusters []*schemodels.ClusterInfo) string {
 	var errs []error
 	for _, cluster := range clusters {
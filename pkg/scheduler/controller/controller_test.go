@@ -2,17 +2,22 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	testingclock "k8s.io/utils/clock/testing"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	"github.com/GBA-BI/tes-scheduler/pkg/metrics"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache/fake"
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 	"github.com/GBA-BI/tes-scheduler/pkg/utils"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
 )
 
 func TestRescheduleTask(t *testing.T) {
@@ -20,7 +25,8 @@ func TestRescheduleTask(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	now := time.Now()
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	now := fakeClock.Now()
 
 	fakeClusterCache := fake.NewFakeClusterCache(ctrl)
 	fakeClusterCache.EXPECT().ListClusters().
@@ -41,28 +47,27 @@ func TestRescheduleTask(t *testing.T) {
 			{ID: "task-03", ClusterID: "cluster-deleted", State: consts.TaskQueued},
 			{ID: "task-04", ClusterID: "cluster-deleted", State: consts.TaskCanceling},
 		})
-	fakeTaskCache.EXPECT().
-		UpdateTask(gomock.Any(), "task-01", utils.Point(consts.TaskQueued), utils.Point(""), nil).
-		Return(nil)
-	fakeTaskCache.EXPECT().
-		UpdateTask(gomock.Any(), "task-02", utils.Point(consts.TaskCanceled), nil, nil).
-		Return(nil)
-	fakeTaskCache.EXPECT().
-		UpdateTask(gomock.Any(), "task-03", utils.Point(consts.TaskQueued), utils.Point(""), nil).
-		Return(nil)
-	fakeTaskCache.EXPECT().
-		UpdateTask(gomock.Any(), "task-04", utils.Point(consts.TaskCanceled), nil, nil).
-		Return(nil)
+	fakeTaskCache.EXPECT().BatchUpdateTasks(gomock.Any(), []*cache.TaskUpdate{
+		{ID: "task-01", State: utils.Point(consts.TaskQueued), ClusterID: utils.Point("")},
+		{ID: "task-02", State: utils.Point(consts.TaskCanceled)},
+		{ID: "task-03", State: utils.Point(consts.TaskQueued), ClusterID: utils.Point("")},
+		{ID: "task-04", State: utils.Point(consts.TaskCanceled)},
+	}).Return(map[string]error{"task-04": fmt.Errorf("canceled already: %w", vetesclient.ErrConflict)})
 
 	c := &Controller{
 		cache: &cache.Cache{
 			TaskCache:    fakeTaskCache,
 			ClusterCache: fakeClusterCache,
 		},
+		clock:                    fakeClock,
 		clusterRescheduleTimeout: time.Minute * 20,
 	}
+	// conflict is skipped
+	rescheduled := testutil.ToFloat64(metrics.ControllerRescheduledTasks)
 	err := c.rescheduleTasks(context.Background())
 	g.Expect(err).NotTo(gomega.HaveOccurred())
+	// canceled ones are not counted
+	g.Expect(testutil.ToFloat64(metrics.ControllerRescheduledTasks)).To(gomega.Equal(rescheduled + 2))
 }
 
 func TestMarkTasksFailedNotMeetLimits(t *testing.T) {
@@ -138,7 +143,13 @@ func TestMarkTasksFailedNotMeetLimits(t *testing.T) {
 			fakeTaskCache := fake.NewFakeTaskCache(ctrl)
 			fakeTaskCache.EXPECT().ListTasks("").Return([]*schemodels.TaskInfo{test.task})
 			if test.mark {
-				fakeTaskCache.EXPECT().UpdateTask(gomock.Any(), test.task.ID, utils.Point(consts.TaskSystemError), nil, gomock.Any())
+				fakeTaskCache.EXPECT().BatchUpdateTasks(gomock.Any(), gomock.Len(1)).
+					DoAndReturn(func(_ context.Context, updates []*cache.TaskUpdate) map[string]error {
+						g.Expect(updates[0].ID).To(gomega.Equal(test.task.ID))
+						g.Expect(updates[0].State).To(gomega.Equal(utils.Point(consts.TaskSystemError)))
+						g.Expect(updates[0].Message).NotTo(gomega.BeNil())
+						return nil
+					})
 			}
 			c := &Controller{cache: &cache.Cache{
 				ClusterCache: fakeClusterCache,
@@ -149,3 +160,25 @@ func TestMarkTasksFailedNotMeetLimits(t *testing.T) {
 		})
 	}
 }
+
+func TestBatchUpdateTasksError(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	updates := []*cache.TaskUpdate{
+		{ID: "task-01", State: utils.Point(consts.TaskQueued)},
+		{ID: "task-02", State: utils.Point(consts.TaskQueued)},
+	}
+	fakeTaskCache := fake.NewFakeTaskCache(ctrl)
+	fakeTaskCache.EXPECT().BatchUpdateTasks(gomock.Any(), updates).
+		Return(map[string]error{"task-02": fmt.Errorf("internal error")})
+
+	c := &Controller{cache: &cache.Cache{TaskCache: fakeTaskCache}}
+	var succeeded []string
+	err := c.batchUpdateTasks(context.Background(), updates, func(update *cache.TaskUpdate) {
+		succeeded = append(succeeded, update.ID)
+	})
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("internal error")))
+	g.Expect(succeeded).To(gomega.Equal([]string{"task-01"}))
+}
@@ -8,6 +8,7 @@ type ClusterInfo struct {
 	HeartbeatTimestamp time.Time
 	Capacity           *Capacity
 	Limits             *Limits
+	Price              *Price
 }
 
 // Capacity ...
@@ -35,3 +36,12 @@ type Limits struct {
 type GPULimit struct {
 	GPU map[string]float64
 }
+
+// Price is the price of resources per hour
+type Price struct {
+	CPUCoreHour float64
+	RamGBHour   float64 // nolint
+	DiskGBHour  float64
+	// GPUHour is GPUType -> price per GPU hour
+	GPUHour map[string]float64
+}
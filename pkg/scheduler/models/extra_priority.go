@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // ExtraPriorityInfo ...
 type ExtraPriorityInfo struct {
 	AccountID          string
@@ -7,11 +9,29 @@ type ExtraPriorityInfo struct {
 	SubmissionID       string
 	RunID              string
 	ExtraPriorityValue int
+	// StartTime and ExpireTime bound the validity, zero means unbounded
+	StartTime  time.Time
+	ExpireTime time.Time
+	Reason     string
+}
+
+// ActiveAt returns whether the extraPriority is valid at now
+func (e *ExtraPriorityInfo) ActiveAt(now time.Time) bool {
+	if e == nil {
+		return false
+	}
+	if !e.StartTime.IsZero() && now.Before(e.StartTime) {
+		return false
+	}
+	if !e.ExpireTime.IsZero() && !now.Before(e.ExpireTime) {
+		return false
+	}
+	return true
 }
 
-// MatchTask ...
-func (e *ExtraPriorityInfo) MatchTask(task *TaskInfo) bool {
-	if e == nil || task == nil || task.BioosInfo == nil {
+// MatchTaskAt returns whether the extraPriority is active at now and matches the task
+func (e *ExtraPriorityInfo) MatchTaskAt(task *TaskInfo, now time.Time) bool {
+	if e == nil || task == nil || task.BioosInfo == nil || !e.ActiveAt(now) {
 		return false
 	}
 	if e.AccountID != "" && e.AccountID == task.BioosInfo.AccountID {
@@ -25,3 +45,95 @@ func (e *ExtraPriorityInfo) MatchTask(task *TaskInfo) bool {
 	}
 	return false
 }
+
+// ExtraPriorityIndex indexes extraPriorities by account, account/user, submission
+// and run, so that matched extraPriorities of a task are found without a full scan.
+// It is immutable after created.
+type ExtraPriorityIndex struct {
+	extraPriorities []*ExtraPriorityInfo
+	byAccount       map[string][]*ExtraPriorityInfo
+	byAccountUser   map[string][]*ExtraPriorityInfo
+	bySubmission    map[string][]*ExtraPriorityInfo
+	byRun           map[string][]*ExtraPriorityInfo
+}
+
+// NewExtraPriorityIndex ...
+func NewExtraPriorityIndex(extraPriorities []*ExtraPriorityInfo) *ExtraPriorityIndex {
+	index := &ExtraPriorityIndex{
+		extraPriorities: extraPriorities,
+		byAccount:       make(map[string][]*ExtraPriorityInfo),
+		byAccountUser:   make(map[string][]*ExtraPriorityInfo),
+		bySubmission:    make(map[string][]*ExtraPriorityInfo),
+		byRun:           make(map[string][]*ExtraPriorityInfo),
+	}
+	for _, e := range extraPriorities {
+		if e == nil {
+			continue
+		}
+		// an extraPriority with account mismatched may still match by submission or run,
+		// so it is indexed by all its fields, and verified by MatchTaskAt in MatchAt.
+		if e.AccountID != "" {
+			if e.UserID == "" {
+				index.byAccount[e.AccountID] = append(index.byAccount[e.AccountID], e)
+			} else {
+				key := accountUserKey(e.AccountID, e.UserID)
+				index.byAccountUser[key] = append(index.byAccountUser[key], e)
+			}
+		}
+		if e.SubmissionID != "" {
+			index.bySubmission[e.SubmissionID] = append(index.bySubmission[e.SubmissionID], e)
+		}
+		if e.RunID != "" {
+			index.byRun[e.RunID] = append(index.byRun[e.RunID], e)
+		}
+	}
+	return index
+}
+
+// List returns all indexed extraPriorities
+func (i *ExtraPriorityIndex) List() []*ExtraPriorityInfo {
+	if i == nil {
+		return nil
+	}
+	return i.extraPriorities
+}
+
+// MatchAt returns all extraPriorities active at now and matching the task
+func (i *ExtraPriorityIndex) MatchAt(task *TaskInfo, now time.Time) []*ExtraPriorityInfo {
+	if i == nil || task == nil || task.BioosInfo == nil {
+		return nil
+	}
+	var res []*ExtraPriorityInfo
+	seen := make(map[*ExtraPriorityInfo]struct{})
+	for _, candidates := range [][]*ExtraPriorityInfo{
+		i.byAccount[task.BioosInfo.AccountID],
+		i.byAccountUser[accountUserKey(task.BioosInfo.AccountID, task.BioosInfo.UserID)],
+		i.bySubmission[task.BioosInfo.SubmissionID],
+		i.byRun[task.BioosInfo.RunID],
+	} {
+		for _, e := range candidates {
+			if _, ok := seen[e]; ok {
+				continue
+			}
+			seen[e] = struct{}{}
+			if e.MatchTaskAt(task, now) {
+				res = append(res, e)
+			}
+		}
+	}
+	return res
+}
+
+// EffectivePriorityAt is the PriorityValue of task plus ExtraPriorityValue of all
+// extraPriorities active at now and matching the task.
+func (i *ExtraPriorityIndex) EffectivePriorityAt(task *TaskInfo, now time.Time) int {
+	value := task.PriorityValue
+	for _, e := range i.MatchAt(task, now) {
+		value += e.ExtraPriorityValue
+	}
+	return value
+}
+
+func accountUserKey(accountID, userID string) string {
+	return accountID + "/" + userID
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/onsi/gomega"
 )
@@ -9,6 +10,7 @@ import (
 func TestMatchTask(t *testing.T) {
 	g := gomega.NewWithT(t)
 
+	now := time.Now()
 	task := &TaskInfo{BioosInfo: &BioosInfo{
 		AccountID:    "account-01",
 		UserID:       "user-01",
@@ -70,7 +72,113 @@ func TestMatchTask(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			g.Expect(test.extraPriority.MatchTask(task)).To(gomega.Equal(test.expMatch))
+			g.Expect(test.extraPriority.MatchTaskAt(task, now)).To(gomega.Equal(test.expMatch))
 		})
 	}
 }
+
+func TestExtraPriorityIndex(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	task := &TaskInfo{
+		PriorityValue: 1,
+		BioosInfo: &BioosInfo{
+			AccountID:    "account-01",
+			UserID:       "user-01",
+			SubmissionID: "submission-01",
+			RunID:        "run-01",
+		},
+	}
+	extraPriorities := []*ExtraPriorityInfo{
+		{AccountID: "account-01", ExtraPriorityValue: 10},
+		{AccountID: "account-01", UserID: "user-01", ExtraPriorityValue: 100},
+		{AccountID: "account-01", UserID: "user-02", ExtraPriorityValue: 1000},
+		{SubmissionID: "submission-01", ExtraPriorityValue: 10000},
+		{RunID: "run-01", ExtraPriorityValue: 100000},
+		{RunID: "run-02", ExtraPriorityValue: 1000000},
+		// account mismatched, but matched by run
+		{AccountID: "account-02", RunID: "run-01", ExtraPriorityValue: 10000000},
+		nil,
+	}
+	now := time.Now()
+	index := NewExtraPriorityIndex(extraPriorities)
+	g.Expect(index.List()).To(gomega.Equal(extraPriorities))
+
+	// same as matching one by one
+	expValue := task.PriorityValue
+	for _, e := range extraPriorities {
+		if e.MatchTaskAt(task, now) {
+			expValue += e.ExtraPriorityValue
+		}
+	}
+	g.Expect(index.MatchAt(task, now)).To(gomega.HaveLen(5))
+	g.Expect(index.EffectivePriorityAt(task, now)).To(gomega.Equal(expValue))
+	g.Expect(index.EffectivePriorityAt(task, now)).To(gomega.Equal(10110111))
+
+	g.Expect(index.MatchAt(&TaskInfo{}, now)).To(gomega.BeEmpty())
+
+	var nilIndex *ExtraPriorityIndex
+	g.Expect(nilIndex.List()).To(gomega.BeNil())
+	g.Expect(nilIndex.EffectivePriorityAt(task, now)).To(gomega.Equal(1))
+}
+
+func TestMatchTaskAt(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	now := time.Now()
+	task := &TaskInfo{BioosInfo: &BioosInfo{AccountID: "account-01"}}
+
+	tests := []struct {
+		name          string
+		extraPriority *ExtraPriorityInfo
+		expMatch      bool
+	}{
+		{
+			name:          "unbounded",
+			extraPriority: &ExtraPriorityInfo{AccountID: "account-01"},
+			expMatch:      true,
+		},
+		{
+			name:          "in window",
+			extraPriority: &ExtraPriorityInfo{AccountID: "account-01", StartTime: now.Add(-time.Hour), ExpireTime: now.Add(time.Hour)},
+			expMatch:      true,
+		},
+		{
+			name:          "not started",
+			extraPriority: &ExtraPriorityInfo{AccountID: "account-01", StartTime: now.Add(time.Hour)},
+			expMatch:      false,
+		},
+		{
+			name:          "expired",
+			extraPriority: &ExtraPriorityInfo{AccountID: "account-01", ExpireTime: now.Add(-time.Hour)},
+			expMatch:      false,
+		},
+		{
+			name:          "expire at now",
+			extraPriority: &ExtraPriorityInfo{AccountID: "account-01", ExpireTime: now},
+			expMatch:      false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g.Expect(test.extraPriority.MatchTaskAt(task, now)).To(gomega.Equal(test.expMatch))
+		})
+	}
+}
+
+func TestExtraPriorityIndexMatchAt(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	now := time.Now()
+	task := &TaskInfo{PriorityValue: 1, BioosInfo: &BioosInfo{AccountID: "account-01"}}
+	index := NewExtraPriorityIndex([]*ExtraPriorityInfo{
+		{AccountID: "account-01", ExtraPriorityValue: 10, ExpireTime: now.Add(time.Hour)},
+		{AccountID: "account-01", ExtraPriorityValue: 100, StartTime: now.Add(time.Hour)},
+	})
+
+	g.Expect(index.MatchAt(task, now)).To(gomega.HaveLen(1))
+	g.Expect(index.EffectivePriorityAt(task, now)).To(gomega.Equal(11))
+	g.Expect(index.MatchAt(task, now.Add(time.Hour))).To(gomega.HaveLen(1))
+	g.Expect(index.EffectivePriorityAt(task, now.Add(time.Hour))).To(gomega.Equal(101))
+}
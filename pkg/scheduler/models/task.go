@@ -5,12 +5,17 @@ import "time"
 // TaskInfo ...
 type TaskInfo struct {
 	ID            string
+	Name          string
 	State         string
 	ClusterID     string
 	CreationTime  time.Time
 	Resources     *Resources
 	BioosInfo     *BioosInfo
 	PriorityValue int
+	// ExecutorImage is the image of the first executor
+	ExecutorImage string
+	// Deadline is the turnaround deadline of task, zero means no deadline
+	Deadline time.Time
 }
 
 // Resources ...
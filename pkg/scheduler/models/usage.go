@@ -0,0 +1,97 @@
+package models
+
+import "math"
+
+// usageScale is the inverse of the precision float resources are rounded to on
+// Sub, so that errors of float arithmetic do not accumulate as tasks come and go
+const usageScale = 1e6
+
+// Usage is the total resources occupied by scheduled tasks
+type Usage struct {
+	Count    int
+	CPUCores int
+	RamGB    float64 // nolint
+	DiskGB   float64
+	GPUCount float64
+	// GPU is gpuType -> count, GPU without type is only counted in GPUCount
+	GPU map[string]float64
+}
+
+// Add adds a task with resources to usage
+func (u *Usage) Add(resources *Resources) {
+	u.Count++
+	if resources == nil {
+		return
+	}
+	u.CPUCores += resources.CPUCores
+	u.RamGB += resources.RamGB
+	u.DiskGB += resources.DiskGB
+	if resources.GPU == nil {
+		return
+	}
+	u.GPUCount += resources.GPU.Count
+	if resources.GPU.Type != "" {
+		if u.GPU == nil {
+			u.GPU = make(map[string]float64)
+		}
+		u.GPU[resources.GPU.Type] += resources.GPU.Count
+	}
+}
+
+// Sub removes a task with resources from usage. Float resources are rounded and
+// clamped at zero against drift.
+func (u *Usage) Sub(resources *Resources) {
+	u.Count--
+	if resources == nil {
+		return
+	}
+	u.CPUCores -= resources.CPUCores
+	u.RamGB = subFloat(u.RamGB, resources.RamGB)
+	u.DiskGB = subFloat(u.DiskGB, resources.DiskGB)
+	if resources.GPU == nil {
+		return
+	}
+	u.GPUCount = subFloat(u.GPUCount, resources.GPU.Count)
+	if resources.GPU.Type != "" {
+		u.GPU[resources.GPU.Type] = subFloat(u.GPU[resources.GPU.Type], resources.GPU.Count)
+		if u.GPU[resources.GPU.Type] <= 0 {
+			delete(u.GPU, resources.GPU.Type)
+		}
+	}
+}
+
+func subFloat(a, b float64) float64 {
+	return math.Max(math.Round((a-b)*usageScale)/usageScale, 0)
+}
+
+// IsZero returns whether no task is counted
+func (u *Usage) IsZero() bool {
+	return u.Count == 0
+}
+
+// DeepCopy ...
+func (u *Usage) DeepCopy() *Usage {
+	res := *u
+	if u.GPU != nil {
+		res.GPU = make(map[string]float64, len(u.GPU))
+		for gpuType, count := range u.GPU {
+			res.GPU[gpuType] = count
+		}
+	}
+	return &res
+}
+
+// Merge adds other usage to usage
+func (u *Usage) Merge(other *Usage) {
+	u.Count += other.Count
+	u.CPUCores += other.CPUCores
+	u.RamGB += other.RamGB
+	u.DiskGB += other.DiskGB
+	u.GPUCount += other.GPUCount
+	for gpuType, count := range other.GPU {
+		if u.GPU == nil {
+			u.GPU = make(map[string]float64)
+		}
+		u.GPU[gpuType] += count
+	}
+}
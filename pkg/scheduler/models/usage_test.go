@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestUsage(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	usage := &Usage{}
+	usage.Add(nil)
+	usage.Add(&Resources{CPUCores: 2, RamGB: 4, DiskGB: 10, GPU: &GPUResource{Type: "GPU-A", Count: 1}})
+	usage.Add(&Resources{CPUCores: 1, GPU: &GPUResource{Count: 2}})
+	g.Expect(usage).To(gomega.Equal(&Usage{
+		Count:    3,
+		CPUCores: 3,
+		RamGB:    4,
+		DiskGB:   10,
+		GPUCount: 3,
+		GPU:      map[string]float64{"GPU-A": 1},
+	}))
+
+	copied := usage.DeepCopy()
+	usage.Sub(&Resources{CPUCores: 2, RamGB: 4, DiskGB: 10, GPU: &GPUResource{Type: "GPU-A", Count: 1}})
+	g.Expect(usage).To(gomega.Equal(&Usage{
+		Count:    2,
+		CPUCores: 1,
+		GPUCount: 2,
+		GPU:      map[string]float64{},
+	}))
+	g.Expect(copied.GPU).To(gomega.Equal(map[string]float64{"GPU-A": 1}))
+
+	usage.Sub(nil)
+	usage.Sub(&Resources{CPUCores: 1, GPU: &GPUResource{Count: 2}})
+	g.Expect(usage.IsZero()).To(gomega.BeTrue())
+}
+
+func TestUsageSubDrift(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	usage := &Usage{}
+	resources := []*Resources{
+		{RamGB: 0.1, DiskGB: 0.7, GPU: &GPUResource{Type: "GPU-A", Count: 0.1}},
+		{RamGB: 0.2, DiskGB: 0.3, GPU: &GPUResource{Type: "GPU-A", Count: 0.2}},
+		{RamGB: 0.3, DiskGB: 0.1, GPU: &GPUResource{Type: "GPU-A", Count: 0.3}},
+	}
+	for _, r := range resources {
+		usage.Add(r)
+	}
+	usage.Sub(resources[1])
+	usage.Sub(resources[0])
+	g.Expect(usage.RamGB).To(gomega.Equal(0.3))
+	g.Expect(usage.DiskGB).To(gomega.Equal(0.1))
+	g.Expect(usage.GPU).To(gomega.Equal(map[string]float64{"GPU-A": 0.3}))
+	usage.Sub(resources[2])
+	g.Expect(usage).To(gomega.Equal(&Usage{GPU: map[string]float64{}}))
+}
+
+func TestUsageMerge(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	usage := &Usage{}
+	usage.Merge(&Usage{Count: 1, CPUCores: 2, GPUCount: 1, GPU: map[string]float64{"GPU-A": 1}})
+	usage.Merge(&Usage{Count: 2, RamGB: 4, DiskGB: 10, GPUCount: 2, GPU: map[string]float64{"GPU-A": 1, "GPU-B": 1}})
+	g.Expect(usage).To(gomega.Equal(&Usage{
+		Count:    3,
+		CPUCores: 2,
+		RamGB:    4,
+		DiskGB:   10,
+		GPUCount: 3,
+		GPU:      map[string]float64{"GPU-A": 2, "GPU-B": 1},
+	}))
+}
@@ -12,17 +12,30 @@ import (
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/clusterlimit"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/prioritysort"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/resourcequota"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/runner"
 )
 
 // Options ...
 type Options struct {
 	Plugins []string `mapstructure:"plugins"`
+	// PluginConfigs is pluginName -> config of the plugin
+	PluginConfigs map[string]interface{} `mapstructure:"pluginConfigs"`
+	// ScoreWeights is pluginName -> weight of score plugin, default 1
+	ScoreWeights map[string]int `mapstructure:"scoreWeights"`
 
 	SchedulePeriod         time.Duration `mapstructure:"schedulePeriod"`
 	ClusterNotReadyTimeout time.Duration `mapstructure:"clusterNotReadyTimeout"`
+	// DeadlineRiskWindow is how long before deadline an unschedulable task is reported
+	DeadlineRiskWindow time.Duration `mapstructure:"deadlineRiskWindow"`
+
+	TieBreak *TieBreakOptions `mapstructure:"tieBreak"`
+	// PermanentRejections are substrings of messages of 400 responses to assignments,
+	// which mark the task failed. Tasks with other 400 responses are left queued.
+	PermanentRejections []string `mapstructure:"permanentRejections"`
 
 	Cache      *cache.Options      `mapstructure:"cache"`
 	Controller *controller.Options `mapstructure:"controller"`
+	Jobs       *runner.Options     `mapstructure:"jobs"`
 }
 
 // NewOptions ...
@@ -37,9 +50,13 @@ func NewOptions() *Options {
 
 		SchedulePeriod:         time.Second * 10,
 		ClusterNotReadyTimeout: time.Minute * 5,
+		DeadlineRiskWindow:     time.Minute * 30,
+
+		TieBreak: &TieBreakOptions{Strategy: TieBreakRandom},
 
 		Cache:      cache.NewOptions(),
 		Controller: controller.NewOptions(),
+		Jobs:       runner.NewOptions(),
 	}
 }
 
@@ -51,6 +68,17 @@ func (o *Options) Validate() error {
 	if err := o.Controller.Validate(); err != nil {
 		return err
 	}
+	if err := o.Jobs.Validate(); err != nil {
+		return err
+	}
+	for name, weight := range o.ScoreWeights {
+		if weight < 0 {
+			return fmt.Errorf("score weight of plugin %s must not be negative", name)
+		}
+	}
+	if err := o.TieBreak.Validate(); err != nil {
+		return err
+	}
 	if o.Controller.ClusterRescheduleTimeout < o.Cache.SyncPeriod {
 		return fmt.Errorf("controller cluster rescheduling timeout must be greater than cache sync period")
 	}
@@ -66,8 +94,45 @@ func (o *Options) Validate() error {
 // AddFlags ...
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&o.Plugins, "scheduler-plugins", o.Plugins, "comma-separated list of scheduler plugins to enable")
+	fs.StringToIntVar(&o.ScoreWeights, "scheduler-score-weights", o.ScoreWeights, "comma-separated pluginName=weight of score plugins, default weight is 1")
 	fs.DurationVar(&o.SchedulePeriod, "scheduler-schedule-period", o.SchedulePeriod, "scheduler schedule period")
 	fs.DurationVar(&o.ClusterNotReadyTimeout, "scheduler-cluster-not-ready-timeout", o.ClusterNotReadyTimeout, "timeout for cluster not ready")
+	fs.DurationVar(&o.DeadlineRiskWindow, "scheduler-deadline-risk-window", o.DeadlineRiskWindow, "how long before deadline an unschedulable task is reported")
+	fs.StringSliceVar(&o.PermanentRejections, "scheduler-permanent-rejections", o.PermanentRejections, "comma-separated substrings of 400 response messages to assignments, which mark the task failed")
+	o.TieBreak.AddFlags(fs)
 	o.Cache.AddFlags(fs)
 	o.Controller.AddFlags(fs)
+	o.Jobs.AddFlags(fs)
+}
+
+// TieBreakOptions decides how to pick a cluster from clusters with the same max score.
+type TieBreakOptions struct {
+	// Strategy is one of Random, RoundRobin, LeastRecentlyAssigned and Static.
+	Strategy string `mapstructure:"strategy"`
+	// Seed of Random strategy, 0 means seeded by current time.
+	Seed int64 `mapstructure:"seed"`
+	// ClusterOrder is the cluster preference order of Static strategy.
+	ClusterOrder []string `mapstructure:"clusterOrder"`
+}
+
+// Validate ...
+func (o *TieBreakOptions) Validate() error {
+	switch o.Strategy {
+	case TieBreakRandom, TieBreakRoundRobin, TieBreakLeastRecentlyAssigned:
+		return nil
+	case TieBreakStatic:
+		if len(o.ClusterOrder) == 0 {
+			return fmt.Errorf("cluster order must be set for %s tie break strategy", TieBreakStatic)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid tie break strategy: %s", o.Strategy)
+	}
+}
+
+// AddFlags ...
+func (o *TieBreakOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Strategy, "scheduler-tie-break-strategy", o.Strategy, "strategy to pick a cluster from clusters with the same max score, one of Random, RoundRobin, LeastRecentlyAssigned and Static")
+	fs.Int64Var(&o.Seed, "scheduler-tie-break-seed", o.Seed, "seed of Random tie break strategy, 0 means seeded by current time")
+	fs.StringSliceVar(&o.ClusterOrder, "scheduler-tie-break-cluster-order", o.ClusterOrder, "comma-separated cluster preference order of Static tie break strategy")
 }
@@ -2,13 +2,14 @@ package clustercapacity
 
 import (
 	"context"
-	"fmt"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin"
+	"github.com/GBA-BI/tes-scheduler/pkg/utils"
 )
 
 // Name is the plugin name
@@ -37,43 +38,30 @@ func (i *impl) Filter(_ context.Context, task *schemodels.TaskInfo, cluster *sch
 		return nil
 	}
 
-	var totalCount = 0
-	var totalCPUCores = 0
-	var totalRamGB float64 = 0
-	var totalDiskGB float64 = 0
-	var totalGPUCount float64 = 0
-	totalGPU := make(map[string]float64)
-	scheduled := i.cache.TaskCache.ListTasks(cluster.ID)
-	for _, item := range scheduled {
-		totalCount++
-		if item.Resources == nil {
-			continue
-		}
-		totalCPUCores += item.Resources.CPUCores
-		totalRamGB += item.Resources.RamGB
-		totalDiskGB += item.Resources.DiskGB
-		if item.Resources.GPU == nil {
-			continue
-		}
-		totalGPUCount += item.Resources.GPU.Count
-		if item.Resources.GPU.Type != "" {
-			totalGPU[item.Resources.GPU.Type] += item.Resources.GPU.Count
-		}
+	usage := i.cache.Snapshot.GetUsage(cache.UsageKey{ClusterID: cluster.ID})
+	totalCount := usage.Count
+	totalCPUCores := usage.CPUCores
+	totalRamGB := usage.RamGB
+	totalDiskGB := usage.DiskGB
+	totalGPUCount := usage.GPUCount
+	totalGPU := usage.GPU
+	if totalGPU == nil {
+		totalGPU = make(map[string]float64)
 	}
 
 	var errs []error
 	if cluster.Capacity.Count != nil && *cluster.Capacity.Count < totalCount+1 {
-		errs = append(errs, fmt.Errorf("count should no more than %d, occupied %d", *cluster.Capacity.Count, totalCount))
+		errs = append(errs, utils.ReasonErrorf(consts.ReasonCount, "count should no more than %d, occupied %d", *cluster.Capacity.Count, totalCount))
 	}
 	if task.Resources != nil {
 		if cluster.Capacity.CPUCores != nil && task.Resources.CPUCores > 0 && *cluster.Capacity.CPUCores < totalCPUCores+task.Resources.CPUCores {
-			errs = append(errs, fmt.Errorf("CPUCores should no more than %d, occupied %d, claimed %d", *cluster.Capacity.CPUCores, totalCPUCores, task.Resources.CPUCores))
+			errs = append(errs, utils.ReasonErrorf(consts.ReasonCPUCores, "CPUCores should no more than %d, occupied %d, claimed %d", *cluster.Capacity.CPUCores, totalCPUCores, task.Resources.CPUCores))
 		}
 		if cluster.Capacity.RamGB != nil && task.Resources.RamGB > 0 && *cluster.Capacity.RamGB < totalRamGB+task.Resources.RamGB {
-			errs = append(errs, fmt.Errorf("RamGB should no more than %.2f, occupied %.2f, claimed %.2f", *cluster.Capacity.RamGB, totalRamGB, task.Resources.RamGB))
+			errs = append(errs, utils.ReasonErrorf(consts.ReasonRamGB, "RamGB should no more than %.2f, occupied %.2f, claimed %.2f", *cluster.Capacity.RamGB, totalRamGB, task.Resources.RamGB))
 		}
 		if cluster.Capacity.DiskGB != nil && task.Resources.DiskGB > 0 && *cluster.Capacity.DiskGB < totalDiskGB+task.Resources.DiskGB {
-			errs = append(errs, fmt.Errorf("DiskGB should no more than %.2f, occupied %.2f, claimed %.2f", *cluster.Capacity.DiskGB, totalDiskGB, task.Resources.DiskGB))
+			errs = append(errs, utils.ReasonErrorf(consts.ReasonDiskGB, "DiskGB should no more than %.2f, occupied %.2f, claimed %.2f", *cluster.Capacity.DiskGB, totalDiskGB, task.Resources.DiskGB))
 		}
 		if cluster.Capacity.GPUCapacity != nil && task.Resources.GPU != nil {
 			// no matter task with gpuType or not, we must check total gpu count, because maybe there are
@@ -83,15 +71,15 @@ func (i *impl) Filter(_ context.Context, task *schemodels.TaskInfo, cluster *sch
 				sumGPUCountCapacity += gpuCount
 			}
 			if sumGPUCountCapacity < totalGPUCount+task.Resources.GPU.Count {
-				errs = append(errs, fmt.Errorf("GPUCount should no more than %.2f, occupied %.2f, claimed %.2f", sumGPUCountCapacity, totalGPUCount, task.Resources.GPU.Count))
+				errs = append(errs, utils.ReasonErrorf(consts.ReasonGPUCount, "GPUCount should no more than %.2f, occupied %.2f, claimed %.2f", sumGPUCountCapacity, totalGPUCount, task.Resources.GPU.Count))
 			}
 			if task.Resources.GPU.Type != "" {
 				gpuType := task.Resources.GPU.Type
 				gpuCountCapacity, ok := cluster.Capacity.GPUCapacity.GPU[gpuType]
 				if !ok {
-					errs = append(errs, fmt.Errorf("no match GPUType: %s", gpuType))
+					errs = append(errs, utils.ReasonErrorf(consts.ReasonGPUType, "no match GPUType: %s", gpuType))
 				} else if gpuCountCapacity < totalGPU[gpuType]+task.Resources.GPU.Count {
-					errs = append(errs, fmt.Errorf("GPUCount should no more than %.2f, occupied %.2f, claimed %.2f", gpuCountCapacity, totalGPU[gpuType], task.Resources.GPU.Count))
+					errs = append(errs, utils.ReasonErrorf(consts.ReasonGPUCount, "GPUCount should no more than %.2f, occupied %.2f, claimed %.2f", gpuCountCapacity, totalGPU[gpuType], task.Resources.GPU.Count))
 				}
 			}
 		}
@@ -3,12 +3,12 @@ package clustercapacity
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/onsi/gomega"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
-	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache/fake"
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin"
 	"github.com/GBA-BI/tes-scheduler/pkg/utils"
@@ -496,9 +496,10 @@ func TestFilter(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			fakeTaskCache := fake.NewFakeTaskCache(ctrl)
-			fakeTaskCache.EXPECT().ListTasks(test.cluster.ID).Return(test.scheduled).AnyTimes() // 0 or 1 time
-			i := &impl{cache: &cache.Cache{TaskCache: fakeTaskCache}}
+			for _, scheduled := range test.scheduled {
+				scheduled.ClusterID = test.cluster.ID
+			}
+			i := &impl{cache: &cache.Cache{Snapshot: cache.NewSnapshot(time.Now(), test.scheduled, nil, nil)}}
 			cycleState := make(map[string]interface{})
 			g.Expect(i.Filter(context.Background(), test.task, test.cluster, cycleState) != nil).To(gomega.Equal(test.expErr))
 			g.Expect(cycleState).To(gomega.BeEquivalentTo(test.expCycleState))
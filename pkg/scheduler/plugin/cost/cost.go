@@ -0,0 +1,172 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin"
+)
+
+// Name is the plugin name
+const Name = "Cost"
+
+// Config of Cost plugin. Lists are used instead of maps keyed by clusterID
+// and GPUType, because viper lowercases map keys.
+type Config struct {
+	// ClusterPrices overrides Price of cluster
+	ClusterPrices []*ClusterPrice `mapstructure:"clusterPrices"`
+}
+
+// ClusterPrice is the price of resources per hour of a cluster
+type ClusterPrice struct {
+	ClusterID   string      `mapstructure:"clusterID"`
+	CPUCoreHour float64     `mapstructure:"cpuCoreHour"`
+	RamGBHour   float64     `mapstructure:"ramGBHour"` // nolint
+	DiskGBHour  float64     `mapstructure:"diskGBHour"`
+	GPUHour     []*GPUPrice `mapstructure:"gpuHour"`
+}
+
+// GPUPrice is the price per GPU hour of a GPUType
+type GPUPrice struct {
+	Type  string  `mapstructure:"type"`
+	Price float64 `mapstructure:"price"`
+}
+
+type impl struct {
+	cache *cache.Cache
+	// prices is clusterID -> price from config
+	prices map[string]*schemodels.Price
+}
+
+var _ plugin.ScorePlugin = (*impl)(nil)
+
+// New ...
+func New(pluginConfig interface{}, cache *cache.Cache) (plugin.Plugin, error) {
+	config := &Config{}
+	if err := mapstructure.Decode(pluginConfig, config); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	prices := make(map[string]*schemodels.Price, len(config.ClusterPrices))
+	for _, clusterPrice := range config.ClusterPrices {
+		if clusterPrice == nil || clusterPrice.ClusterID == "" {
+			return nil, fmt.Errorf("invalid config: clusterID of clusterPrices cannot be empty")
+		}
+		price := &schemodels.Price{
+			CPUCoreHour: clusterPrice.CPUCoreHour,
+			RamGBHour:   clusterPrice.RamGBHour,
+			DiskGBHour:  clusterPrice.DiskGBHour,
+			GPUHour:     make(map[string]float64, len(clusterPrice.GPUHour)),
+		}
+		for _, gpuPrice := range clusterPrice.GPUHour {
+			price.GPUHour[gpuPrice.Type] = gpuPrice.Price
+		}
+		prices[clusterPrice.ClusterID] = price
+	}
+	return &impl{cache: cache, prices: prices}, nil
+}
+
+// Name ...
+func (i *impl) Name() string {
+	return Name
+}
+
+// Score prefers the cheapest cluster. The cost of task on each cluster is
+// normalized between the cheapest and the most expensive priced clusters.
+// Cluster without price gets a middle score.
+func (i *impl) Score(_ context.Context, task *schemodels.TaskInfo, cluster *schemodels.ClusterInfo, cycleState map[string]interface{}) int64 {
+	cost, ok := i.estimateCost(task, cluster)
+	if !ok {
+		return (plugin.MaxScore + plugin.MinScore) / 2
+	}
+
+	costRange, ok := cycleState[costRangeKey].([2]float64)
+	if !ok {
+		costRange = i.getCostRange(task, cycleState)
+		cycleState[costRangeKey] = costRange
+	}
+	minCost, maxCost := costRange[0], costRange[1]
+	if maxCost <= minCost || cost <= minCost {
+		return plugin.MaxScore
+	}
+	if cost >= maxCost {
+		return plugin.MinScore
+	}
+	return plugin.MaxScore - int64((cost-minCost)/(maxCost-minCost)*float64(plugin.MaxScore-plugin.MinScore))
+}
+
+// getCostRange returns the min and max cost of task on priced candidate clusters,
+// or on all clusters if candidates are not set.
+func (i *impl) getCostRange(task *schemodels.TaskInfo, cycleState map[string]interface{}) [2]float64 {
+	clusters, ok := cycleState[plugin.CandidateClustersKey].([]*schemodels.ClusterInfo)
+	if !ok {
+		clusters = i.cache.Snapshot.ListClusters()
+	}
+	var res [2]float64
+	found := false
+	for _, cluster := range clusters {
+		cost, ok := i.estimateCost(task, cluster)
+		if !ok {
+			continue
+		}
+		if !found || cost < res[0] {
+			res[0] = cost
+		}
+		if !found || cost > res[1] {
+			res[1] = cost
+		}
+		found = true
+	}
+	return res
+}
+
+// estimateCost returns the cost per hour of task on cluster, false if the cluster is not priced.
+func (i *impl) estimateCost(task *schemodels.TaskInfo, cluster *schemodels.ClusterInfo) (float64, bool) {
+	price := i.getPrice(cluster)
+	if price == nil {
+		return 0, false
+	}
+	if task.Resources == nil {
+		return 0, true
+	}
+
+	cost := float64(task.Resources.CPUCores)*price.CPUCoreHour +
+		task.Resources.RamGB*price.RamGBHour +
+		task.Resources.DiskGB*price.DiskGBHour
+	if task.Resources.GPU == nil || task.Resources.GPU.Count == 0 {
+		return cost, true
+	}
+
+	if task.Resources.GPU.Type != "" {
+		gpuPrice, ok := price.GPUHour[task.Resources.GPU.Type]
+		if !ok {
+			return 0, false
+		}
+		return cost + task.Resources.GPU.Count*gpuPrice, true
+	}
+	// task without GPUType may use any GPUType, use the cheapest one
+	found := false
+	var minGPUPrice float64
+	for _, gpuPrice := range price.GPUHour {
+		if !found || gpuPrice < minGPUPrice {
+			minGPUPrice = gpuPrice
+		}
+		found = true
+	}
+	if !found {
+		return 0, false
+	}
+	return cost + task.Resources.GPU.Count*minGPUPrice, true
+}
+
+func (i *impl) getPrice(cluster *schemodels.ClusterInfo) *schemodels.Price {
+	if price, ok := i.prices[cluster.ID]; ok {
+		return price
+	}
+	return cluster.Price
+}
+
+const costRangeKey = "costRange"
@@ -0,0 +1,142 @@
+package cost
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin"
+)
+
+func TestNew(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	p, err := New(map[string]interface{}{
+		"clusterPrices": []interface{}{
+			map[string]interface{}{
+				"clusterID":   "cluster-01",
+				"cpuCoreHour": 0.5,
+				"ramGBHour":   0.1,
+				"gpuHour": []interface{}{
+					map[string]interface{}{"type": "GPU-A", "price": 3},
+				},
+			},
+		},
+	}, &cache.Cache{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(p.(*impl).prices).To(gomega.Equal(map[string]*schemodels.Price{
+		"cluster-01": {
+			CPUCoreHour: 0.5,
+			RamGBHour:   0.1,
+			GPUHour:     map[string]float64{"GPU-A": 3},
+		},
+	}))
+
+	_, err = New(nil, &cache.Cache{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = New(map[string]interface{}{
+		"clusterPrices": []interface{}{map[string]interface{}{"cpuCoreHour": 0.5}},
+	}, &cache.Cache{})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestScore(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	cheap := &schemodels.ClusterInfo{
+		ID:    "cluster-cheap",
+		Price: &schemodels.Price{CPUCoreHour: 1, RamGBHour: 0.1, GPUHour: map[string]float64{"GPU-A": 2, "GPU-B": 4}},
+	}
+	middle := &schemodels.ClusterInfo{
+		ID:    "cluster-middle",
+		Price: &schemodels.Price{CPUCoreHour: 100, RamGBHour: 100}, // overridden by config
+	}
+	expensive := &schemodels.ClusterInfo{
+		ID:    "cluster-expensive",
+		Price: &schemodels.Price{CPUCoreHour: 3, RamGBHour: 0.3, GPUHour: map[string]float64{"GPU-A": 6}},
+	}
+	unpriced := &schemodels.ClusterInfo{ID: "cluster-unpriced"}
+	clusters := []*schemodels.ClusterInfo{cheap, middle, expensive, unpriced}
+
+	tests := []struct {
+		name     string
+		task     *schemodels.TaskInfo
+		expScore map[string]int64
+	}{
+		{
+			name: "cpu and ram",
+			task: &schemodels.TaskInfo{Resources: &schemodels.Resources{CPUCores: 2, RamGB: 10}},
+			expScore: map[string]int64{
+				cheap.ID:     plugin.MaxScore,
+				middle.ID:    50,
+				expensive.ID: plugin.MinScore,
+				unpriced.ID:  50,
+			},
+		},
+		{
+			name: "no resources",
+			task: &schemodels.TaskInfo{},
+			expScore: map[string]int64{
+				cheap.ID:     plugin.MaxScore,
+				middle.ID:    plugin.MaxScore,
+				expensive.ID: plugin.MaxScore,
+				unpriced.ID:  50,
+			},
+		},
+		{
+			name: "gpu with type",
+			task: &schemodels.TaskInfo{Resources: &schemodels.Resources{CPUCores: 2, RamGB: 10, GPU: &schemodels.GPUResource{Type: "GPU-B", Count: 1}}},
+			expScore: map[string]int64{
+				cheap.ID:     plugin.MaxScore,
+				middle.ID:    50,
+				expensive.ID: 50,
+				unpriced.ID:  50,
+			},
+		},
+		{
+			name: "gpu without type, use cheapest type",
+			task: &schemodels.TaskInfo{Resources: &schemodels.Resources{CPUCores: 2, RamGB: 10, GPU: &schemodels.GPUResource{Count: 1}}},
+			expScore: map[string]int64{
+				cheap.ID:     plugin.MaxScore,
+				middle.ID:    50,
+				expensive.ID: plugin.MinScore,
+				unpriced.ID:  50,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			i := &impl{
+				cache: &cache.Cache{Snapshot: cache.NewSnapshot(time.Now(), nil, clusters, nil)},
+				prices: map[string]*schemodels.Price{
+					middle.ID: {CPUCoreHour: 2, RamGBHour: 0.2, GPUHour: map[string]float64{"GPU-A": 4}},
+				},
+			}
+			cycleState := make(map[string]interface{})
+			for _, cluster := range clusters {
+				g.Expect(i.Score(context.Background(), test.task, cluster, cycleState)).To(gomega.Equal(test.expScore[cluster.ID]), cluster.ID)
+			}
+		})
+	}
+}
+
+func TestScoreCandidateClusters(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	cheap := &schemodels.ClusterInfo{ID: "cluster-cheap", Price: &schemodels.Price{CPUCoreHour: 1}}
+	middle := &schemodels.ClusterInfo{ID: "cluster-middle", Price: &schemodels.Price{CPUCoreHour: 2}}
+	expensive := &schemodels.ClusterInfo{ID: "cluster-expensive", Price: &schemodels.Price{CPUCoreHour: 100}}
+	i := &impl{cache: &cache.Cache{Snapshot: cache.NewSnapshot(time.Now(), nil, []*schemodels.ClusterInfo{cheap, middle, expensive}, nil)}}
+	task := &schemodels.TaskInfo{Resources: &schemodels.Resources{CPUCores: 2}}
+
+	// the filtered out expensive cluster does not squeeze scores of candidates
+	cycleState := map[string]interface{}{plugin.CandidateClustersKey: []*schemodels.ClusterInfo{cheap, middle}}
+	g.Expect(i.Score(context.Background(), task, cheap, cycleState)).To(gomega.Equal(plugin.MaxScore))
+	g.Expect(i.Score(context.Background(), task, middle, cycleState)).To(gomega.Equal(plugin.MinScore))
+}
@@ -0,0 +1,80 @@
+package deadlinesort
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin"
+)
+
+// Name is the plugin name
+const Name = "DeadlineSort"
+
+const defaultPriorityBandWidth = 100
+
+// Config of DeadlineSort plugin
+type Config struct {
+	// PriorityBandWidth is the width of priority band. Tasks whose effective
+	// priority fall into the same band are ordered by earliest deadline first.
+	PriorityBandWidth int `mapstructure:"priorityBandWidth"`
+}
+
+type impl struct {
+	cache             *cache.Cache
+	priorityBandWidth int
+}
+
+var _ plugin.SortPlugin = (*impl)(nil)
+
+// New ...
+func New(pluginConfig interface{}, cache *cache.Cache) (plugin.Plugin, error) {
+	config := &Config{PriorityBandWidth: defaultPriorityBandWidth}
+	if err := mapstructure.Decode(pluginConfig, config); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if config.PriorityBandWidth <= 0 {
+		return nil, fmt.Errorf("invalid config: priorityBandWidth must be positive")
+	}
+	return &impl{cache: cache, priorityBandWidth: config.PriorityBandWidth}, nil
+}
+
+// Name ...
+func (i *impl) Name() string {
+	return Name
+}
+
+// Less orders tasks by priority band first. In the same band, tasks with
+// deadline come before tasks without, and earlier deadline comes first.
+// Others are ordered the same as PrioritySort.
+func (i *impl) Less(taskI *schemodels.TaskInfo, taskJ *schemodels.TaskInfo) bool {
+	valueI := i.cache.Snapshot.EffectivePriority(taskI)
+	valueJ := i.cache.Snapshot.EffectivePriority(taskJ)
+
+	bandI, bandJ := i.band(valueI), i.band(valueJ)
+	if bandI != bandJ {
+		return bandI > bandJ
+	}
+	hasDeadlineI, hasDeadlineJ := !taskI.Deadline.IsZero(), !taskJ.Deadline.IsZero()
+	if hasDeadlineI != hasDeadlineJ {
+		return hasDeadlineI
+	}
+	if hasDeadlineI && !taskI.Deadline.Equal(taskJ.Deadline) {
+		return taskI.Deadline.Before(taskJ.Deadline)
+	}
+	if valueI == valueJ {
+		return taskI.CreationTime.Before(taskJ.CreationTime)
+	}
+	return valueI > valueJ
+}
+
+// band is value floor divided by priorityBandWidth
+func (i *impl) band(value int) int {
+	band := value / i.priorityBandWidth
+	if value%i.priorityBandWidth != 0 && value < 0 {
+		band--
+	}
+	return band
+}
@@ -0,0 +1,101 @@
+package deadlinesort
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+)
+
+func TestNew(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	p, err := New(nil, &cache.Cache{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(p.(*impl).priorityBandWidth).To(gomega.Equal(defaultPriorityBandWidth))
+
+	p, err = New(map[string]interface{}{"priorityBandWidth": 10}, &cache.Cache{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(p.(*impl).priorityBandWidth).To(gomega.Equal(10))
+
+	_, err = New(map[string]interface{}{"priorityBandWidth": 0}, &cache.Cache{})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestLess(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	now := time.Now()
+
+	tests := []struct {
+		name            string
+		taskI           *schemodels.TaskInfo
+		taskJ           *schemodels.TaskInfo
+		extraPriorities []*schemodels.ExtraPriorityInfo
+		expLess         bool
+	}{
+		{
+			name:    "different band, deadline ignored",
+			taskI:   &schemodels.TaskInfo{PriorityValue: 100},
+			taskJ:   &schemodels.TaskInfo{PriorityValue: 99, Deadline: now},
+			expLess: true,
+		},
+		{
+			name:    "negative band",
+			taskI:   &schemodels.TaskInfo{PriorityValue: 0},
+			taskJ:   &schemodels.TaskInfo{PriorityValue: -1, Deadline: now},
+			expLess: true,
+		},
+		{
+			name:    "same band, deadline first",
+			taskI:   &schemodels.TaskInfo{PriorityValue: 150},
+			taskJ:   &schemodels.TaskInfo{PriorityValue: 100, Deadline: now.Add(time.Hour)},
+			expLess: false,
+		},
+		{
+			name:    "same band, earlier deadline first",
+			taskI:   &schemodels.TaskInfo{PriorityValue: 100, Deadline: now},
+			taskJ:   &schemodels.TaskInfo{PriorityValue: 150, Deadline: now.Add(time.Hour)},
+			expLess: true,
+		},
+		{
+			name: "same band by extra priority, deadline first",
+			taskI: &schemodels.TaskInfo{
+				PriorityValue: 0,
+				BioosInfo:     &schemodels.BioosInfo{AccountID: "account-01"},
+				Deadline:      now,
+			},
+			taskJ: &schemodels.TaskInfo{PriorityValue: 150},
+			extraPriorities: []*schemodels.ExtraPriorityInfo{{
+				AccountID:          "account-01",
+				ExtraPriorityValue: 100,
+			}},
+			expLess: true,
+		},
+		{
+			name:    "same band, no deadline, compare priorityValue",
+			taskI:   &schemodels.TaskInfo{PriorityValue: 110},
+			taskJ:   &schemodels.TaskInfo{PriorityValue: 120},
+			expLess: false,
+		},
+		{
+			name:    "same deadline and value, compare CreationTime",
+			taskI:   &schemodels.TaskInfo{PriorityValue: 110, Deadline: now, CreationTime: now},
+			taskJ:   &schemodels.TaskInfo{PriorityValue: 110, Deadline: now, CreationTime: now.Add(-time.Second)},
+			expLess: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			i := &impl{
+				cache:             &cache.Cache{Snapshot: cache.NewSnapshot(time.Now(), nil, nil, test.extraPriorities)},
+				priorityBandWidth: defaultPriorityBandWidth,
+			}
+			g.Expect(i.Less(test.taskI, test.taskJ)).To(gomega.Equal(test.expLess))
+		})
+	}
+}
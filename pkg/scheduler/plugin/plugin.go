@@ -46,3 +46,7 @@ const (
 	MaxScore int64 = 100
 	MinScore int64 = 0
 )
+
+// CandidateClustersKey is the cycleState key of the clusters passing all filters,
+// set by the scheduler before scoring.
+const CandidateClustersKey = "CandidateClusters"
@@ -27,17 +27,8 @@ func (i *impl) Name() string {
 
 // Less ...
 func (i *impl) Less(taskI *schemodels.TaskInfo, taskJ *schemodels.TaskInfo) bool {
-	extraPriorities := i.cache.ExtraPriorityCache.ListExtraPriorities()
-	valueI := taskI.PriorityValue
-	valueJ := taskJ.PriorityValue
-	for _, ep := range extraPriorities {
-		if ep.MatchTask(taskI) {
-			valueI += ep.ExtraPriorityValue
-		}
-		if ep.MatchTask(taskJ) {
-			valueJ += ep.ExtraPriorityValue
-		}
-	}
+	valueI := i.cache.Snapshot.EffectivePriority(taskI)
+	valueJ := i.cache.Snapshot.EffectivePriority(taskJ)
 	if valueI == valueJ {
 		return taskI.CreationTime.Before(taskJ.CreationTime)
 	}
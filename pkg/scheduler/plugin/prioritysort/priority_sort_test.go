@@ -4,18 +4,14 @@ import (
 	"testing"
 	"time"
 
-	"github.com/golang/mock/gomock"
 	"github.com/onsi/gomega"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
-	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache/fake"
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 )
 
 func TestLess(t *testing.T) {
 	g := gomega.NewWithT(t)
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
 
 	tests := []struct {
 		name            string
@@ -94,9 +90,7 @@ func TestLess(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			fakeExtraPriorityCache := fake.NewFakeExtraPriorityCache(ctrl)
-			fakeExtraPriorityCache.EXPECT().ListExtraPriorities().Return(test.extraPriorities)
-			i := &impl{cache: &cache.Cache{ExtraPriorityCache: fakeExtraPriorityCache}}
+			i := &impl{cache: &cache.Cache{Snapshot: cache.NewSnapshot(time.Now(), nil, nil, test.extraPriorities)}}
 			g.Expect(i.Less(test.taskI, test.taskJ)).To(gomega.Equal(test.expLess))
 		})
 	}
@@ -3,6 +3,7 @@ package resourcequota
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/onsi/gomega"
@@ -94,9 +95,10 @@ func TestGlobalFilter(t *testing.T) {
 			fakeQuotaCache.EXPECT().GetGlobalQuota(gomock.Any()).Return(test.globalResourceQuota, nil).AnyTimes()
 			fakeQuotaCache.EXPECT().GetAccountQuota(gomock.Any(), gomock.Any()).Return(test.accountResourceQuota, nil).AnyTimes()
 			fakeQuotaCache.EXPECT().GetUserQuota(gomock.Any(), gomock.Any(), gomock.Any()).Return(test.userResourceQuota, nil).AnyTimes()
-			fakeTaskCache := fake.NewFakeTaskCache(ctrl)
-			fakeTaskCache.EXPECT().ListScheduledTasks().Return(test.scheduledTasks)
-			i := &impl{cache: &cache.Cache{QuotaCache: fakeQuotaCache, TaskCache: fakeTaskCache}}
+			for _, scheduledTask := range test.scheduledTasks {
+				scheduledTask.ClusterID = "cluster-01"
+			}
+			i := &impl{cache: &cache.Cache{QuotaCache: fakeQuotaCache, Snapshot: cache.NewSnapshot(time.Now(), test.scheduledTasks, nil, nil)}}
 			err := i.GlobalFilter(context.Background(), test.task, make(map[string]interface{}))
 			g.Expect(err != nil).To(gomega.Equal(test.expErr))
 		})
@@ -464,9 +466,11 @@ func TestCheckQuota(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			err := checkQuota(test.quota, test.task, test.scheduled, func(scheduledTask *schemodels.TaskInfo) bool {
-				return true
-			})
+			usage := &schemodels.Usage{}
+			for _, scheduled := range test.scheduled {
+				usage.Add(scheduled.Resources)
+			}
+			err := checkQuota(test.quota, test.task, usage)
 			g.Expect(err != nil).To(gomega.Equal(test.expErr))
 		})
 	}
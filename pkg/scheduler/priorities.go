@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+)
+
+// prioritiesDebugName is served under the debug path of server
+const prioritiesDebugName = "priorities"
+
+// queuePriorities records the sorted queue of last schedule cycle for debugging
+type queuePriorities struct {
+	mutex sync.RWMutex
+	time  time.Time
+	tasks []*schemodels.TaskInfo
+	// priorities is effective priority of tasks in the same order
+	priorities []int
+	// snapshot is only used for matching extraPriorities, which is immutable
+	snapshot *cache.Snapshot
+}
+
+type queuePrioritiesResponse struct {
+	Time  time.Time               `json:"time"`
+	Tasks []*taskPriorityResponse `json:"tasks"`
+}
+
+type taskPriorityResponse struct {
+	TaskID            string                          `json:"task_id"`
+	PriorityValue     int                             `json:"priority_value"`
+	EffectivePriority int                             `json:"effective_priority"`
+	ExtraPriorities   []*schemodels.ExtraPriorityInfo `json:"extra_priorities,omitempty"`
+}
+
+// record must be called with tasks sorted, before snapshot is updated by scheduling
+func (q *queuePriorities) record(snapshot *cache.Snapshot, tasks []*schemodels.TaskInfo) {
+	priorities := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		priorities = append(priorities, snapshot.EffectivePriority(task))
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.time = snapshot.Now()
+	q.tasks = tasks
+	q.priorities = priorities
+	q.snapshot = snapshot
+}
+
+// ServeHTTP returns the queued tasks with effective priorities in scheduling order
+func (q *queuePriorities) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	q.mutex.RLock()
+	resp := &queuePrioritiesResponse{Time: q.time, Tasks: make([]*taskPriorityResponse, 0, len(q.tasks))}
+	for index, task := range q.tasks {
+		resp.Tasks = append(resp.Tasks, &taskPriorityResponse{
+			TaskID:            task.ID,
+			PriorityValue:     task.PriorityValue,
+			EffectivePriority: q.priorities[index],
+			ExtraPriorities:   q.snapshot.MatchExtraPriorities(task),
+		})
+	}
+	q.mutex.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -1,9 +1,13 @@
 package scheduler
 
 import (
+	"strings"
+
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/clustercapacity"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/clusterlimit"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/cost"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/deadlinesort"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/prioritysort"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/resourcequota"
 )
@@ -13,10 +17,37 @@ var registry = map[string]plugin.Factory{
 	resourcequota.Name:   resourcequota.New,
 	clusterlimit.Name:    clusterlimit.New,
 	clustercapacity.Name: clustercapacity.New,
+	cost.Name:            cost.New,
+	deadlinesort.Name:    deadlinesort.New,
 }
 
 // extractPluginConfig extract config of different plugin.
-// todo: I don't know how to use viper to set config of different plugin
-func extractPluginConfig(_ *Options, _ string) interface{} {
+// viper lowercases map keys, so pluginName is matched case-insensitively.
+func extractPluginConfig(opts *Options, pluginName string) interface{} {
+	config := lookupByPluginName(opts.PluginConfigs, pluginName)
+	if config == nil {
+		return nil
+	}
+	return *config
+}
+
+// extractScoreWeight extract weight of score plugin, default 1.
+func extractScoreWeight(opts *Options, pluginName string) int64 {
+	weight := lookupByPluginName(opts.ScoreWeights, pluginName)
+	if weight == nil {
+		return 1
+	}
+	return int64(*weight)
+}
+
+func lookupByPluginName[T any](m map[string]T, pluginName string) *T {
+	if value, ok := m[pluginName]; ok {
+		return &value
+	}
+	for name, value := range m {
+		if strings.EqualFold(name, pluginName) {
+			return &value
+		}
+	}
 	return nil
 }
@@ -0,0 +1,47 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Options ...
+type Options struct {
+	// Jitter delays each run by a random duration up to Jitter times the period
+	Jitter float64 `mapstructure:"jitter"`
+	// StuckTimeout is how long a run lasts before the runner is unhealthy, 0 means unlimited
+	StuckTimeout time.Duration `mapstructure:"stuckTimeout"`
+	// FailureThreshold is how many consecutive failures of a job make the runner unhealthy, 0 means unlimited
+	FailureThreshold int `mapstructure:"failureThreshold"`
+}
+
+// NewOptions ...
+func NewOptions() *Options {
+	return &Options{
+		Jitter:       0.1,
+		StuckTimeout: time.Minute * 5,
+	}
+}
+
+// Validate ...
+func (o *Options) Validate() error {
+	if o.Jitter < 0 || o.Jitter > 1 {
+		return fmt.Errorf("jobs jitter must be between 0 and 1")
+	}
+	if o.StuckTimeout < 0 {
+		return fmt.Errorf("jobs stuck timeout cannot be negative")
+	}
+	if o.FailureThreshold < 0 {
+		return fmt.Errorf("jobs failure threshold cannot be negative")
+	}
+	return nil
+}
+
+// AddFlags ...
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.Float64Var(&o.Jitter, "scheduler-jobs-jitter", o.Jitter, "max random delay of each run of periodic jobs, as a fraction of the period")
+	fs.DurationVar(&o.StuckTimeout, "scheduler-jobs-stuck-timeout", o.StuckTimeout, "how long a run of periodic jobs lasts before health check fails, 0 means unlimited")
+	fs.IntVar(&o.FailureThreshold, "scheduler-jobs-failure-threshold", o.FailureThreshold, "how many consecutive failures of a periodic job fail health check, 0 means unlimited")
+}
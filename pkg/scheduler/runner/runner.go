@@ -0,0 +1,293 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/log"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/clock"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/metrics"
+)
+
+const (
+	resultSuccess = "success"
+	resultError   = "error"
+	resultPanic   = "panic"
+	resultSkipped = "skipped"
+)
+
+// Registerer registers periodic jobs
+type Registerer interface {
+	// Register adds a job run every period. Errors returned by fn are logged and
+	// counted as failures of the job. Names must be unique.
+	Register(name string, period time.Duration, fn func() error) error
+}
+
+// Runner runs registered jobs periodically. A run of a job is skipped if the
+// last run of the same job is not finished.
+type Runner struct {
+	clock            clock.WithTicker
+	jitter           float64
+	stuckTimeout     time.Duration
+	failureThreshold int
+
+	mutex sync.Mutex
+	// jobs are in order of registration
+	jobs   []*job
+	cancel context.CancelFunc
+	// wg waits for loops and runs of jobs
+	wg sync.WaitGroup
+}
+
+type job struct {
+	name   string
+	period time.Duration
+	fn     func() error
+
+	running atomic.Bool
+	// next is the time to run next in RunDue, zero means at once
+	next time.Time
+
+	mutex  sync.RWMutex
+	status Status
+}
+
+// Status is the run status of a job
+type Status struct {
+	LastRunTime         time.Time
+	LastRunDuration     time.Duration
+	LastError           error
+	ConsecutiveFailures int
+	// RunningSince is the start time of the running run, zero if not running
+	RunningSince time.Time
+}
+
+var _ Registerer = (*Runner)(nil)
+
+// New ...
+func New(opts *Options, clk clock.WithTicker) *Runner {
+	return &Runner{
+		clock:            clk,
+		jitter:           opts.Jitter,
+		stuckTimeout:     opts.StuckTimeout,
+		failureThreshold: opts.FailureThreshold,
+	}
+}
+
+// Register must be called before Start
+func (r *Runner) Register(name string, period time.Duration, fn func() error) error {
+	if period <= 0 {
+		return fmt.Errorf("period of job %s must be positive", name)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, j := range r.jobs {
+		if j.name == name {
+			return fmt.Errorf("job %s already registered", name)
+		}
+	}
+	r.jobs = append(r.jobs, &job{name: name, period: period, fn: fn})
+	return nil
+}
+
+// Start runs each job every period plus jitter, until Stop. It can be started
+// again after Stop.
+func (r *Runner) Start() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go r.loop(ctx, j)
+	}
+}
+
+// Stop stops running jobs, the returned context is done when all running runs
+// are finished.
+func (r *Runner) Stop() context.Context {
+	r.mutex.Lock()
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+	r.mutex.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		r.wg.Wait()
+		cancel()
+	}()
+	return ctx
+}
+
+func (r *Runner) loop(ctx context.Context, j *job) {
+	defer r.wg.Done()
+	for {
+		timer := r.clock.NewTimer(r.delay(j.period))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+		if !j.running.CompareAndSwap(false, true) {
+			metrics.JobRuns.WithLabelValues(j.name, resultSkipped).Inc()
+			log.Warnw("last run of job not finished, skip", "job", j.name)
+			continue
+		}
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			defer j.running.Store(false)
+			r.run(j)
+		}()
+	}
+}
+
+func (r *Runner) delay(period time.Duration) time.Duration {
+	if r.jitter <= 0 {
+		return period
+	}
+	return period + time.Duration(rand.Float64()*r.jitter*float64(period)) // nolint
+}
+
+// RunDue synchronously runs jobs due at the current time of the clock in order of
+// registration without jitter, and returns the earliest time a job is due next.
+// It drives jobs by a fake clock instead of Start, and must not be mixed with Start.
+func (r *Runner) RunDue() time.Time {
+	r.mutex.Lock()
+	jobs := r.jobs
+	r.mutex.Unlock()
+
+	now := r.clock.Now()
+	var next time.Time
+	for _, j := range jobs {
+		if !j.next.After(now) && j.running.CompareAndSwap(false, true) {
+			r.run(j)
+			j.running.Store(false)
+			j.next = now.Add(j.period)
+		}
+		if next.IsZero() || j.next.Before(next) {
+			next = j.next
+		}
+	}
+	return next
+}
+
+func (r *Runner) run(j *job) {
+	start := r.clock.Now()
+	j.mutex.Lock()
+	j.status.RunningSince = start
+	j.mutex.Unlock()
+
+	panicked, err := call(j.fn)
+	duration := r.clock.Since(start)
+
+	result := resultSuccess
+	switch {
+	case panicked:
+		result = resultPanic
+	case err != nil:
+		result = resultError
+		log.Errorw("job failed", "job", j.name, "err", err)
+	}
+	metrics.JobRuns.WithLabelValues(j.name, result).Inc()
+	metrics.JobLastRunTimestamp.WithLabelValues(j.name).Set(float64(start.Add(duration).Unix()))
+	metrics.JobLastRunDuration.WithLabelValues(j.name).Set(duration.Seconds())
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.status.RunningSince = time.Time{}
+	j.status.LastRunTime = start
+	j.status.LastRunDuration = duration
+	j.status.LastError = err
+	if err != nil {
+		j.status.ConsecutiveFailures++
+	} else {
+		j.status.ConsecutiveFailures = 0
+	}
+}
+
+// call recovers panic of fn as an error
+func call(fn func() error) (panicked bool, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			panicked = true
+			err = fmt.Errorf("panic: %v", p)
+			log.Errorw("job panicked", "panic", p, "stack", string(debug.Stack()))
+		}
+	}()
+	return false, fn()
+}
+
+// Status returns the run status of a job, false if not registered
+func (r *Runner) Status(name string) (Status, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, j := range r.jobs {
+		if j.name == name {
+			j.mutex.RLock()
+			defer j.mutex.RUnlock()
+			return j.status, true
+		}
+	}
+	return Status{}, false
+}
+
+// Name ...
+func (r *Runner) Name() string {
+	return "jobs"
+}
+
+// Check fails if a run of any job lasts more than stuckTimeout, or any job fails
+// consecutively for failureThreshold times.
+func (r *Runner) Check(_ *http.Request) error {
+	r.mutex.Lock()
+	jobs := r.jobs
+	r.mutex.Unlock()
+
+	var errs []error
+	for _, j := range jobs {
+		j.mutex.RLock()
+		status := j.status
+		j.mutex.RUnlock()
+
+		if r.stuckTimeout > 0 && !status.RunningSince.IsZero() {
+			if running := r.clock.Since(status.RunningSince); running > r.stuckTimeout {
+				errs = append(errs, fmt.Errorf("job %s has been running for %s", j.name, running))
+			}
+		}
+		if r.failureThreshold > 0 && status.ConsecutiveFailures >= r.failureThreshold {
+			errs = append(errs, fmt.Errorf("job %s failed %d times consecutively: %w", j.name, status.ConsecutiveFailures, status.LastError))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// WithPrefix returns a Registerer registering jobs to r with names prefixed, to
+// distinguish the same jobs of different instances.
+func WithPrefix(r Registerer, prefix string) Registerer {
+	return &prefixedRegisterer{registerer: r, prefix: prefix}
+}
+
+type prefixedRegisterer struct {
+	registerer Registerer
+	prefix     string
+}
+
+// Register ...
+func (p *prefixedRegisterer) Register(name string, period time.Duration, fn func() error) error {
+	return p.registerer.Register(p.prefix+"/"+name, period, fn)
+}
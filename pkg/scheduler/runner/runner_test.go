@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestRegister(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	r := New(NewOptions(), testingclock.NewFakeClock(time.Now()))
+	g.Expect(r.Register("job", time.Second, func() error { return nil })).To(gomega.Succeed())
+	g.Expect(r.Register("job", time.Second, func() error { return nil })).NotTo(gomega.Succeed())
+	g.Expect(r.Register("invalid", 0, func() error { return nil })).NotTo(gomega.Succeed())
+
+	prefixed := WithPrefix(r, "endpoint-a")
+	g.Expect(prefixed.Register("job", time.Second, func() error { return nil })).To(gomega.Succeed())
+	_, ok := r.Status("endpoint-a/job")
+	g.Expect(ok).To(gomega.BeTrue())
+}
+
+func TestRunDue(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	fakeClock := testingclock.NewFakeClock(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	r := New(NewOptions(), fakeClock)
+
+	var runs []string
+	g.Expect(r.Register("fast", time.Second*10, func() error { runs = append(runs, "fast"); return nil })).To(gomega.Succeed())
+	g.Expect(r.Register("slow", time.Minute, func() error { runs = append(runs, "slow"); return nil })).To(gomega.Succeed())
+
+	// all jobs are due at first, in order of registration
+	g.Expect(r.RunDue()).To(gomega.Equal(fakeClock.Now().Add(time.Second * 10)))
+	g.Expect(runs).To(gomega.Equal([]string{"fast", "slow"}))
+
+	runs = nil
+	fakeClock.Step(time.Second * 5)
+	r.RunDue()
+	g.Expect(runs).To(gomega.BeEmpty())
+
+	fakeClock.Step(time.Minute)
+	r.RunDue()
+	g.Expect(runs).To(gomega.Equal([]string{"fast", "slow"}))
+}
+
+func TestRunStatus(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	fakeClock := testingclock.NewFakeClock(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	opts := NewOptions()
+	opts.FailureThreshold = 2
+	r := New(opts, fakeClock)
+
+	var fail atomic.Bool
+	fail.Store(true)
+	g.Expect(r.Register("failing", time.Second, func() error {
+		if fail.Load() {
+			return errors.New("failed")
+		}
+		return nil
+	})).To(gomega.Succeed())
+	g.Expect(r.Register("panicking", time.Minute, func() error { panic("boom") })).To(gomega.Succeed())
+
+	r.RunDue()
+	status, ok := r.Status("failing")
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(status.LastRunTime).To(gomega.Equal(fakeClock.Now()))
+	g.Expect(status.LastError).To(gomega.MatchError("failed"))
+	g.Expect(status.ConsecutiveFailures).To(gomega.Equal(1))
+	status, _ = r.Status("panicking")
+	g.Expect(status.LastError).To(gomega.MatchError(gomega.ContainSubstring("boom")))
+	g.Expect(r.Check(nil)).To(gomega.Succeed())
+
+	fakeClock.Step(time.Second)
+	r.RunDue()
+	status, _ = r.Status("failing")
+	g.Expect(status.ConsecutiveFailures).To(gomega.Equal(2))
+	g.Expect(r.Check(nil)).To(gomega.MatchError(gomega.ContainSubstring("job failing failed 2 times")))
+
+	fail.Store(false)
+	fakeClock.Step(time.Second)
+	r.RunDue()
+	status, _ = r.Status("failing")
+	g.Expect(status.ConsecutiveFailures).To(gomega.BeZero())
+	g.Expect(status.LastError).To(gomega.BeNil())
+	g.Expect(r.Check(nil)).To(gomega.Succeed())
+}
+
+func TestStartStop(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	opts := NewOptions()
+	opts.Jitter = 0
+	opts.StuckTimeout = time.Minute
+	r := New(opts, fakeClock)
+
+	var runs atomic.Int32
+	release := make(chan struct{})
+	g.Expect(r.Register("blocking", time.Second, func() error {
+		runs.Add(1)
+		<-release
+		return nil
+	})).To(gomega.Succeed())
+
+	r.Start()
+	g.Eventually(fakeClock.HasWaiters).Should(gomega.BeTrue())
+	fakeClock.Step(time.Second)
+	g.Eventually(runs.Load).Should(gomega.Equal(int32(1)))
+
+	// skipped as the last run is not finished
+	g.Eventually(fakeClock.HasWaiters).Should(gomega.BeTrue())
+	fakeClock.Step(time.Second)
+	g.Eventually(fakeClock.HasWaiters).Should(gomega.BeTrue())
+	g.Consistently(runs.Load, time.Millisecond*100).Should(gomega.Equal(int32(1)))
+
+	fakeClock.Step(time.Minute)
+	g.Expect(r.Check(nil)).To(gomega.MatchError(gomega.ContainSubstring("job blocking has been running for")))
+
+	stopCtx := r.Stop()
+	g.Consistently(stopCtx.Done(), time.Millisecond*100).ShouldNot(gomega.BeClosed())
+	close(release)
+	g.Eventually(stopCtx.Done()).Should(gomega.BeClosed())
+	g.Expect(r.Check(nil)).To(gomega.Succeed())
+
+	// started again after stop
+	r.Start()
+	g.Eventually(fakeClock.HasWaiters).Should(gomega.BeTrue())
+	fakeClock.Step(time.Second)
+	g.Eventually(runs.Load).Should(gomega.Equal(int32(2)))
+	g.Eventually(r.Stop().Done()).Should(gomega.BeClosed())
+}
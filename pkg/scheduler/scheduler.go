@@ -2,20 +2,26 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
+	"net/http"
 	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/log"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/utils/clock"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	"github.com/GBA-BI/tes-scheduler/pkg/metrics"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/controller"
-	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/crontab"
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/runner"
 	"github.com/GBA-BI/tes-scheduler/pkg/utils"
 	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
 )
@@ -23,8 +29,18 @@ import (
 // Scheduler ...
 type Scheduler struct {
 	cache                  *cache.Cache
+	clock                  clock.PassiveClock
+	jobs                   *runner.Runner
 	plugins                pluginsGroup
+	tieBreaker             tieBreaker
 	clusterNotReadyTimeout time.Duration
+	deadlineRiskWindow     time.Duration
+	queuePriorities        *queuePriorities
+	permanentRejections    []string
+	// leading is true while Run, caches are only synced then
+	leading atomic.Bool
+	// unscheduledHandler is called with names of plugins rejecting a task, nil means none
+	unscheduledHandler func(task *schemodels.TaskInfo, pluginNames []string)
 }
 
 type pluginsGroup struct {
@@ -32,38 +48,64 @@ type pluginsGroup struct {
 	globalFilters []plugin.GlobalFilterPlugin
 	filters       []plugin.FilterPlugin
 	scores        []plugin.ScorePlugin
+	// scoreWeights is pluginName -> weight, missing means 1
+	scoreWeights map[string]int64
 }
 
-// NewScheduler ...
-func NewScheduler(opts *Options, vetesClient vetesclient.Client) (*Scheduler, error) {
-	cache, err := cache.NewCache(vetesClient, opts.Cache)
+func (p pluginsGroup) scoreWeight(pluginName string) int64 {
+	if weight, ok := p.scoreWeights[pluginName]; ok {
+		return weight
+	}
+	return 1
+}
+
+// NewScheduler federates caches of all vetesClients, unless there is only the one
+// with empty name. The caches, the controller, plugins and periodic jobs share clk.
+func NewScheduler(opts *Options, vetesClients map[string]vetesclient.Client, clk clock.WithTicker) (*Scheduler, error) {
+	jobs := runner.New(opts.Jobs, clk)
+	cache, err := newCache(vetesClients, opts.Cache, clk, jobs)
 	if err != nil {
 		return nil, err
 	}
 
 	scheduler := &Scheduler{
 		cache:                  cache,
+		clock:                  clk,
+		jobs:                   jobs,
 		clusterNotReadyTimeout: opts.ClusterNotReadyTimeout,
+		deadlineRiskWindow:     opts.DeadlineRiskWindow,
+		queuePriorities:        &queuePriorities{},
+		permanentRejections:    opts.PermanentRejections,
 	}
 	plugins, err := initPluginsGroup(opts, cache)
 	if err != nil {
 		return nil, err
 	}
 	scheduler.plugins = plugins
+	if scheduler.tieBreaker, err = newTieBreaker(opts.TieBreak); err != nil {
+		return nil, err
+	}
 
-	if err = controller.Init(opts.Controller, cache); err != nil {
+	if err = controller.Init(opts.Controller, cache, jobs); err != nil {
 		return nil, err
 	}
 
-	if err = crontab.RegisterCron(opts.SchedulePeriod, scheduler.scheduleTasks); err != nil {
+	if err = jobs.Register("schedule", opts.SchedulePeriod, scheduler.scheduleTasks); err != nil {
 		return nil, err
 	}
 
 	return scheduler, nil
 }
 
+func newCache(vetesClients map[string]vetesclient.Client, opts *cache.Options, clk clock.PassiveClock, jobs runner.Registerer) (*cache.Cache, error) {
+	if vetesClient, ok := vetesClients[""]; ok && len(vetesClients) == 1 {
+		return cache.NewCache(vetesClient, opts, clk, jobs)
+	}
+	return cache.NewFederatedCache(vetesClients, opts, clk, jobs)
+}
+
 func initPluginsGroup(opts *Options, cache *cache.Cache) (pluginsGroup, error) {
-	plugins := pluginsGroup{}
+	plugins := pluginsGroup{scoreWeights: make(map[string]int64)}
 	for _, pluginName := range opts.Plugins {
 		factory, ok := registry[pluginName]
 		if !ok {
@@ -84,28 +126,94 @@ func initPluginsGroup(opts *Options, cache *cache.Cache) (pluginsGroup, error) {
 		}
 		if score, ok := p.(plugin.ScorePlugin); ok {
 			plugins.scores = append(plugins.scores, score)
+			plugins.scoreWeights[pluginName] = extractScoreWeight(opts, pluginName)
 		}
 	}
 	return plugins, nil
 }
 
+// OnUnscheduled sets the handler called with names of plugins rejecting a task,
+// every time the task is found unschedulable in a scheduling cycle.
+func (s *Scheduler) OnUnscheduled(handler func(task *schemodels.TaskInfo, pluginNames []string)) {
+	s.unscheduledHandler = handler
+}
+
+// Jobs returns the runner of periodic jobs of the scheduler
+func (s *Scheduler) Jobs() *runner.Runner {
+	return s.jobs
+}
+
+// HealthCheckers returns liveness checkers of the scheduler, to be registered by the caller
+func (s *Scheduler) HealthCheckers() []healthz.HealthChecker {
+	return []healthz.HealthChecker{s.jobs}
+}
+
+// ReadinessCheckers returns checkers of the scheduler only for readiness
+func (s *Scheduler) ReadinessCheckers() []healthz.HealthChecker {
+	return []healthz.HealthChecker{&cacheChecker{scheduler: s}}
+}
+
+// DebugHandlers returns debug handlers of the scheduler, name -> handler
+func (s *Scheduler) DebugHandlers() map[string]http.Handler {
+	return map[string]http.Handler{prioritiesDebugName: s.queuePriorities}
+}
+
 // Run ...
 func (s *Scheduler) Run(ctx context.Context) {
-	crontab.Start()
+	s.leading.Store(true)
+	defer s.leading.Store(false)
+	s.jobs.Start()
 	defer func() {
-		stopCtx := crontab.Stop()
-		<-stopCtx.Done() // wait for all cronjob finish
+		stopCtx := s.jobs.Stop()
+		<-stopCtx.Done() // wait for all running jobs finish
 	}()
 
 	<-ctx.Done()
 }
 
-func (s *Scheduler) scheduleTasks() {
-	tasks := s.cache.TaskCache.ListTasks("")
+// cacheChecker fails readiness if caches are stale while leading. Non-leaders do
+// not sync caches, so they are exempt.
+type cacheChecker struct {
+	scheduler *Scheduler
+}
+
+// Name ...
+func (c *cacheChecker) Name() string {
+	return "cache"
+}
+
+// Check ...
+func (c *cacheChecker) Check(_ *http.Request) error {
+	if !c.scheduler.leading.Load() {
+		return nil
+	}
+	return c.scheduler.cache.Stale()
+}
+
+// scheduleTasks returns an error if assignments fail to be committed other than
+// conflicts and rejections. Scheduling is skipped if the cache is stale, which is
+// reported by readiness rather than as a failure of the job.
+func (s *Scheduler) scheduleTasks() error {
+	if err := s.cache.Stale(); err != nil {
+		log.Warnw("cache is stale, skip scheduling", "err", err)
+		return nil
+	}
+
+	start := s.clock.Now()
+	defer func() {
+		metrics.ScheduleCycleDuration.Observe(s.clock.Since(start).Seconds())
+	}()
+
+	// plugins read from the snapshot during this cycle
+	s.cache.Snapshot = s.cache.TakeSnapshot()
+
+	tasks := s.cache.Snapshot.ListTasks("")
+	recordQueueDepth(s.cache.Snapshot)
 	toScheduleTasks := make([]*schemodels.TaskInfo, 0, len(tasks))
+	cancelingTasks := make([]*schemodels.TaskInfo, 0)
 	for _, task := range tasks {
 		if task.State == consts.TaskCanceling {
-			s.cancelUnscheduledTask(task)
+			cancelingTasks = append(cancelingTasks, task)
 			continue
 		}
 		if task.State != consts.TaskQueued {
@@ -113,61 +221,303 @@ func (s *Scheduler) scheduleTasks() {
 		}
 		toScheduleTasks = append(toScheduleTasks, task)
 	}
+	s.cancelUnscheduledTasks(cancelingTasks)
+	sort.Slice(toScheduleTasks, func(i, j int) bool {
+		return s.plugins.sort.Less(toScheduleTasks[i], toScheduleTasks[j])
+	})
+	s.queuePriorities.record(s.cache.Snapshot, toScheduleTasks)
+	s.recordExtraPriorityAffectedTasks(toScheduleTasks)
 	if len(toScheduleTasks) == 0 {
-		return
+		recordCycleTasks(cycleTasks{})
+		s.checkDeadlines(nil)
+		return nil
 	}
 
-	clusters := s.cache.ClusterCache.ListClusters()
+	clusters := s.cache.Snapshot.ListClusters()
 	readyClusters := make([]*schemodels.ClusterInfo, 0, len(clusters))
 	for _, cluster := range clusters {
-		if time.Since(cluster.HeartbeatTimestamp) <= s.clusterNotReadyTimeout {
+		if s.clock.Since(cluster.HeartbeatTimestamp) <= s.clusterNotReadyTimeout {
 			readyClusters = append(readyClusters, cluster)
 		}
 	}
 	if len(readyClusters) == 0 {
-		return
+		recordCycleTasks(cycleTasks{attempted: len(toScheduleTasks), unschedulable: len(toScheduleTasks)})
+		s.checkDeadlines(toScheduleTasks)
+		return nil
 	}
 
-	sort.Slice(toScheduleTasks, func(i, j int) bool {
-		return s.plugins.sort.Less(toScheduleTasks[i], toScheduleTasks[j])
-	})
+	unscheduledTasks := make([]*schemodels.TaskInfo, 0)
+	assignments := make([]*assignment, 0, len(toScheduleTasks))
 	for _, task := range toScheduleTasks {
-		s.scheduleTask(task, readyClusters)
+		if assignment := s.scheduleTask(task, s.placeableClusters(task, readyClusters)); assignment != nil {
+			assignments = append(assignments, assignment)
+		} else {
+			unscheduledTasks = append(unscheduledTasks, task)
+		}
+	}
+	commit := s.commitAssignments(assignments)
+	s.tieBreaker.record(commit.scheduledClusterIDs)
+	now := s.clock.Now()
+	for _, task := range commit.scheduled {
+		if !task.CreationTime.IsZero() {
+			metrics.TaskScheduleLatency.Observe(now.Sub(task.CreationTime).Seconds())
+		}
+	}
+	unscheduledTasks = append(unscheduledTasks, commit.errored...)
+	recordCycleTasks(cycleTasks{
+		attempted:     len(toScheduleTasks),
+		scheduled:     len(commit.scheduled),
+		unschedulable: len(unscheduledTasks),
+		conflict:      commit.conflicts,
+		rejected:      len(commit.rejected) + commit.permanentlyRejected,
+	})
+	// rejected ones left queued are still at risk
+	s.checkDeadlines(append(unscheduledTasks, commit.rejected...))
+	return commit.err
+}
+
+// cycleTasks are numbers of queued tasks by result in a cycle
+type cycleTasks struct {
+	attempted     int
+	scheduled     int
+	unschedulable int
+	// conflict are changed concurrently, rejected are responded 400 when committed
+	conflict int
+	rejected int
+}
+
+// recordCycleTasks reports numbers of queued tasks by result in this cycle
+func recordCycleTasks(tasks cycleTasks) {
+	for result, count := range map[string]int{
+		"attempted":     tasks.attempted,
+		"scheduled":     tasks.scheduled,
+		"unschedulable": tasks.unschedulable,
+		"conflict":      tasks.conflict,
+		"rejected":      tasks.rejected,
+	} {
+		metrics.ScheduleCycleTasks.WithLabelValues(result).Set(float64(count))
+		metrics.ScheduleAttempts.WithLabelValues(result).Add(float64(count))
 	}
 }
 
-func (s *Scheduler) cancelUnscheduledTask(task *schemodels.TaskInfo) {
+// recordQueueDepth reports unfinished tasks in snapshot by state, account and GPU type
+func recordQueueDepth(snapshot *cache.Snapshot) {
+	metrics.QueueDepth.Reset()
+	for _, task := range snapshot.ListAllTasks() {
+		var accountID, gpuType string
+		if task.BioosInfo != nil {
+			accountID = task.BioosInfo.AccountID
+		}
+		if task.Resources != nil && task.Resources.GPU != nil {
+			gpuType = task.Resources.GPU.Type
+		}
+		metrics.QueueDepth.WithLabelValues(task.State, accountID, gpuType).Inc()
+	}
+}
+
+// placeableClusters returns clusters registered with the same endpoint as task,
+// or all clusters if not federated. Agents only poll their own endpoint, so a task
+// is never handed to clusters of another endpoint.
+func (s *Scheduler) placeableClusters(task *schemodels.TaskInfo, clusters []*schemodels.ClusterInfo) []*schemodels.ClusterInfo {
+	if len(s.cache.Endpoints) == 0 {
+		return clusters
+	}
+	endpoint := s.cache.EndpointOf(task.ID)
+	res := make([]*schemodels.ClusterInfo, 0, len(clusters))
+	for _, cluster := range clusters {
+		if s.cache.EndpointOf(cluster.ID) == endpoint {
+			res = append(res, cluster)
+		}
+	}
+	return res
+}
+
+// checkDeadlines reports unschedulable tasks which are close to their deadline,
+// taking the estimated runtime into account
+func (s *Scheduler) checkDeadlines(unscheduledTasks []*schemodels.TaskInfo) {
 	ctx := context.Background()
-	if err := s.cache.TaskCache.UpdateTask(ctx, task.ID, utils.Point(consts.TaskCanceled), nil, nil); err != nil {
-		log.CtxErrorw(ctx, "failed to cancel unscheduled task", "task", task.ID, "err", err)
+	atRisk := 0
+	for _, task := range unscheduledTasks {
+		if task.Deadline.IsZero() {
+			continue
+		}
+		// the task must start before deadline minus its estimated runtime
+		latestStartTime := task.Deadline
+		estimatedRuntime, ok := s.cache.RuntimeEstimator.Estimate(task)
+		if ok {
+			latestStartTime = latestStartTime.Add(-estimatedRuntime)
+		}
+		remaining := latestStartTime.Sub(s.clock.Now())
+		if remaining > s.deadlineRiskWindow {
+			continue
+		}
+		atRisk++
+		log.CtxWarnw(ctx, "task close to deadline is still unschedulable", "task", task.ID, "deadline", task.Deadline, "estimatedRuntime", estimatedRuntime, "remaining", remaining)
+	}
+	metrics.DeadlineAtRiskTasks.Set(float64(atRisk))
+}
+
+// recordExtraPriorityAffectedTasks reports how many queued tasks active extraPriorities match, summed
+// up by account, and logs the count of each extraPriority
+func (s *Scheduler) recordExtraPriorityAffectedTasks(queuedTasks []*schemodels.TaskInfo) {
+	now := s.cache.Snapshot.Now()
+	affected := make(map[*schemodels.ExtraPriorityInfo]int)
+	for _, extraPriority := range s.cache.Snapshot.ListExtraPriorities() {
+		if extraPriority.ActiveAt(now) {
+			affected[extraPriority] = 0
+		}
+	}
+	for _, task := range queuedTasks {
+		for _, extraPriority := range s.cache.Snapshot.MatchExtraPriorities(task) {
+			affected[extraPriority]++
+		}
+	}
+
+	metrics.ExtraPriorityAffectedTasks.Reset()
+	for extraPriority, count := range affected {
+		metrics.ExtraPriorityAffectedTasks.WithLabelValues(extraPriority.AccountID).Add(float64(count))
+		log.Debugw("extraPriority affected tasks", "accountID", extraPriority.AccountID, "userID", extraPriority.UserID,
+			"submissionID", extraPriority.SubmissionID, "runID", extraPriority.RunID, "reason", extraPriority.Reason, "count", count)
+	}
+}
+
+func (s *Scheduler) cancelUnscheduledTasks(tasks []*schemodels.TaskInfo) {
+	if len(tasks) == 0 {
+		return
+	}
+	ctx := context.Background()
+	updates := make([]*cache.TaskUpdate, 0, len(tasks))
+	for _, task := range tasks {
+		updates = append(updates, &cache.TaskUpdate{ID: task.ID, State: utils.Point(consts.TaskCanceled)})
+	}
+	errs := s.cache.TaskCache.BatchUpdateTasks(ctx, updates)
+	for _, task := range tasks {
+		err := errs[task.ID]
+		switch {
+		case err == nil:
+			log.CtxInfow(ctx, "directly cancel unscheduled task", "task", task.ID)
+		case errors.Is(err, vetesclient.ErrConflict):
+			log.CtxInfow(ctx, "task changed concurrently, skip canceling it", "task", task.ID, "err", err)
+		default:
+			log.CtxErrorw(ctx, "failed to cancel unscheduled task", "task", task.ID, "err", err)
+		}
 	}
-	log.CtxInfow(ctx, "directly cancel unscheduled task", "task", task.ID)
 }
 
-func (s *Scheduler) scheduleTask(task *schemodels.TaskInfo, clusters []*schemodels.ClusterInfo) {
+// assignment is a scheduled task waiting to be committed
+type assignment struct {
+	task           *schemodels.TaskInfo
+	clusterID      string
+	tiedClusterIDs []string
+}
+
+// scheduleTask returns the assignment of the task, or nil if it is unschedulable.
+// The task is assigned in the snapshot at once, so the following tasks of this
+// cycle take its usage into account before the assignment is committed.
+func (s *Scheduler) scheduleTask(task *schemodels.TaskInfo, clusters []*schemodels.ClusterInfo) *assignment {
 	ctx := context.Background()
 	cycleState := make(map[string]interface{})
 
 	for _, globalFilter := range s.plugins.globalFilters {
 		if err := globalFilter.GlobalFilter(ctx, task, cycleState); err != nil {
-			s.recordUnscheduledReason(ctx, task.ID, map[string][]error{globalFilter.Name(): {err}})
-			return
+			s.recordUnscheduledReason(ctx, task, map[string][]error{globalFilter.Name(): {err}})
+			return nil
 		}
 	}
 
 	availableClusters, pluginNameWithErrors := s.filterAvailableClusters(task, clusters, ctx, cycleState)
 	if len(availableClusters) == 0 {
-		s.recordUnscheduledReason(ctx, task.ID, pluginNameWithErrors)
-		return
+		s.recordUnscheduledReason(ctx, task, pluginNameWithErrors)
+		return nil
 	}
 
 	clusterWithScores := s.getClusterWithScores(task, availableClusters, ctx, cycleState)
-	scheduleClusterID := s.getMaxScoreClusterID(clusterWithScores)
+	scheduleClusterID, tiedClusterIDs := s.getMaxScoreClusterID(clusterWithScores)
+	s.cache.Snapshot.AssignTask(task.ID, scheduleClusterID)
+	return &assignment{task: task, clusterID: scheduleClusterID, tiedClusterIDs: tiedClusterIDs}
+}
+
+// commitResult is the result of committing assignments
+type commitResult struct {
+	// scheduled are updated successfully to scheduledClusterIDs respectively
+	scheduled           []*schemodels.TaskInfo
+	scheduledClusterIDs []string
+	// errored are failed to be updated for errors other than conflicts and
+	// rejections, and err aggregates the errors. They are scheduled in next cycles.
+	errored []*schemodels.TaskInfo
+	err     error
+	// rejected are responded 400 and left queued, permanently rejected ones are
+	// marked failed instead
+	rejected            []*schemodels.TaskInfo
+	permanentlyRejected int
+	// conflicts is the number of tasks changed concurrently
+	conflicts int
+}
 
-	if err := s.cache.TaskCache.UpdateTask(ctx, task.ID, nil, utils.Point(scheduleClusterID), nil); err != nil {
-		s.recordUnscheduledReason(ctx, task.ID, map[string][]error{"finalUpdate": {err}})
+// commitAssignments updates the assigned tasks in one batch
+func (s *Scheduler) commitAssignments(assignments []*assignment) *commitResult {
+	res := &commitResult{}
+	if len(assignments) == 0 {
+		return res
 	}
-	s.recordScheduleResult(ctx, task.ID, scheduleClusterID)
+	ctx := context.Background()
+	updates := make([]*cache.TaskUpdate, 0, len(assignments))
+	for _, assignment := range assignments {
+		updates = append(updates, &cache.TaskUpdate{ID: assignment.task.ID, ClusterID: utils.Point(assignment.clusterID)})
+	}
+	errs := s.cache.TaskCache.BatchUpdateTasks(ctx, updates)
+
+	var commitErrs []error
+	for _, assignment := range assignments {
+		taskID := assignment.task.ID
+		err := errs[taskID]
+		switch {
+		case err == nil:
+			s.recordScheduleResult(ctx, taskID, assignment.clusterID, assignment.tiedClusterIDs)
+			res.scheduled = append(res.scheduled, assignment.task)
+			res.scheduledClusterIDs = append(res.scheduledClusterIDs, assignment.clusterID)
+		case errors.Is(err, vetesclient.ErrConflict):
+			// such as canceled by user, the cache has refetched it
+			log.CtxInfow(ctx, "task changed concurrently, skip scheduling it", "task", taskID, "err", err)
+			res.conflicts++
+		case s.isPermanentRejection(err):
+			// retrying will never succeed
+			s.markTaskFailed(ctx, taskID, fmt.Sprintf("failed to schedule to cluster %s: %s", assignment.clusterID, err))
+			res.permanentlyRejected++
+		case errors.Is(err, vetesclient.ErrBadRequest):
+			log.CtxWarnw(ctx, "assignment rejected, leave task queued", "task", taskID, "cluster", assignment.clusterID, "err", err)
+			s.recordUnscheduledReason(ctx, assignment.task, map[string][]error{"finalUpdate": {err}})
+			res.rejected = append(res.rejected, assignment.task)
+		default:
+			s.recordUnscheduledReason(ctx, assignment.task, map[string][]error{"finalUpdate": {err}})
+			res.errored = append(res.errored, assignment.task)
+			commitErrs = append(commitErrs, fmt.Errorf("task %s: %w", taskID, err))
+		}
+	}
+	res.err = utilerrors.NewAggregate(commitErrs)
+	return res
+}
+
+// isPermanentRejection returns whether err is a 400 response with a message in permanentRejections
+func (s *Scheduler) isPermanentRejection(err error) bool {
+	var apiErr *vetesclient.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	for _, rejection := range s.permanentRejections {
+		if strings.Contains(apiErr.Body, rejection) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scheduler) markTaskFailed(ctx context.Context, taskID, message string) {
+	if err := s.cache.TaskCache.UpdateTask(ctx, taskID, utils.Point(consts.TaskSystemError), nil, utils.Point(message)); err != nil {
+		log.CtxErrorw(ctx, "failed to mark task failed", "task", taskID, "err", err)
+		return
+	}
+	log.CtxInfow(ctx, "mark task failed", "task", taskID, "message", message)
 }
 
 func (s *Scheduler) filterAvailableClusters(task *schemodels.TaskInfo, clusters []*schemodels.ClusterInfo, ctx context.Context, cycleState map[string]interface{}) ([]*schemodels.ClusterInfo, map[string][]error) {
@@ -191,9 +541,15 @@ func (s *Scheduler) filterAvailableClusters(task *schemodels.TaskInfo, clusters
 
 func (s *Scheduler) getClusterWithScores(task *schemodels.TaskInfo, availableClusters []*schemodels.ClusterInfo, ctx context.Context, cycleState map[string]interface{}) []clusterWithScore {
 	clusterWithScores := make([]clusterWithScore, 0, len(availableClusters))
+	cycleState[plugin.CandidateClustersKey] = availableClusters
 	for _, cluster := range availableClusters {
 		var valueSum int64 = 0
+		var weightSum int64 = 0
 		for _, score := range s.plugins.scores {
+			weight := s.plugins.scoreWeight(score.Name())
+			if weight == 0 {
+				continue
+			}
 			scoreValue := score.Score(ctx, task, cluster, cycleState)
 			if scoreValue < plugin.MinScore {
 				scoreValue = plugin.MinScore
@@ -201,9 +557,10 @@ func (s *Scheduler) getClusterWithScores(task *schemodels.TaskInfo, availableClu
 			if scoreValue > plugin.MaxScore {
 				scoreValue = plugin.MaxScore
 			}
-			valueSum += scoreValue
+			valueSum += scoreValue * weight
+			weightSum += weight
 		}
-		if len(s.plugins.scores) == 0 {
+		if weightSum == 0 {
 			clusterWithScores = append(clusterWithScores, clusterWithScore{
 				clusterID: cluster.ID,
 				score:     plugin.MaxScore,
@@ -211,28 +568,28 @@ func (s *Scheduler) getClusterWithScores(task *schemodels.TaskInfo, availableClu
 		} else {
 			clusterWithScores = append(clusterWithScores, clusterWithScore{
 				clusterID: cluster.ID,
-				score:     valueSum / int64(len(s.plugins.scores)),
+				score:     valueSum / weightSum,
 			})
 		}
 	}
 	return clusterWithScores
 }
 
-func (s *Scheduler) getMaxScoreClusterID(clusterWithScores []clusterWithScore) string {
-	var maxItems []clusterWithScore // clusterID with same score
+// getMaxScoreClusterID returns the picked clusterID and all clusterIDs with the max score.
+func (s *Scheduler) getMaxScoreClusterID(clusterWithScores []clusterWithScore) (string, []string) {
+	var maxClusterIDs []string // clusterID with same score
+	var maxScore int64
 	for _, item := range clusterWithScores {
-		if len(maxItems) == 0 {
-			maxItems = []clusterWithScore{item}
-		}
-		if item.score > maxItems[0].score {
-			maxItems = []clusterWithScore{item}
+		if len(maxClusterIDs) == 0 || item.score > maxScore {
+			maxClusterIDs = []string{item.clusterID}
+			maxScore = item.score
+			continue
 		}
-		if item.score == maxItems[0].score {
-			maxItems = append(maxItems, item)
+		if item.score == maxScore {
+			maxClusterIDs = append(maxClusterIDs, item.clusterID)
 		}
 	}
-	maxItem := maxItems[rand.Intn(len(maxItems))]
-	return maxItem.clusterID
+	return s.tieBreaker.pick(maxClusterIDs), maxClusterIDs
 }
 
 type clusterWithScore struct {
@@ -240,16 +597,30 @@ type clusterWithScore struct {
 	score     int64
 }
 
-func (s *Scheduler) recordUnscheduledReason(ctx context.Context, taskID string, pluginNameWithErrors map[string][]error) {
+func (s *Scheduler) recordUnscheduledReason(ctx context.Context, task *schemodels.TaskInfo, pluginNameWithErrors map[string][]error) {
 	keysAndValues := make([]interface{}, 0, len(pluginNameWithErrors)*2)
+	pluginNames := make([]string, 0, len(pluginNameWithErrors))
 	for name, errs := range pluginNameWithErrors {
+		err := utilerrors.NewAggregate(errs)
 		keysAndValues = append(keysAndValues, name)
-		keysAndValues = append(keysAndValues, utilerrors.NewAggregate(errs).Error())
+		keysAndValues = append(keysAndValues, err.Error())
+		pluginNames = append(pluginNames, name)
+		for _, reason := range utils.Reasons(err) {
+			metrics.UnschedulableTasks.WithLabelValues(name, reason).Inc()
+		}
 	}
-	keysAndValues = append(keysAndValues, "task", taskID)
+	keysAndValues = append(keysAndValues, "task", task.ID)
 	log.CtxInfow(ctx, "failed to schedule task", keysAndValues...)
+	if s.unscheduledHandler != nil {
+		sort.Strings(pluginNames)
+		s.unscheduledHandler(task, pluginNames)
+	}
 }
 
-func (s *Scheduler) recordScheduleResult(ctx context.Context, taskID, clusterID string) {
-	log.CtxInfow(ctx, "successfully schedule task", "task", taskID, "cluster", clusterID)
+func (s *Scheduler) recordScheduleResult(ctx context.Context, taskID, clusterID string, tiedClusterIDs []string) {
+	keysAndValues := []interface{}{"task", taskID, "cluster", clusterID}
+	if len(tiedClusterIDs) > 1 {
+		keysAndValues = append(keysAndValues, "tieBreak", s.tieBreaker.name(), "tiedClusters", tiedClusterIDs)
+	}
+	log.CtxInfow(ctx, "successfully schedule task", keysAndValues...)
 }
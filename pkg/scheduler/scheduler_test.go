@@ -1,23 +1,35 @@
 package scheduler
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	testingclock "k8s.io/utils/clock/testing"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	"github.com/GBA-BI/tes-scheduler/pkg/metrics"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/cache/fake"
 	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/clustercapacity"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/clusterlimit"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/cost"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/prioritysort"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/resourcequota"
 	"github.com/GBA-BI/tes-scheduler/pkg/utils"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
+	clientmodels "github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/testserver"
 )
 
 func TestScheduleTasks(t *testing.T) {
@@ -25,7 +37,8 @@ func TestScheduleTasks(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	now := time.Now()
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	now := fakeClock.Now()
 
 	fakeClusterCache := fake.NewFakeClusterCache(ctrl)
 	fakeClusterCache.EXPECT().ListClusters().Return([]*schemodels.ClusterInfo{
@@ -33,14 +46,29 @@ func TestScheduleTasks(t *testing.T) {
 		{ID: "cluster-not-ready", HeartbeatTimestamp: now.Add(-time.Hour)},
 	})
 	fakeTaskCache := fake.NewFakeTaskCache(ctrl)
-	fakeTaskCache.EXPECT().ListTasks("").Return([]*schemodels.TaskInfo{
+	fakeTaskCache.EXPECT().ListTasksWithUsages().Return([]*schemodels.TaskInfo{
 		{ID: "task-01", State: consts.TaskQueued},
 		{ID: "task-02", State: consts.TaskQueued},
 		{ID: "task-canceling", State: consts.TaskCanceling},
+		{ID: "task-running", State: consts.TaskRunning, ClusterID: "cluster-ready"},
+	}, map[cache.UsageKey]*schemodels.Usage{
+		{}:                           {Count: 1},
+		{ClusterID: "cluster-ready"}: {Count: 1},
 	})
-	fakeTaskCache.EXPECT().UpdateTask(gomock.Any(), "task-canceling", utils.Point(consts.TaskCanceled), nil, nil).Return(nil)
-	task2Call := fakeTaskCache.EXPECT().UpdateTask(gomock.Any(), "task-02", nil, utils.Point("cluster-ready"), nil).Return(nil)
-	fakeTaskCache.EXPECT().UpdateTask(gomock.Any(), "task-01", nil, utils.Point("cluster-ready"), nil).Return(nil).After(task2Call)
+	fakeTaskCache.EXPECT().BatchUpdateTasks(gomock.Any(), []*cache.TaskUpdate{
+		{ID: "task-canceling", State: utils.Point(consts.TaskCanceled)},
+	}).Return(nil)
+	// task-02 is sorted before task-01
+	fakeTaskCache.EXPECT().BatchUpdateTasks(gomock.Any(), []*cache.TaskUpdate{
+		{ID: "task-02", ClusterID: utils.Point("cluster-ready")},
+		{ID: "task-01", ClusterID: utils.Point("cluster-ready")},
+	}).Return(nil)
+
+	fakeExtraPriorityCache := fake.NewFakeExtraPriorityCache(ctrl)
+	fakeExtraPriorityCache.EXPECT().GetExtraPriorityIndex().Return(schemodels.NewExtraPriorityIndex(nil))
+	fakeClusterCache.EXPECT().SyncStatus().Return(cache.SyncStatus{Synced: true, LastSyncTime: now})
+	fakeTaskCache.EXPECT().SyncStatus().Return(cache.SyncStatus{Synced: true, LastSyncTime: now})
+	fakeExtraPriorityCache.EXPECT().SyncStatus().Return(cache.SyncStatus{Synced: true, LastSyncTime: now})
 
 	fakeSort := plugin.NewFakeSortPlugin(ctrl)
 	// task-01 < task-02
@@ -55,15 +83,153 @@ func TestScheduleTasks(t *testing.T) {
 
 	s := &Scheduler{
 		cache: &cache.Cache{
-			ClusterCache: fakeClusterCache,
-			TaskCache:    fakeTaskCache,
+			ClusterCache:       fakeClusterCache,
+			TaskCache:          fakeTaskCache,
+			UsageCache:         fakeTaskCache,
+			ExtraPriorityCache: fakeExtraPriorityCache,
+			Clock:              fakeClock,
 		},
+		clock: fakeClock,
 		plugins: pluginsGroup{
 			sort: fakeSort,
 		},
+		tieBreaker:             &roundRobinTieBreaker{},
 		clusterNotReadyTimeout: time.Minute * 5,
+		queuePriorities:        &queuePriorities{},
+	}
+	g.Expect(s.scheduleTasks()).To(gomega.Succeed())
+	// assignments in this cycle are recorded in snapshot
+	g.Expect(s.cache.Snapshot.ListTasks("cluster-ready")).To(gomega.HaveLen(3))
+	g.Expect(s.cache.Snapshot.GetUsage(cache.UsageKey{ClusterID: "cluster-ready"}).Count).To(gomega.Equal(3))
+
+	g.Expect(testutil.ToFloat64(metrics.ScheduleCycleTasks.WithLabelValues("attempted"))).To(gomega.Equal(float64(2)))
+	g.Expect(testutil.ToFloat64(metrics.ScheduleCycleTasks.WithLabelValues("scheduled"))).To(gomega.Equal(float64(2)))
+	g.Expect(testutil.ToFloat64(metrics.ScheduleCycleTasks.WithLabelValues("unschedulable"))).To(gomega.Equal(float64(0)))
+	g.Expect(testutil.ToFloat64(metrics.ScheduleCycleTasks.WithLabelValues("conflict"))).To(gomega.Equal(float64(0)))
+	g.Expect(testutil.ToFloat64(metrics.ScheduleCycleTasks.WithLabelValues("rejected"))).To(gomega.Equal(float64(0)))
+	g.Expect(testutil.ToFloat64(metrics.QueueDepth.WithLabelValues(consts.TaskQueued, "", ""))).To(gomega.Equal(float64(2)))
+	g.Expect(testutil.ToFloat64(metrics.QueueDepth.WithLabelValues(consts.TaskRunning, "", ""))).To(gomega.Equal(float64(1)))
+
+	// sorted queue is recorded for debugging
+	recorder := httptest.NewRecorder()
+	s.queuePriorities.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/priorities", nil))
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+	resp := &queuePrioritiesResponse{}
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), resp)).To(gomega.Succeed())
+	g.Expect(resp.Tasks).To(gomega.HaveLen(2))
+	g.Expect(resp.Tasks[0].TaskID).To(gomega.Equal("task-02"))
+	g.Expect(resp.Tasks[1].TaskID).To(gomega.Equal("task-01"))
+}
+
+func TestScheduleTasksWithTestServer(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	// the test server stamps times by the same fake clock as the scheduler
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	server := testserver.New(fakeClock)
+	defer server.Close()
+	server.SetCluster(&clientmodels.Cluster{ID: "cluster-01", Capacity: &clientmodels.Capacity{Count: utils.Point(1)}})
+	server.SetQuotas(&clientmodels.Quota{Global: true, ResourceQuota: &clientmodels.ResourceQuota{Count: utils.Point(10)}})
+	taskIDs := []string{
+		server.AddTask(&clientmodels.Task{Resources: &clientmodels.Resources{CPUCores: 1}, PriorityValue: 10}),
+		server.AddTask(&clientmodels.Task{Resources: &clientmodels.Resources{CPUCores: 1}}),
+	}
+
+	clientOpts := vetesclient.NewOptions()
+	clientOpts.Endpoint = server.Endpoint()
+	vetesClient, err := vetesclient.NewClient(clientOpts, fakeClock)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	opts := NewOptions()
+	s, err := NewScheduler(opts, map[string]vetesclient.Client{"": vetesClient}, fakeClock)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// runCycle runs all jobs once, caches are synced before the controller and scheduling
+	runCycle := func() {
+		fakeClock.Step(opts.SchedulePeriod)
+		s.Jobs().RunDue()
+		for _, name := range []string{"schedule", "controller-reschedule"} {
+			status, ok := s.Jobs().Status(name)
+			g.Expect(ok).To(gomega.BeTrue())
+			g.Expect(status.LastRunTime).To(gomega.Equal(fakeClock.Now()))
+			g.Expect(status.LastError).NotTo(gomega.HaveOccurred())
+		}
 	}
-	g.Expect(func() { s.scheduleTasks() }).NotTo(gomega.Panic())
+
+	// cycle 1: only one task fits the capacity of cluster
+	rejections := testutil.ToFloat64(metrics.UnschedulableTasks.WithLabelValues(clustercapacity.Name, consts.ReasonCount))
+	runCycle()
+	g.Expect(testutil.ToFloat64(metrics.UnschedulableTasks.WithLabelValues(clustercapacity.Name, consts.ReasonCount))).To(gomega.Equal(rejections + 1))
+	g.Expect(server.GetTask(taskIDs[0]).ClusterID).To(gomega.Equal("cluster-01"))
+	g.Expect(server.GetTask(taskIDs[1]).ClusterID).To(gomega.BeEmpty())
+	g.Expect(server.PickUpTasks("cluster-01", 0)).To(gomega.Equal(taskIDs[:1]))
+	g.Expect(server.FinishTask(taskIDs[0], consts.TaskComplete)).To(gomega.Succeed())
+
+	// cycle 2: the finished task is synced, and the other one takes its place
+	runCycle()
+	g.Expect(server.GetTask(taskIDs[0]).State).To(gomega.Equal(consts.TaskComplete))
+	g.Expect(server.GetTask(taskIDs[1]).ClusterID).To(gomega.Equal("cluster-01"))
+	g.Expect(server.GetTask(taskIDs[1]).State).To(gomega.Equal(consts.TaskQueued))
+
+	// cycle 3: the controller reschedules the task of the removed cluster
+	server.RemoveCluster("cluster-01")
+	rescheduled := testutil.ToFloat64(metrics.ControllerRescheduledTasks)
+	runCycle()
+	g.Expect(testutil.ToFloat64(metrics.ControllerRescheduledTasks)).To(gomega.Equal(rescheduled + 1))
+	g.Expect(server.GetTask(taskIDs[1]).ClusterID).To(gomega.BeEmpty())
+	g.Expect(server.GetTask(taskIDs[1]).State).To(gomega.Equal(consts.TaskQueued))
+
+	// cycle 4: scheduled again once the cluster is back
+	server.SetCluster(&clientmodels.Cluster{ID: "cluster-01", Capacity: &clientmodels.Capacity{Count: utils.Point(1)}})
+	runCycle()
+	g.Expect(server.GetTask(taskIDs[1]).ClusterID).To(gomega.Equal("cluster-01"))
+	g.Expect(server.PickUpTasks("cluster-01", 0)).To(gomega.Equal(taskIDs[1:]))
+	g.Expect(server.FinishTask(taskIDs[1], consts.TaskComplete)).To(gomega.Succeed())
+}
+
+func TestScheduleTasksNotSynced(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeClusterCache := fake.NewFakeClusterCache(ctrl)
+	fakeClusterCache.EXPECT().SyncStatus().Return(cache.SyncStatus{})
+	fakeTaskCache := fake.NewFakeTaskCache(ctrl)
+	fakeTaskCache.EXPECT().SyncStatus().Return(cache.SyncStatus{Synced: true, LastSyncTime: time.Now()})
+	fakeExtraPriorityCache := fake.NewFakeExtraPriorityCache(ctrl)
+	fakeExtraPriorityCache.EXPECT().SyncStatus().Return(cache.SyncStatus{Synced: true, LastSyncTime: time.Now()})
+	s := &Scheduler{cache: &cache.Cache{
+		ClusterCache:       fakeClusterCache,
+		TaskCache:          fakeTaskCache,
+		ExtraPriorityCache: fakeExtraPriorityCache,
+	}}
+	g.Expect(s.scheduleTasks()).To(gomega.Succeed())
+	g.Expect(s.cache.Snapshot).To(gomega.BeNil())
+}
+
+func TestCacheChecker(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeClusterCache := fake.NewFakeClusterCache(ctrl)
+	fakeClusterCache.EXPECT().SyncStatus().Return(cache.SyncStatus{})
+	fakeTaskCache := fake.NewFakeTaskCache(ctrl)
+	fakeTaskCache.EXPECT().SyncStatus().Return(cache.SyncStatus{Synced: true, LastSyncTime: time.Now()})
+	fakeExtraPriorityCache := fake.NewFakeExtraPriorityCache(ctrl)
+	fakeExtraPriorityCache.EXPECT().SyncStatus().Return(cache.SyncStatus{Synced: true, LastSyncTime: time.Now()})
+	s := &Scheduler{cache: &cache.Cache{
+		ClusterCache:       fakeClusterCache,
+		TaskCache:          fakeTaskCache,
+		ExtraPriorityCache: fakeExtraPriorityCache,
+	}}
+	checkers := s.ReadinessCheckers()
+	g.Expect(checkers).To(gomega.HaveLen(1))
+	checker := checkers[0]
+
+	// non-leaders do not sync caches
+	g.Expect(checker.Check(nil)).To(gomega.Succeed())
+	s.leading.Store(true)
+	g.Expect(checker.Check(nil)).To(gomega.MatchError(gomega.ContainSubstring("cluster cache")))
 }
 
 func TestSchedulerTask(t *testing.T) {
@@ -166,27 +332,26 @@ func TestSchedulerTask(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			fakeTaskCache := fake.NewFakeTaskCache(ctrl)
-			switch len(test.expClusterIDs) {
-			case 0:
-				fakeTaskCache.EXPECT().UpdateTask(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
-			case 1:
-				fakeTaskCache.EXPECT().UpdateTask(gomock.Any(), test.task.ID, nil, utils.Point(test.expClusterIDs[0]), nil)
-			default:
-				// random
-				for _, clusterID := range test.expClusterIDs {
-					fakeTaskCache.EXPECT().UpdateTask(gomock.Any(), test.task.ID, nil, utils.Point(clusterID), nil).AnyTimes()
-				}
-			}
 			s := &Scheduler{
-				cache: &cache.Cache{TaskCache: fakeTaskCache},
+				cache: &cache.Cache{
+					Snapshot: cache.NewSnapshot(time.Now(), []*schemodels.TaskInfo{test.task}, test.clusters, nil),
+				},
 				plugins: pluginsGroup{
 					globalFilters: test.globalFilters,
 					filters:       test.filters,
 					scores:        test.scores,
 				},
+				tieBreaker: &randomTieBreaker{rand: rand.New(rand.NewSource(1))},
 			}
-			g.Expect(func() { s.scheduleTask(test.task, test.clusters) }).NotTo(gomega.Panic())
+			var res *assignment
+			g.Expect(func() { res = s.scheduleTask(test.task, test.clusters) }).NotTo(gomega.Panic())
+			if len(test.expClusterIDs) == 0 {
+				g.Expect(res).To(gomega.BeNil())
+				return
+			}
+			// random if more than one
+			g.Expect(res).NotTo(gomega.BeNil())
+			g.Expect(test.expClusterIDs).To(gomega.ContainElement(res.clusterID))
 		})
 	}
 }
@@ -208,3 +373,197 @@ func TestInitPluginsGroup(t *testing.T) {
 	g.Expect(plugins.filters[1].Name()).To(gomega.Equal(clusterlimit.Name))
 	g.Expect(plugins.scores[0].Name()).To(gomega.Equal(clustercapacity.Name))
 }
+
+func TestPlaceableClusters(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	clusters := []*schemodels.ClusterInfo{{ID: "endpoint-a/cluster-01"}, {ID: "endpoint-b/cluster-01"}}
+	task := &schemodels.TaskInfo{ID: "endpoint-b/task-01"}
+	s := &Scheduler{cache: &cache.Cache{Endpoints: []string{"endpoint-a", "endpoint-b"}}}
+	g.Expect(s.placeableClusters(task, clusters)).To(gomega.Equal([]*schemodels.ClusterInfo{{ID: "endpoint-b/cluster-01"}}))
+
+	// not federated
+	s = &Scheduler{cache: &cache.Cache{}}
+	g.Expect(s.placeableClusters(&schemodels.TaskInfo{ID: "task-01"}, clusters)).To(gomega.Equal(clusters))
+}
+
+func TestGetMaxScoreClusterID(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	s := &Scheduler{tieBreaker: &staticTieBreaker{preference: map[string]int{"cluster-03": 0}}}
+	clusterID, tiedClusterIDs := s.getMaxScoreClusterID([]clusterWithScore{
+		{clusterID: "cluster-01", score: 10},
+		{clusterID: "cluster-02", score: 20},
+		{clusterID: "cluster-03", score: 20},
+		{clusterID: "cluster-04", score: 5},
+	})
+	g.Expect(clusterID).To(gomega.Equal("cluster-03"))
+	g.Expect(tiedClusterIDs).To(gomega.Equal([]string{"cluster-02", "cluster-03"}))
+
+	clusterID, tiedClusterIDs = s.getMaxScoreClusterID([]clusterWithScore{{clusterID: "cluster-01", score: 10}})
+	g.Expect(clusterID).To(gomega.Equal("cluster-01"))
+	g.Expect(tiedClusterIDs).To(gomega.Equal([]string{"cluster-01"}))
+}
+
+func TestGetClusterWithScores(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeScore := plugin.NewFakeScorePlugin(ctrl)
+	fakeScore.EXPECT().Name().Return("fakeScore").AnyTimes()
+	fakeScore.EXPECT().Score(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(10)).AnyTimes()
+	fakeScoreAnother := plugin.NewFakeScorePlugin(ctrl)
+	fakeScoreAnother.EXPECT().Name().Return("fakeScoreAnother").AnyTimes()
+	fakeScoreAnother.EXPECT().Score(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(200)).AnyTimes()
+
+	tests := []struct {
+		name         string
+		scoreWeights map[string]int64
+		expScore     int64
+	}{
+		{
+			name:     "default weight",
+			expScore: 55,
+		},
+		{
+			name:         "weighted",
+			scoreWeights: map[string]int64{"fakeScore": 3},
+			expScore:     32,
+		},
+		{
+			name:         "zero weight",
+			scoreWeights: map[string]int64{"fakeScoreAnother": 0},
+			expScore:     10,
+		},
+		{
+			name:         "all zero weight",
+			scoreWeights: map[string]int64{"fakeScore": 0, "fakeScoreAnother": 0},
+			expScore:     plugin.MaxScore,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Scheduler{plugins: pluginsGroup{
+				scores:       []plugin.ScorePlugin{fakeScore, fakeScoreAnother},
+				scoreWeights: test.scoreWeights,
+			}}
+			res := s.getClusterWithScores(&schemodels.TaskInfo{}, []*schemodels.ClusterInfo{{ID: "cluster-01"}}, context.Background(), map[string]interface{}{})
+			g.Expect(res).To(gomega.Equal([]clusterWithScore{{clusterID: "cluster-01", score: test.expScore}}))
+		})
+	}
+}
+
+func TestExtractPluginConfig(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	opts := &Options{
+		PluginConfigs: map[string]interface{}{"cost": map[string]interface{}{"key": "value"}},
+		ScoreWeights:  map[string]int{"cost": 2},
+	}
+	g.Expect(extractPluginConfig(opts, cost.Name)).To(gomega.Equal(map[string]interface{}{"key": "value"}))
+	g.Expect(extractPluginConfig(opts, clustercapacity.Name)).To(gomega.BeNil())
+	g.Expect(extractScoreWeight(opts, cost.Name)).To(gomega.Equal(int64(2)))
+	g.Expect(extractScoreWeight(opts, clustercapacity.Name)).To(gomega.Equal(int64(1)))
+}
+
+func TestCheckDeadlines(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	now := fakeClock.Now()
+	runtimeEstimator := cache.NewRuntimeEstimator()
+	longTask := &schemodels.TaskInfo{ID: "task-long", Name: "long", Deadline: now.Add(time.Hour)}
+	for i := 0; i < 3; i++ {
+		runtimeEstimator.Observe(longTask, time.Minute*40)
+	}
+	s := &Scheduler{
+		cache:              &cache.Cache{RuntimeEstimator: runtimeEstimator},
+		clock:              fakeClock,
+		deadlineRiskWindow: time.Minute * 30,
+	}
+	s.checkDeadlines([]*schemodels.TaskInfo{
+		{ID: "task-no-deadline"},
+		{ID: "task-far", Deadline: now.Add(time.Hour)},
+		{ID: "task-close", Deadline: now.Add(time.Minute * 10)},
+		{ID: "task-missed", Deadline: now.Add(-time.Minute)},
+		longTask,
+	})
+	g.Expect(testutil.ToFloat64(metrics.DeadlineAtRiskTasks)).To(gomega.Equal(float64(3)))
+
+	// task-far gets close as time goes by
+	fakeClock.Step(time.Minute * 40)
+	s.checkDeadlines([]*schemodels.TaskInfo{{ID: "task-far", Deadline: now.Add(time.Hour)}})
+	g.Expect(testutil.ToFloat64(metrics.DeadlineAtRiskTasks)).To(gomega.Equal(float64(1)))
+
+	s.checkDeadlines(nil)
+	g.Expect(testutil.ToFloat64(metrics.DeadlineAtRiskTasks)).To(gomega.Equal(float64(0)))
+}
+
+func TestRecordExtraPriorityAffectedTasks(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	now := time.Now()
+	s := &Scheduler{cache: &cache.Cache{Snapshot: cache.NewSnapshot(now, nil, nil, []*schemodels.ExtraPriorityInfo{
+		{AccountID: "account-01", ExtraPriorityValue: 10, Reason: "vip"},
+		{AccountID: "account-01", UserID: "user-01", ExtraPriorityValue: 10, Reason: "vip"},
+		{RunID: "run-01", ExtraPriorityValue: 10},
+		{RunID: "run-02", ExtraPriorityValue: 10, ExpireTime: now.Add(-time.Minute)},
+	})}}
+	s.recordExtraPriorityAffectedTasks([]*schemodels.TaskInfo{
+		{ID: "task-01", BioosInfo: &schemodels.BioosInfo{AccountID: "account-01", RunID: "run-02"}},
+		{ID: "task-02", BioosInfo: &schemodels.BioosInfo{AccountID: "account-01", UserID: "user-01", RunID: "run-03"}},
+		{ID: "task-03", BioosInfo: &schemodels.BioosInfo{AccountID: "account-02", RunID: "run-02"}},
+	})
+	g.Expect(testutil.ToFloat64(metrics.ExtraPriorityAffectedTasks.WithLabelValues("account-01"))).To(gomega.Equal(float64(3)))
+	g.Expect(testutil.ToFloat64(metrics.ExtraPriorityAffectedTasks.WithLabelValues(""))).To(gomega.Equal(float64(0)))
+	// expired one is not reported, WithLabelValues above creates 2 series
+	g.Expect(testutil.CollectAndCount(metrics.ExtraPriorityAffectedTasks)).To(gomega.Equal(2))
+}
+
+func TestCommitAssignments(t *testing.T) {
+	g := gomega.NewWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tasks := []*schemodels.TaskInfo{
+		{ID: "task-ok", State: consts.TaskQueued},
+		{ID: "task-conflict", State: consts.TaskQueued},
+		{ID: "task-bad-request", State: consts.TaskQueued},
+		{ID: "task-rejected", State: consts.TaskQueued},
+		{ID: "task-error", State: consts.TaskQueued},
+	}
+	assignments := make([]*assignment, 0, len(tasks))
+	updates := make([]*cache.TaskUpdate, 0, len(tasks))
+	for _, task := range tasks {
+		assignments = append(assignments, &assignment{task: task, clusterID: "cluster-01"})
+		updates = append(updates, &cache.TaskUpdate{ID: task.ID, ClusterID: utils.Point("cluster-01")})
+	}
+
+	fakeTaskCache := fake.NewFakeTaskCache(ctrl)
+	fakeTaskCache.EXPECT().BatchUpdateTasks(gomock.Any(), updates).Return(map[string]error{
+		"task-conflict":    &vetesclient.APIError{StatusCode: http.StatusConflict},
+		"task-bad-request": &vetesclient.APIError{StatusCode: http.StatusBadRequest, Body: "cannot assign Running task to cluster"},
+		"task-rejected":    &vetesclient.APIError{StatusCode: http.StatusBadRequest, Body: "invalid resources of task"},
+		"task-error":       &vetesclient.APIError{StatusCode: http.StatusInternalServerError},
+	})
+	// only the identified permanent rejection fails the task
+	fakeTaskCache.EXPECT().UpdateTask(gomock.Any(), "task-rejected", utils.Point(consts.TaskSystemError), nil, gomock.Any()).
+		Return(nil)
+
+	s := &Scheduler{cache: &cache.Cache{TaskCache: fakeTaskCache}, permanentRejections: []string{"invalid resources"}}
+	res := s.commitAssignments(assignments)
+	g.Expect(res.scheduled).To(gomega.Equal([]*schemodels.TaskInfo{tasks[0]}))
+	g.Expect(res.errored).To(gomega.Equal([]*schemodels.TaskInfo{tasks[4]}))
+	g.Expect(res.rejected).To(gomega.Equal([]*schemodels.TaskInfo{tasks[2]}))
+	g.Expect(res.permanentlyRejected).To(gomega.Equal(1))
+	g.Expect(res.conflicts).To(gomega.Equal(1))
+	// only the server error is returned
+	g.Expect(res.err).To(gomega.MatchError(gomega.ContainSubstring("task task-error")))
+	g.Expect(res.err).NotTo(gomega.MatchError(gomega.ContainSubstring("task-bad-request")))
+	res = s.commitAssignments(nil)
+	g.Expect(res.scheduled).To(gomega.BeEmpty())
+	g.Expect(res.errored).To(gomega.BeEmpty())
+	g.Expect(res.err).NotTo(gomega.HaveOccurred())
+}
@@ -0,0 +1,197 @@
+package scheduler
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// tie break strategies
+const (
+	TieBreakRandom                = "Random"
+	TieBreakRoundRobin            = "RoundRobin"
+	TieBreakLeastRecentlyAssigned = "LeastRecentlyAssigned"
+	TieBreakStatic                = "Static"
+)
+
+// tieBreaker picks one cluster from clusters with the same max score.
+type tieBreaker interface {
+	name() string
+	// pick is called with at least one candidate. The picked one is taken into
+	// account by later picks of the same cycle, but only kept after record.
+	pick(candidates []string) string
+	// record keeps picks of committed assignments in order of picking, and drops
+	// other picks of the cycle. It is called once per cycle.
+	record(clusterIDs []string)
+}
+
+func newTieBreaker(opts *TieBreakOptions) (tieBreaker, error) {
+	switch opts.Strategy {
+	case "", TieBreakRandom:
+		seed := opts.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		return &randomTieBreaker{rand: rand.New(rand.NewSource(seed))}, nil
+	case TieBreakRoundRobin:
+		return &roundRobinTieBreaker{}, nil
+	case TieBreakLeastRecentlyAssigned:
+		return &leastRecentlyAssignedTieBreaker{lastAssigned: make(map[string]uint64)}, nil
+	case TieBreakStatic:
+		preference := make(map[string]int, len(opts.ClusterOrder))
+		for index, clusterID := range opts.ClusterOrder {
+			if _, ok := preference[clusterID]; !ok {
+				preference[clusterID] = index
+			}
+		}
+		return &staticTieBreaker{preference: preference}, nil
+	default:
+		return nil, fmt.Errorf("invalid tie break strategy: %s", opts.Strategy)
+	}
+}
+
+// randomTieBreaker picks randomly, a non-zero seed makes it reproducible.
+type randomTieBreaker struct {
+	mutex sync.Mutex
+	rand  *rand.Rand
+}
+
+func (r *randomTieBreaker) name() string {
+	return TieBreakRandom
+}
+
+func (r *randomTieBreaker) pick(candidates []string) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return candidates[r.rand.Intn(len(candidates))]
+}
+
+func (r *randomTieBreaker) record(_ []string) {}
+
+// roundRobinTieBreaker picks the first cluster after the last picked one in ID order.
+type roundRobinTieBreaker struct {
+	mutex sync.Mutex
+	// lastRecorded is the last committed pick, lastPicked includes picks of this cycle
+	lastRecorded string
+	lastPicked   string
+}
+
+func (r *roundRobinTieBreaker) name() string {
+	return TieBreakRoundRobin
+}
+
+func (r *roundRobinTieBreaker) pick(candidates []string) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	sorted := sortedCopy(candidates)
+	picked := sorted[0]
+	for _, clusterID := range sorted {
+		if clusterID > r.lastPicked {
+			picked = clusterID
+			break
+		}
+	}
+	r.lastPicked = picked
+	return picked
+}
+
+func (r *roundRobinTieBreaker) record(clusterIDs []string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(clusterIDs) > 0 {
+		r.lastRecorded = clusterIDs[len(clusterIDs)-1]
+	}
+	r.lastPicked = r.lastRecorded
+}
+
+// leastRecentlyAssignedTieBreaker picks the cluster which has not been assigned for the longest time.
+type leastRecentlyAssignedTieBreaker struct {
+	mutex sync.Mutex
+	// sequence is increased for every recorded pick, clusters never assigned have sequence 0
+	sequence     uint64
+	lastAssigned map[string]uint64
+	// cycleSequence and cyclePicked are the same for picks of this cycle, nil before the first one
+	cycleSequence uint64
+	cyclePicked   map[string]uint64
+}
+
+func (l *leastRecentlyAssignedTieBreaker) name() string {
+	return TieBreakLeastRecentlyAssigned
+}
+
+func (l *leastRecentlyAssignedTieBreaker) pick(candidates []string) string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.cyclePicked == nil {
+		l.cycleSequence = l.sequence
+		l.cyclePicked = make(map[string]uint64)
+	}
+	lastPicked := func(clusterID string) uint64 {
+		if sequence, ok := l.cyclePicked[clusterID]; ok {
+			return sequence
+		}
+		return l.lastAssigned[clusterID]
+	}
+	sorted := sortedCopy(candidates)
+	picked := sorted[0]
+	for _, clusterID := range sorted[1:] {
+		if lastPicked(clusterID) < lastPicked(picked) {
+			picked = clusterID
+		}
+	}
+	l.cycleSequence++
+	l.cyclePicked[picked] = l.cycleSequence
+	return picked
+}
+
+func (l *leastRecentlyAssignedTieBreaker) record(clusterIDs []string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, clusterID := range clusterIDs {
+		l.sequence++
+		l.lastAssigned[clusterID] = l.sequence
+	}
+	l.cyclePicked = nil
+}
+
+// staticTieBreaker picks by the configured cluster order, clusters not in the order come last in ID order.
+type staticTieBreaker struct {
+	preference map[string]int
+}
+
+func (s *staticTieBreaker) name() string {
+	return TieBreakStatic
+}
+
+func (s *staticTieBreaker) pick(candidates []string) string {
+	sorted := sortedCopy(candidates)
+	picked := sorted[0]
+	for _, clusterID := range sorted[1:] {
+		if s.less(clusterID, picked) {
+			picked = clusterID
+		}
+	}
+	return picked
+}
+
+func (s *staticTieBreaker) record(_ []string) {}
+
+func (s *staticTieBreaker) less(clusterI, clusterJ string) bool {
+	indexI, okI := s.preference[clusterI]
+	indexJ, okJ := s.preference[clusterJ]
+	if okI && okJ {
+		return indexI < indexJ
+	}
+	return okI
+}
+
+func sortedCopy(clusterIDs []string) []string {
+	res := make([]string, len(clusterIDs))
+	copy(res, clusterIDs)
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestNewTieBreaker(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	tests := []struct {
+		name    string
+		opts    *TieBreakOptions
+		expName string
+		expErr  bool
+	}{
+		{
+			name:    "default",
+			opts:    &TieBreakOptions{},
+			expName: TieBreakRandom,
+		},
+		{
+			name:    "round robin",
+			opts:    &TieBreakOptions{Strategy: TieBreakRoundRobin},
+			expName: TieBreakRoundRobin,
+		},
+		{
+			name:    "least recently assigned",
+			opts:    &TieBreakOptions{Strategy: TieBreakLeastRecentlyAssigned},
+			expName: TieBreakLeastRecentlyAssigned,
+		},
+		{
+			name:    "static",
+			opts:    &TieBreakOptions{Strategy: TieBreakStatic, ClusterOrder: []string{"cluster-01"}},
+			expName: TieBreakStatic,
+		},
+		{
+			name:   "invalid",
+			opts:   &TieBreakOptions{Strategy: "xxx"},
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tieBreaker, err := newTieBreaker(test.opts)
+			if test.expErr {
+				g.Expect(err).To(gomega.HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(tieBreaker.name()).To(gomega.Equal(test.expName))
+		})
+	}
+}
+
+func TestRandomTieBreaker(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	candidates := []string{"cluster-01", "cluster-02", "cluster-03"}
+	pickAll := func(seed int64) []string {
+		tieBreaker, err := newTieBreaker(&TieBreakOptions{Strategy: TieBreakRandom, Seed: seed})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		res := make([]string, 0, 10)
+		for i := 0; i < 10; i++ {
+			picked := tieBreaker.pick(candidates)
+			g.Expect(candidates).To(gomega.ContainElement(picked))
+			res = append(res, picked)
+		}
+		return res
+	}
+	g.Expect(pickAll(42)).To(gomega.Equal(pickAll(42)))
+}
+
+func TestRoundRobinTieBreaker(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	tieBreaker := &roundRobinTieBreaker{}
+	g.Expect(tieBreaker.pick([]string{"cluster-02", "cluster-01", "cluster-03"})).To(gomega.Equal("cluster-01"))
+	g.Expect(tieBreaker.pick([]string{"cluster-03", "cluster-02", "cluster-01"})).To(gomega.Equal("cluster-02"))
+	g.Expect(tieBreaker.pick([]string{"cluster-01", "cluster-02"})).To(gomega.Equal("cluster-01"))
+	g.Expect(tieBreaker.pick([]string{"cluster-01", "cluster-03"})).To(gomega.Equal("cluster-03"))
+	g.Expect(tieBreaker.pick([]string{"cluster-01", "cluster-02", "cluster-03"})).To(gomega.Equal("cluster-01"))
+
+	// only committed picks are kept
+	tieBreaker.record([]string{"cluster-03"})
+	g.Expect(tieBreaker.pick([]string{"cluster-01", "cluster-02", "cluster-03"})).To(gomega.Equal("cluster-01"))
+	g.Expect(tieBreaker.pick([]string{"cluster-01", "cluster-02", "cluster-03"})).To(gomega.Equal("cluster-02"))
+	tieBreaker.record(nil)
+	g.Expect(tieBreaker.pick([]string{"cluster-01", "cluster-02", "cluster-03"})).To(gomega.Equal("cluster-01"))
+}
+
+func TestLeastRecentlyAssignedTieBreaker(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	tieBreaker := &leastRecentlyAssignedTieBreaker{lastAssigned: make(map[string]uint64)}
+	g.Expect(tieBreaker.pick([]string{"cluster-02"})).To(gomega.Equal("cluster-02"))
+	g.Expect(tieBreaker.pick([]string{"cluster-02", "cluster-01"})).To(gomega.Equal("cluster-01"))
+	g.Expect(tieBreaker.pick([]string{"cluster-03", "cluster-02", "cluster-01"})).To(gomega.Equal("cluster-03"))
+	g.Expect(tieBreaker.pick([]string{"cluster-03", "cluster-02", "cluster-01"})).To(gomega.Equal("cluster-02"))
+	g.Expect(tieBreaker.pick([]string{"cluster-03", "cluster-01"})).To(gomega.Equal("cluster-01"))
+
+	// only committed picks are kept
+	tieBreaker.record([]string{"cluster-02", "cluster-01"})
+	g.Expect(tieBreaker.pick([]string{"cluster-03", "cluster-02", "cluster-01"})).To(gomega.Equal("cluster-03"))
+	tieBreaker.record(nil)
+	g.Expect(tieBreaker.pick([]string{"cluster-03", "cluster-02", "cluster-01"})).To(gomega.Equal("cluster-03"))
+	tieBreaker.record([]string{"cluster-03"})
+	g.Expect(tieBreaker.pick([]string{"cluster-03", "cluster-02", "cluster-01"})).To(gomega.Equal("cluster-02"))
+}
+
+func TestStaticTieBreaker(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	tieBreaker, err := newTieBreaker(&TieBreakOptions{Strategy: TieBreakStatic, ClusterOrder: []string{"cluster-03", "cluster-01"}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(tieBreaker.pick([]string{"cluster-01", "cluster-02", "cluster-03"})).To(gomega.Equal("cluster-03"))
+	g.Expect(tieBreaker.pick([]string{"cluster-01", "cluster-02"})).To(gomega.Equal("cluster-01"))
+	g.Expect(tieBreaker.pick([]string{"cluster-04", "cluster-02"})).To(gomega.Equal("cluster-02"))
+}
@@ -10,7 +10,9 @@ import (
 type Options struct {
 	Port        uint16 `mapstructure:"port"`
 	HealthzPath string `mapstructure:"healthzPath"`
+	ReadyzPath  string `mapstructure:"readyzPath"`
 	MetricsPath string `mapstructure:"metricsPath"`
+	DebugPath   string `mapstructure:"debugPath"`
 }
 
 // NewOptions ...
@@ -18,7 +20,9 @@ func NewOptions() *Options {
 	return &Options{
 		Port:        8080,
 		HealthzPath: "/healthz",
+		ReadyzPath:  "/readyz",
 		MetricsPath: "/metrics",
+		DebugPath:   "/debug",
 	}
 }
 
@@ -27,11 +31,18 @@ func (o *Options) Validate() error {
 	if o.HealthzPath == "" {
 		return fmt.Errorf("healthz path cannot be empty")
 	}
+	if o.ReadyzPath == "" {
+		return fmt.Errorf("readyz path cannot be empty")
+	}
 	if o.MetricsPath == "" {
 		return fmt.Errorf("metrics path cannot be empty")
 	}
-	if o.HealthzPath == o.MetricsPath {
-		return fmt.Errorf("healthz and metrics path cannot be the same")
+	if o.DebugPath == "" {
+		return fmt.Errorf("debug path cannot be empty")
+	}
+	paths := map[string]struct{}{o.HealthzPath: {}, o.ReadyzPath: {}, o.MetricsPath: {}, o.DebugPath: {}}
+	if len(paths) < 4 {
+		return fmt.Errorf("healthz, readyz, metrics and debug path cannot be the same")
 	}
 	return nil
 }
@@ -40,5 +51,7 @@ func (o *Options) Validate() error {
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.Uint16Var(&o.Port, "http-port", o.Port, "http port to listen on")
 	fs.StringVar(&o.HealthzPath, "http-healthz-path", o.HealthzPath, "http path to healthz")
+	fs.StringVar(&o.ReadyzPath, "http-readyz-path", o.ReadyzPath, "http path to readyz, which also fails if caches are stale")
 	fs.StringVar(&o.MetricsPath, "http-metrics-path", o.MetricsPath, "http path to metrics")
+	fs.StringVar(&o.DebugPath, "http-debug-path", o.DebugPath, "http path prefix of debug handlers")
 }
@@ -3,17 +3,23 @@ package server
 import (
 	"fmt"
 	"net/http"
+	"path"
 
 	applog "github.com/GBA-BI/tes-scheduler/pkg/log"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/GBA-BI/tes-scheduler/pkg/debug"
 	"github.com/GBA-BI/tes-scheduler/pkg/healthz"
 )
 
 // Run ...
 func Run(opts *Options) {
 	http.HandleFunc(opts.HealthzPath, healthz.Handler)
+	http.HandleFunc(opts.ReadyzPath, healthz.ReadinessHandler)
 	http.Handle(opts.MetricsPath, promhttp.Handler())
+	for name, handler := range debug.Handlers() {
+		http.Handle(path.Join(opts.DebugPath, name), handler)
+	}
 	if err := http.ListenAndServe(fmt.Sprintf(":%d", opts.Port), nil); err != nil {
 		applog.Fatalw("Failed to start HTTP server", "err", err)
 	}
@@ -0,0 +1,333 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+// client is an in-memory vetes-api in simulated time. Tasks of trace arrive at
+// their arrival time, start at once when assigned to a cluster, and complete after
+// their duration.
+type client struct {
+	mutex sync.Mutex
+	now   time.Time
+	// lastChange is the simulated time tasks are changed last time
+	lastChange time.Time
+
+	trace []*TraceTask
+	// arrived is the number of arrived tasks of trace
+	arrived int
+	tasks   map[string]*simTask
+
+	clusters        models.ListClustersResponse
+	quotas          models.ListQuotasResponse
+	extraPriorities models.ListExtraPriorityResponse
+}
+
+var _ vetesclient.Client = (*client)(nil)
+
+// simTask is an arrived task
+type simTask struct {
+	*models.Task
+	trace    *TraceTask
+	attempts []*attempt
+	endTime  time.Time
+}
+
+// attempt is a run of task on a cluster, endTime is zero if it is running
+type attempt struct {
+	clusterID string
+	startTime time.Time
+	endTime   time.Time
+}
+
+// running returns the running attempt, nil if none
+func (t *simTask) running() *attempt {
+	if len(t.attempts) == 0 || !t.attempts[len(t.attempts)-1].endTime.IsZero() {
+		return nil
+	}
+	return t.attempts[len(t.attempts)-1]
+}
+
+// runningAt returns whether an attempt of task is running on the cluster at time at
+func (t *simTask) runningAt(clusterID string, at time.Time) bool {
+	for _, a := range t.attempts {
+		if a.clusterID == clusterID && !a.startTime.After(at) && (a.endTime.IsZero() || a.endTime.After(at)) {
+			return true
+		}
+	}
+	return false
+}
+
+func newClient(trace []*TraceTask, clusters models.ListClustersResponse, quotas models.ListQuotasResponse,
+	extraPriorities models.ListExtraPriorityResponse) *client {
+	return &client{
+		now:             trace[0].ArrivalTime,
+		trace:           trace,
+		tasks:           make(map[string]*simTask, len(trace)),
+		clusters:        clusters,
+		quotas:          quotas,
+		extraPriorities: extraPriorities,
+	}
+}
+
+// advance moves the simulated time to now, tasks arrive and complete meanwhile
+func (c *client) advance(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = now
+	for ; c.arrived < len(c.trace) && !c.trace[c.arrived].ArrivalTime.After(now); c.arrived++ {
+		trace := c.trace[c.arrived]
+		task := &models.Task{
+			ID:            trace.ID,
+			Name:          trace.Name,
+			State:         consts.TaskQueued,
+			Resources:     trace.Resources,
+			Tags:          trace.Tags,
+			CreationTime:  trace.ArrivalTime.UTC().Format(time.RFC3339),
+			BioosInfo:     trace.BioosInfo,
+			PriorityValue: trace.PriorityValue,
+		}
+		if trace.ExecutorImage != "" {
+			task.Executors = []*models.Executor{{Image: trace.ExecutorImage}}
+		}
+		c.tasks[trace.ID] = &simTask{Task: task, trace: trace}
+		c.lastChange = now
+	}
+	for _, task := range c.tasks {
+		if running := task.running(); running != nil && !running.startTime.Add(task.trace.duration).After(now) {
+			c.finish(task, consts.TaskComplete, running.startTime.Add(task.trace.duration))
+		}
+	}
+}
+
+// nextEvent returns the simulated time of next arrival or completion, zero if none
+func (c *client) nextEvent() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var res time.Time
+	if c.arrived < len(c.trace) {
+		res = c.trace[c.arrived].ArrivalTime
+	}
+	for _, task := range c.tasks {
+		running := task.running()
+		if running == nil {
+			continue
+		}
+		if endTime := running.startTime.Add(task.trace.duration); res.IsZero() || endTime.Before(res) {
+			res = endTime
+		}
+	}
+	return res
+}
+
+// counts returns the number of queued and running tasks, and tasks yet to arrive
+func (c *client) counts() (queued, running, pending int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, task := range c.tasks {
+		switch task.State {
+		case consts.TaskQueued:
+			queued++
+		case consts.TaskRunning:
+			running++
+		}
+	}
+	return queued, running, len(c.trace) - c.arrived
+}
+
+// lastChangeTime ...
+func (c *client) lastChangeTime() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lastChange
+}
+
+// finish must be called with mutex held
+func (c *client) finish(task *simTask, state string, endTime time.Time) {
+	task.State = state
+	task.endTime = endTime
+	endAttempt(task, endTime)
+	c.lastChange = c.now
+}
+
+// endAttempt ends the running attempt of task if any
+func endAttempt(task *simTask, endTime time.Time) {
+	running := task.running()
+	if running == nil {
+		return
+	}
+	running.endTime = endTime
+	end := endTime.UTC().Format(time.RFC3339)
+	for i := len(task.Logs) - 1; i >= 0; i-- {
+		if task.Logs[i].StartTime != nil {
+			task.Logs[i].EndTime = &end
+			return
+		}
+	}
+}
+
+// ListTasks lists all matched tasks in one page
+func (c *client) ListTasks(_ context.Context, req *models.ListTasksRequest) (*models.ListTasksResponse, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	states := make(map[string]struct{}, len(req.State))
+	for _, state := range req.State {
+		states[state] = struct{}{}
+	}
+	resp := &models.ListTasksResponse{Tasks: make([]*models.Task, 0)}
+	for _, task := range c.tasks {
+		if _, ok := states[task.State]; len(states) > 0 && !ok {
+			continue
+		}
+		if (req.ClusterID != "" && task.ClusterID != req.ClusterID) || (req.WithoutCluster && task.ClusterID != "") {
+			continue
+		}
+		resp.Tasks = append(resp.Tasks, copyTask(task.Task))
+	}
+	sort.Slice(resp.Tasks, func(i, j int) bool { return resp.Tasks[i].ID < resp.Tasks[j].ID })
+	return resp, nil
+}
+
+// GetTask ...
+func (c *client) GetTask(_ context.Context, req *models.GetTaskRequest) (*models.GetTaskResponse, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	task, ok := c.tasks[req.ID]
+	if !ok {
+		return nil, newAPIError(http.StatusNotFound, http.MethodGet, "/tasks/"+req.ID, "task not found")
+	}
+	return &models.GetTaskResponse{Task: copyTask(task.Task)}, nil
+}
+
+// UpdateTask starts the task at once if it is assigned to a cluster
+func (c *client) UpdateTask(_ context.Context, req *models.UpdateTaskRequest) (*models.UpdateTaskResponse, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	task, ok := c.tasks[req.ID]
+	if !ok {
+		return nil, newAPIError(http.StatusNotFound, http.MethodPatch, "/tasks/"+req.ID, "task not found")
+	}
+	if req.ExpectedState != nil && task.State != *req.ExpectedState {
+		return nil, newAPIError(http.StatusConflict, http.MethodPatch, "/tasks/"+req.ID, fmt.Sprintf("task is %s", task.State))
+	}
+	if isFinished(task.State) {
+		return nil, newAPIError(http.StatusConflict, http.MethodPatch, "/tasks/"+req.ID, fmt.Sprintf("task is already %s", task.State))
+	}
+
+	c.lastChange = c.now
+	if req.State != nil && isFinished(*req.State) {
+		c.finish(task, *req.State, c.now)
+		task.Logs = append(task.Logs, req.Logs...)
+		return &models.UpdateTaskResponse{}, nil
+	}
+	if req.State != nil && *req.State == consts.TaskQueued && task.State == consts.TaskRunning {
+		// rescheduled, the running attempt is lost
+		task.State = consts.TaskQueued
+		endAttempt(task, c.now)
+	}
+	task.Logs = append(task.Logs, req.Logs...)
+	if req.ClusterID != nil {
+		task.ClusterID = *req.ClusterID
+		if task.ClusterID != "" && task.State == consts.TaskQueued {
+			start := c.now.UTC().Format(time.RFC3339)
+			task.State = consts.TaskRunning
+			task.attempts = append(task.attempts, &attempt{clusterID: task.ClusterID, startTime: c.now})
+			task.Logs = append(task.Logs, &models.TaskLog{ClusterID: task.ClusterID, StartTime: &start})
+		}
+	}
+	return &models.UpdateTaskResponse{}, nil
+}
+
+// BatchUpdateTasks ...
+func (c *client) BatchUpdateTasks(ctx context.Context, req *models.BatchUpdateTasksRequest) (*models.BatchUpdateTasksResponse, error) {
+	resp := &models.BatchUpdateTasksResponse{Results: make([]*models.BatchUpdateTaskResult, 0, len(req.Tasks))}
+	for _, task := range req.Tasks {
+		result := &models.BatchUpdateTaskResult{ID: task.ID, StatusCode: http.StatusOK}
+		if _, err := c.UpdateTask(ctx, task); err != nil {
+			result.Error = err
+			result.StatusCode = err.(*vetesclient.APIError).StatusCode
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+// GatherTasksResources ...
+func (c *client) GatherTasksResources(_ context.Context, _ *models.GatherTasksResourcesRequest) (*models.GatherTasksResourcesResponse, error) {
+	return nil, fmt.Errorf("gather tasks resources is not supported in simulation")
+}
+
+// ListClusters regards all clusters as alive
+func (c *client) ListClusters(_ context.Context, _ *models.ListClustersRequest) (*models.ListClustersResponse, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now.UTC().Format(time.RFC3339)
+	resp := make(models.ListClustersResponse, 0, len(c.clusters))
+	for _, cluster := range c.clusters {
+		cluster := *cluster
+		cluster.HeartbeatTimestamp = now
+		resp = append(resp, &cluster)
+	}
+	return &resp, nil
+}
+
+// GetQuota responds 404 if there is no such quota
+func (c *client) GetQuota(_ context.Context, req *models.GetQuotaRequest) (*models.GetQuotaResponse, error) {
+	for _, quota := range c.quotas {
+		if quota.Global != req.Global || (!req.Global && (quota.AccountID != req.AccountID || quota.UserID != req.UserID)) {
+			continue
+		}
+		return &models.GetQuotaResponse{
+			Global:        quota.Global,
+			AccountID:     quota.AccountID,
+			UserID:        quota.UserID,
+			ResourceQuota: quota.ResourceQuota,
+		}, nil
+	}
+	return nil, newAPIError(http.StatusNotFound, http.MethodGet, "/quota", "quota not found")
+}
+
+// ListQuotas ...
+func (c *client) ListQuotas(_ context.Context, _ *models.ListQuotasRequest) (*models.ListQuotasResponse, error) {
+	resp := append(models.ListQuotasResponse{}, c.quotas...)
+	return &resp, nil
+}
+
+// ListExtraPriority ...
+func (c *client) ListExtraPriority(_ context.Context, _ *models.ListExtraPriorityRequest) (*models.ListExtraPriorityResponse, error) {
+	resp := append(models.ListExtraPriorityResponse{}, c.extraPriorities...)
+	return &resp, nil
+}
+
+func newAPIError(statusCode int, method, path, message string) *vetesclient.APIError {
+	return &vetesclient.APIError{StatusCode: statusCode, Body: message, Method: method, Path: path}
+}
+
+// copyTask copies task so that the simulation can go on while it is read
+func copyTask(task *models.Task) *models.Task {
+	res := *task
+	res.Logs = make([]*models.TaskLog, 0, len(task.Logs))
+	for _, taskLog := range task.Logs {
+		taskLog := *taskLog
+		res.Logs = append(res.Logs, &taskLog)
+	}
+	return &res
+}
+
+func isFinished(state string) bool {
+	switch state {
+	case consts.TaskComplete, consts.TaskSystemError, consts.TaskExecutorError, consts.TaskCanceled:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,61 @@
+package simulator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Options ...
+type Options struct {
+	// TraceFile is a JSONL file of task arrivals, see TraceTask.
+	TraceFile string `mapstructure:"traceFile"`
+	// ClustersFile, QuotasFile and ExtraPrioritiesFile are JSON or YAML files in the
+	// format of vetes-api responses. Quotas and extra priorities are optional.
+	ClustersFile        string `mapstructure:"clustersFile"`
+	QuotasFile          string `mapstructure:"quotasFile"`
+	ExtraPrioritiesFile string `mapstructure:"extraPrioritiesFile"`
+	// SampleInterval is the simulated interval of sampling cluster utilisation
+	SampleInterval time.Duration `mapstructure:"sampleInterval"`
+	// MaxDuration stops the simulation after this simulated duration since the
+	// first arrival, 0 means until all tasks are finished or unschedulable.
+	MaxDuration time.Duration `mapstructure:"maxDuration"`
+	// Output is the file the JSON report is written to, empty means stdout.
+	Output string `mapstructure:"output"`
+}
+
+// NewOptions ...
+func NewOptions() *Options {
+	return &Options{
+		SampleInterval: time.Hour,
+	}
+}
+
+// Validate ...
+func (o *Options) Validate() error {
+	if o.TraceFile == "" {
+		return fmt.Errorf("trace file must be set")
+	}
+	if o.ClustersFile == "" {
+		return fmt.Errorf("clusters file must be set")
+	}
+	if o.SampleInterval <= 0 {
+		return fmt.Errorf("sample interval must be positive")
+	}
+	if o.MaxDuration < 0 {
+		return fmt.Errorf("max duration cannot be negative")
+	}
+	return nil
+}
+
+// AddFlags ...
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.TraceFile, "simulator-trace-file", o.TraceFile, "JSONL file of task arrivals")
+	fs.StringVar(&o.ClustersFile, "simulator-clusters-file", o.ClustersFile, "JSON or YAML file of clusters in the format of vetes-api")
+	fs.StringVar(&o.QuotasFile, "simulator-quotas-file", o.QuotasFile, "JSON or YAML file of quotas in the format of vetes-api, optional")
+	fs.StringVar(&o.ExtraPrioritiesFile, "simulator-extra-priorities-file", o.ExtraPrioritiesFile, "JSON or YAML file of extra priorities in the format of vetes-api, optional")
+	fs.DurationVar(&o.SampleInterval, "simulator-sample-interval", o.SampleInterval, "simulated interval of sampling cluster utilisation")
+	fs.DurationVar(&o.MaxDuration, "simulator-max-duration", o.MaxDuration, "stop after this simulated duration since the first arrival, 0 means until all tasks are finished or unschedulable")
+	fs.StringVar(&o.Output, "simulator-output", o.Output, "file the JSON report is written to, empty means stdout")
+}
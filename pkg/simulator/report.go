@@ -0,0 +1,215 @@
+package simulator
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/resourcequota"
+)
+
+// Report is the result of a simulation
+type Report struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	// Makespan is from the first arrival to the last finish of tasks
+	MakespanSeconds float64 `json:"makespan_seconds"`
+
+	Tasks TaskCounts `json:"tasks"`
+	// Accounts is account ID -> report of tasks of the account
+	Accounts map[string]*AccountReport `json:"accounts"`
+	// Rejections is plugin name -> times the plugin rejects a task
+	Rejections map[string]int `json:"rejections"`
+	// Utilisation of clusters sampled every SampleInterval
+	Utilisation []*UtilisationSample `json:"utilisation"`
+}
+
+// TaskCounts counts tasks by final state
+type TaskCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+	Canceled  int `json:"canceled"`
+	// Running tasks are not finished when the simulation stops
+	Running int `json:"running"`
+	// Unscheduled tasks are still queued when the simulation stops
+	Unscheduled int `json:"unscheduled"`
+}
+
+// AccountReport ...
+type AccountReport struct {
+	Tasks int `json:"tasks"`
+	// Wait is from arrival to the first start of started tasks
+	Wait WaitStats `json:"wait"`
+	// QuotaRejections is times tasks are rejected by ResourceQuota
+	QuotaRejections int `json:"quota_rejections"`
+	// QuotaRejectedTasks is the number of tasks rejected by ResourceQuota at least once
+	QuotaRejectedTasks int `json:"quota_rejected_tasks"`
+}
+
+// WaitStats ...
+type WaitStats struct {
+	Count       int     `json:"count"`
+	MeanSeconds float64 `json:"mean_seconds"`
+	P50Seconds  float64 `json:"p50_seconds"`
+	P95Seconds  float64 `json:"p95_seconds"`
+	MaxSeconds  float64 `json:"max_seconds"`
+}
+
+// UtilisationSample is the usage of running tasks on a cluster at a time
+type UtilisationSample struct {
+	Time      time.Time `json:"time"`
+	ClusterID string    `json:"cluster_id"`
+	Tasks     int       `json:"tasks"`
+	CPUCores  int       `json:"cpu_cores"`
+	RamGB     float64   `json:"ram_gb"` // nolint
+	GPU       float64   `json:"gpu"`
+	// CPUUtilisation is CPUCores divided by capacity, omitted without capacity
+	CPUUtilisation *float64 `json:"cpu_utilisation,omitempty"`
+}
+
+func (s *Simulator) report(start, end time.Time) *Report {
+	s.client.mutex.Lock()
+	defer s.client.mutex.Unlock()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	report := &Report{
+		StartTime:  start,
+		EndTime:    end,
+		Tasks:      TaskCounts{Total: len(s.client.trace)},
+		Accounts:   make(map[string]*AccountReport),
+		Rejections: make(map[string]int),
+	}
+	waits := make(map[string][]float64)
+	var lastFinish time.Time
+	for _, trace := range s.client.trace {
+		accountID := ""
+		if trace.BioosInfo != nil {
+			accountID = trace.BioosInfo.AccountID
+		}
+		account, ok := report.Accounts[accountID]
+		if !ok {
+			account = &AccountReport{}
+			report.Accounts[accountID] = account
+		}
+		account.Tasks++
+
+		quotaRejected := false
+		for _, pluginNames := range s.rejections[trace.ID] {
+			for _, pluginName := range pluginNames {
+				report.Rejections[pluginName]++
+				if pluginName == resourcequota.Name {
+					account.QuotaRejections++
+					quotaRejected = true
+				}
+			}
+		}
+		if quotaRejected {
+			account.QuotaRejectedTasks++
+		}
+
+		task, ok := s.client.tasks[trace.ID]
+		if !ok {
+			continue // not arrived
+		}
+		if len(task.attempts) > 0 {
+			waits[accountID] = append(waits[accountID], task.attempts[0].startTime.Sub(trace.ArrivalTime).Seconds())
+		}
+		switch task.State {
+		case consts.TaskComplete:
+			report.Tasks.Completed++
+		case consts.TaskSystemError, consts.TaskExecutorError:
+			report.Tasks.Failed++
+		case consts.TaskCanceled:
+			report.Tasks.Canceled++
+		case consts.TaskRunning:
+			report.Tasks.Running++
+		case consts.TaskQueued:
+			report.Tasks.Unscheduled++
+		}
+		if isFinished(task.State) && task.endTime.After(lastFinish) {
+			lastFinish = task.endTime
+		}
+	}
+	report.Tasks.Unscheduled += len(s.client.trace) - s.client.arrived
+	if !lastFinish.IsZero() {
+		report.MakespanSeconds = lastFinish.Sub(start).Seconds()
+	}
+	for accountID, account := range report.Accounts {
+		account.Wait = newWaitStats(waits[accountID])
+	}
+	report.Utilisation = s.sampleUtilisation(start, end)
+	return report
+}
+
+// sampleUtilisation must be called with mutex of client held
+func (s *Simulator) sampleUtilisation(start, end time.Time) []*UtilisationSample {
+	res := make([]*UtilisationSample, 0)
+	for t := start; !t.After(end); t = t.Add(s.opts.SampleInterval) {
+		for _, cluster := range s.client.clusters {
+			sample := &UtilisationSample{Time: t, ClusterID: cluster.ID}
+			for _, task := range s.client.tasks {
+				if !task.runningAt(cluster.ID, t) || task.Resources == nil {
+					continue
+				}
+				sample.Tasks++
+				sample.CPUCores += task.Resources.CPUCores
+				sample.RamGB += task.Resources.RamGB
+				if task.Resources.GPU != nil {
+					sample.GPU += task.Resources.GPU.Count
+				}
+			}
+			if cluster.Capacity != nil && cluster.Capacity.CPUCores != nil && *cluster.Capacity.CPUCores > 0 {
+				utilisation := float64(sample.CPUCores) / float64(*cluster.Capacity.CPUCores)
+				sample.CPUUtilisation = &utilisation
+			}
+			res = append(res, sample)
+		}
+	}
+	return res
+}
+
+func newWaitStats(waits []float64) WaitStats {
+	if len(waits) == 0 {
+		return WaitStats{}
+	}
+	sort.Float64s(waits)
+	var sum float64
+	for _, wait := range waits {
+		sum += wait
+	}
+	return WaitStats{
+		Count:       len(waits),
+		MeanSeconds: sum / float64(len(waits)),
+		P50Seconds:  percentile(waits, 0.5),
+		P95Seconds:  percentile(waits, 0.95),
+		MaxSeconds:  waits[len(waits)-1],
+	}
+}
+
+// percentile of sorted values by nearest rank
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// WriteReport writes report as JSON to path, empty path means stdout
+func WriteReport(report *Report, path string) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	content = append(content, '\n')
+	if path == "" {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
@@ -0,0 +1,134 @@
+package simulator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	testingclock "k8s.io/utils/clock/testing"
+
+	applog "github.com/GBA-BI/tes-scheduler/pkg/log"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler"
+	schemodels "github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/runner"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+// Simulator replays a trace against the real scheduler in simulated time. The
+// scheduler is driven by a fake clock, its jobs are run one by one at the simulated
+// time they are due, and idle time without any arrival or completion is skipped.
+type Simulator struct {
+	opts   *Options
+	clock  *testingclock.FakeClock
+	client *client
+	jobs   *runner.Runner
+	// settle is how long the scheduler takes to react to a change
+	settle time.Duration
+
+	mutex sync.Mutex
+	// rejections is task ID -> names of plugins rejecting the task, one per attempt
+	rejections map[string][][]string
+}
+
+// New creates a Simulator with options of scheduler. Incremental sync and
+// checkpoint of cache are disabled in simulation.
+func New(opts *Options, schedulerOpts *scheduler.Options) (*Simulator, error) {
+	trace, err := loadTrace(opts.TraceFile)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := loadFile[models.ListClustersResponse](opts.ClustersFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("no cluster in clusters file %s", opts.ClustersFile)
+	}
+	quotas, err := loadFile[models.ListQuotasResponse](opts.QuotasFile)
+	if err != nil {
+		return nil, err
+	}
+	extraPriorities, err := loadFile[models.ListExtraPriorityResponse](opts.ExtraPrioritiesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	simOpts := *schedulerOpts
+	cacheOpts := *schedulerOpts.Cache
+	cacheOpts.IncrementalSync = false
+	cacheOpts.CheckpointPath = ""
+	simOpts.Cache = &cacheOpts
+
+	s := &Simulator{
+		opts:       opts,
+		clock:      testingclock.NewFakeClock(trace[0].ArrivalTime),
+		client:     newClient(trace, clusters, quotas, extraPriorities),
+		settle:     maxDuration(cacheOpts.SyncPeriod, simOpts.SchedulePeriod, simOpts.Controller.Period),
+		rejections: make(map[string][][]string),
+	}
+	s.client.advance(s.client.now)
+
+	sche, err := scheduler.NewScheduler(&simOpts, map[string]vetesclient.Client{"": s.client}, s.clock)
+	if err != nil {
+		return nil, err
+	}
+	sche.OnUnscheduled(s.recordRejection)
+	s.jobs = sche.Jobs()
+	return s, nil
+}
+
+func (s *Simulator) recordRejection(task *schemodels.TaskInfo, pluginNames []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.rejections[task.ID] = append(s.rejections[task.ID], pluginNames)
+}
+
+// Run runs the simulation until all tasks are finished, the remaining tasks stay
+// unschedulable with nothing running, or MaxDuration is reached.
+func (s *Simulator) Run() (*Report, error) {
+	start := s.client.now
+	now := start
+	for {
+		if s.opts.MaxDuration > 0 && now.Sub(start) > s.opts.MaxDuration {
+			applog.Warnw("simulation reaches max duration", "time", now)
+			break
+		}
+		s.clock.SetTime(now)
+		s.client.advance(now)
+		next := s.jobs.RunDue()
+		if next.IsZero() {
+			return nil, fmt.Errorf("no scheduler job is registered")
+		}
+
+		queued, running, pending := s.client.counts()
+		if queued == 0 && running == 0 && pending == 0 {
+			break
+		}
+		if now.Sub(s.client.lastChangeTime()) <= s.settle {
+			now = next
+			continue
+		}
+		nextEvent := s.client.nextEvent()
+		if nextEvent.IsZero() {
+			applog.Warnw("remaining tasks are unschedulable", "time", now, "count", queued)
+			break
+		}
+		// nothing happens until next arrival or completion
+		if nextEvent.After(next) {
+			next = nextEvent
+		}
+		now = next
+	}
+	return s.report(start, now), nil
+}
+
+func maxDuration(durations ...time.Duration) time.Duration {
+	var res time.Duration
+	for _, d := range durations {
+		if d > res {
+			res = d
+		}
+	}
+	return res
+}
@@ -0,0 +1,103 @@
+package simulator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler"
+	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/plugin/resourcequota"
+)
+
+const (
+	testTrace = `{"id": "task-a1", "arrival_time": "2024-05-01T00:00:00Z", "duration": "1h", "resources": {"cpu_cores": 4}, "bioos_info": {"account_id": "account-a"}}
+{"id": "task-a2", "arrival_time": "2024-05-01T00:00:00Z", "duration": "1h", "resources": {"cpu_cores": 4}, "bioos_info": {"account_id": "account-a"}}
+{"id": "task-b1", "arrival_time": "2024-05-01T00:10:00Z", "duration": "30m", "resources": {"cpu_cores": 2}, "bioos_info": {"account_id": "account-b"}}
+
+{"id": "task-b2", "arrival_time": "2024-05-01T00:10:00Z", "duration": "30m", "resources": {"cpu_cores": 2}, "bioos_info": {"account_id": "account-b"}}
+{"id": "task-c1", "arrival_time": "2024-05-01T03:00:00Z", "duration": "10m", "resources": {"cpu_cores": 64}, "bioos_info": {"account_id": "account-c"}}
+`
+	testClusters = `- id: cluster-01
+  capacity:
+    cpu_cores: 16
+`
+	testQuotas = `- account_id: account-b
+  resource_quota:
+    cpu_cores: 2
+`
+)
+
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSimulator(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	dir := t.TempDir()
+	opts := NewOptions()
+	opts.TraceFile = writeTestFile(t, dir, "trace.jsonl", testTrace)
+	opts.ClustersFile = writeTestFile(t, dir, "clusters.yaml", testClusters)
+	opts.QuotasFile = writeTestFile(t, dir, "quotas.yaml", testQuotas)
+	opts.SampleInterval = time.Minute * 30
+	g.Expect(opts.Validate()).To(gomega.Succeed())
+
+	sim, err := New(opts, scheduler.NewOptions())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	report, err := sim.Run()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	g.Expect(report.StartTime).To(gomega.BeTemporally("==", start))
+	g.Expect(report.Tasks).To(gomega.Equal(TaskCounts{Total: 5, Completed: 4, Unscheduled: 1}))
+	// task-b2 waits for task-b1 as the quota of account-b, and finishes last
+	g.Expect(report.MakespanSeconds).To(gomega.BeNumerically("~", (time.Minute * 70).Seconds(), 30))
+
+	g.Expect(report.Accounts).To(gomega.HaveLen(3))
+	accountA := report.Accounts["account-a"]
+	g.Expect(accountA.Wait.Count).To(gomega.Equal(2))
+	g.Expect(accountA.Wait.MaxSeconds).To(gomega.BeNumerically("<=", 30))
+	g.Expect(accountA.QuotaRejections).To(gomega.BeZero())
+	accountB := report.Accounts["account-b"]
+	g.Expect(accountB.Wait.Count).To(gomega.Equal(2))
+	g.Expect(accountB.Wait.MaxSeconds).To(gomega.BeNumerically("~", (time.Minute * 30).Seconds(), 30))
+	g.Expect(accountB.QuotaRejectedTasks).To(gomega.Equal(1))
+	g.Expect(accountB.QuotaRejections).To(gomega.BeNumerically(">", 0))
+	g.Expect(report.Rejections).To(gomega.HaveKeyWithValue(resourcequota.Name, accountB.QuotaRejections))
+	accountC := report.Accounts["account-c"]
+	g.Expect(accountC.Wait.Count).To(gomega.BeZero())
+
+	g.Expect(report.Utilisation).NotTo(gomega.BeEmpty())
+	first := report.Utilisation[0]
+	g.Expect(first.Time).To(gomega.BeTemporally("==", start))
+	g.Expect(first.CPUCores).To(gomega.Equal(8))
+	g.Expect(*first.CPUUtilisation).To(gomega.Equal(0.5))
+}
+
+func TestLoadTrace(t *testing.T) {
+	g := gomega.NewWithT(t)
+	dir := t.TempDir()
+
+	trace, err := loadTrace(writeTestFile(t, dir, "trace.jsonl", `{"arrival_time": "2024-05-01T01:00:00Z", "duration": "1h"}
+{"id": "task-01", "arrival_time": "2024-05-01T00:00:00Z", "duration": "30m"}
+`))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(trace).To(gomega.HaveLen(2))
+	g.Expect(trace[0].ID).To(gomega.Equal("task-01"))
+	g.Expect(trace[0].duration).To(gomega.Equal(time.Minute * 30))
+	g.Expect(trace[1].ID).To(gomega.Equal("task-00000001"))
+
+	_, err = loadTrace(writeTestFile(t, dir, "invalid.jsonl", `{"arrival_time": "2024-05-01T01:00:00Z", "duration": "0s"}`))
+	g.Expect(err).To(gomega.HaveOccurred())
+	_, err = loadTrace(writeTestFile(t, dir, "duplicated.jsonl", `{"id": "task-01", "arrival_time": "2024-05-01T01:00:00Z", "duration": "1h"}
+{"id": "task-01", "arrival_time": "2024-05-01T01:00:00Z", "duration": "1h"}
+`))
+	g.Expect(err).To(gomega.HaveOccurred())
+}
@@ -0,0 +1,89 @@
+package simulator
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+// TraceTask is a line of trace file, such as
+//
+//	{"id": "task-01", "arrival_time": "2024-05-01T00:00:00Z", "duration": "1h30m", "resources": {"cpu_cores": 4}, "bioos_info": {"account_id": "account-01"}}
+type TraceTask struct {
+	ID            string            `json:"id"`
+	Name          string            `json:"name,omitempty"`
+	ArrivalTime   time.Time         `json:"arrival_time"`
+	Duration      string            `json:"duration"`
+	Resources     *models.Resources `json:"resources,omitempty"`
+	BioosInfo     *models.BioosInfo `json:"bioos_info,omitempty"`
+	PriorityValue int               `json:"priority_value,omitempty"`
+	ExecutorImage string            `json:"executor_image,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+
+	duration time.Duration
+}
+
+// loadTrace returns tasks of trace file sorted by arrival time
+func loadTrace(path string) ([]*TraceTask, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	res := make([]*TraceTask, 0)
+	ids := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		task := new(TraceTask)
+		if err = json.Unmarshal(scanner.Bytes(), task); err != nil {
+			return nil, fmt.Errorf("invalid trace line %d: %w", line, err)
+		}
+		if task.duration, err = time.ParseDuration(task.Duration); err != nil || task.duration <= 0 {
+			return nil, fmt.Errorf("invalid duration %q of trace line %d", task.Duration, line)
+		}
+		if task.ID == "" {
+			task.ID = fmt.Sprintf("task-%08d", line)
+		}
+		if _, ok := ids[task.ID]; ok {
+			return nil, fmt.Errorf("duplicated task %s of trace line %d", task.ID, line)
+		}
+		ids[task.ID] = struct{}{}
+		res = append(res, task)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no task in trace file %s", path)
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].ArrivalTime.Before(res[j].ArrivalTime) })
+	return res, nil
+}
+
+// loadFile reads a JSON or YAML file, empty path means the zero value
+func loadFile[T any](path string) (T, error) {
+	var res T
+	if path == "" {
+		return res, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return res, err
+	}
+	if err = yaml.Unmarshal(content, &res); err != nil {
+		return res, fmt.Errorf("invalid file %s: %w", path, err)
+	}
+	return res, nil
+}
@@ -1,10 +1,9 @@
 package utils
 
 import (
-	"fmt"
-
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
 	"github.com/GBA-BI/tes-scheduler/pkg/scheduler/models"
 )
 
@@ -17,10 +16,10 @@ func ResourcesMeetLimits(resources *models.Resources, limits *models.Limits) err
 	var errs []error
 
 	if limits.CPUCores != nil && resources.CPUCores > *limits.CPUCores {
-		errs = append(errs, fmt.Errorf("CPUCore should no more than %d", *limits.CPUCores))
+		errs = append(errs, ReasonErrorf(consts.ReasonCPUCores, "CPUCore should no more than %d", *limits.CPUCores))
 	}
 	if limits.RamGB != nil && resources.RamGB > *limits.RamGB {
-		errs = append(errs, fmt.Errorf("RamGB should no more than %.2f", *limits.RamGB))
+		errs = append(errs, ReasonErrorf(consts.ReasonRamGB, "RamGB should no more than %.2f", *limits.RamGB))
 	}
 
 	if resources.GPU != nil && limits.GPULimit != nil {
@@ -33,15 +32,15 @@ func ResourcesMeetLimits(resources *models.Resources, limits *models.Limits) err
 				}
 			}
 			if !existProperGPUType {
-				errs = append(errs, fmt.Errorf("GPUCount should less than %+v", limits.GPULimit.GPU))
+				errs = append(errs, ReasonErrorf(consts.ReasonGPUCount, "GPUCount should less than %+v", limits.GPULimit.GPU))
 			}
 		} else {
 			gpuType := resources.GPU.Type
 			gpuCount, ok := limits.GPULimit.GPU[gpuType]
 			if !ok {
-				errs = append(errs, fmt.Errorf("no match GPUType %s", gpuType))
+				errs = append(errs, ReasonErrorf(consts.ReasonGPUType, "no match GPUType %s", gpuType))
 			} else if resources.GPU.Count > gpuCount {
-				errs = append(errs, fmt.Errorf("GPUCount of GPUType %s should no more than %.2f", gpuType, gpuCount))
+				errs = append(errs, ReasonErrorf(consts.ReasonGPUCount, "GPUCount of GPUType %s should no more than %.2f", gpuType, gpuCount))
 			}
 		}
 	}
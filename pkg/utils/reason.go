@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+)
+
+// ReasonError is an error with a reason of few values, such as a resource name
+type ReasonError struct {
+	Reason string
+	Err    error
+}
+
+// Error ...
+func (e *ReasonError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap ...
+func (e *ReasonError) Unwrap() error {
+	return e.Err
+}
+
+// ReasonErrorf formats an error with reason
+func ReasonErrorf(reason, format string, a ...interface{}) error {
+	return &ReasonError{Reason: reason, Err: fmt.Errorf(format, a...)}
+}
+
+// Reasons returns sorted distinct reasons of err and aggregated errors in it,
+// consts.ReasonOther for errors without reason.
+func Reasons(err error) []string {
+	set := make(map[string]struct{})
+	collectReasons(err, set)
+	res := make([]string, 0, len(set))
+	for reason := range set {
+		res = append(res, reason)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func collectReasons(err error, set map[string]struct{}) {
+	if err == nil {
+		return
+	}
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		for _, e := range agg.Errors() {
+			collectReasons(e, set)
+		}
+		return
+	}
+	var reasonErr *ReasonError
+	if errors.As(err, &reasonErr) {
+		set[reasonErr.Reason] = struct{}{}
+		return
+	}
+	set[consts.ReasonOther] = struct{}{}
+}
@@ -0,0 +1,131 @@
+package vetesclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// authTransport sets credentials to each request
+type authTransport struct {
+	base        http.RoundTripper
+	bearerToken string
+	tokenFile   *tokenFile
+	username    string
+	password    string
+}
+
+var _ http.RoundTripper = (*authTransport)(nil)
+
+// RoundTrip ...
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := t.bearerToken
+	if t.tokenFile != nil {
+		var err error
+		if token, err = t.tokenFile.get(); err != nil {
+			return nil, err
+		}
+	}
+	if token == "" && t.username == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	// RoundTrip should not modify the request
+	req = req.Clone(req.Context())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		req.SetBasicAuth(t.username, t.password)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// tokenFile re-reads the token when the file is modified, such as a rotated kubernetes secret
+type tokenFile struct {
+	path string
+
+	mutex   sync.Mutex
+	token   string
+	modTime time.Time
+}
+
+// get returns the last read token if the file is unreadable temporarily during rotation
+func (f *tokenFile) get() (string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if f.token != "" {
+			return f.token, nil
+		}
+		return "", fmt.Errorf("stat token file: %w", err)
+	}
+	if f.token != "" && info.ModTime().Equal(f.modTime) {
+		return f.token, nil
+	}
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		if f.token != "" {
+			return f.token, nil
+		}
+		return "", fmt.Errorf("read token file: %w", err)
+	}
+	f.token = strings.TrimSpace(string(content))
+	f.modTime = info.ModTime()
+	return f.token, nil
+}
+
+// newTransport returns the transport with TLS and auth configured by opts
+func newTransport(opts *Options) (http.RoundTripper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.TLSCertFile != "" || opts.TLSCAFile != "" {
+		tlsConfig, err := newTLSConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	res := &authTransport{
+		base:        transport,
+		bearerToken: opts.BearerToken,
+		username:    opts.Username,
+		password:    opts.Password,
+	}
+	if opts.BearerTokenFile != "" {
+		res.tokenFile = &tokenFile{path: opts.BearerTokenFile}
+		if _, err := res.tokenFile.get(); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func newTLSConfig(opts *Options) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client cert: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if opts.TLSCAFile != "" {
+		content, err := os.ReadFile(opts.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, fmt.Errorf("no valid certificate in ca file %s", opts.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
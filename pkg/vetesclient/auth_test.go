@@ -0,0 +1,156 @@
+package vetesclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"k8s.io/utils/clock"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+// newAuthServer returns a server which records Authorization header of last request
+func newAuthServer(lastAuth *string) *httptest.Server {
+	return httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*lastAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte("[]"))
+	}))
+}
+
+var _ = ginkgo.It("bearer token", func() {
+	var lastAuth string
+	server := newAuthServer(&lastAuth)
+	server.Start()
+	defer server.Close()
+
+	client, err := NewClient(&Options{Endpoint: server.URL, Timeout: 5 * time.Second, BearerToken: "token-01"}, clock.RealClock{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(lastAuth).To(gomega.Equal("Bearer token-01"))
+})
+
+var _ = ginkgo.It("bearer token file", func() {
+	var lastAuth string
+	server := newAuthServer(&lastAuth)
+	server.Start()
+	defer server.Close()
+
+	tokenPath := filepath.Join(ginkgo.GinkgoT().TempDir(), "token")
+	gomega.Expect(os.WriteFile(tokenPath, []byte("token-01\n"), 0600)).To(gomega.Succeed())
+	client, err := NewClient(&Options{Endpoint: server.URL, Timeout: 5 * time.Second, BearerTokenFile: tokenPath}, clock.RealClock{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(lastAuth).To(gomega.Equal("Bearer token-01"))
+
+	// rotated
+	gomega.Expect(os.WriteFile(tokenPath, []byte("token-02\n"), 0600)).To(gomega.Succeed())
+	gomega.Expect(os.Chtimes(tokenPath, time.Now(), time.Now().Add(time.Minute))).To(gomega.Succeed())
+	_, err = client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(lastAuth).To(gomega.Equal("Bearer token-02"))
+
+	// last token is used if the file is missing temporarily
+	gomega.Expect(os.Remove(tokenPath)).To(gomega.Succeed())
+	_, err = client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(lastAuth).To(gomega.Equal("Bearer token-02"))
+
+	_, err = NewClient(&Options{Endpoint: server.URL, BearerTokenFile: tokenPath}, clock.RealClock{})
+	gomega.Expect(err).To(gomega.HaveOccurred())
+})
+
+var _ = ginkgo.It("basic auth", func() {
+	var lastAuth string
+	server := newAuthServer(&lastAuth)
+	server.Start()
+	defer server.Close()
+
+	client, err := NewClient(&Options{Endpoint: server.URL, Timeout: 5 * time.Second, Username: "user", Password: "pass"}, clock.RealClock{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(lastAuth).To(gomega.Equal("Basic dXNlcjpwYXNz"))
+})
+
+var _ = ginkgo.It("mTLS", func() {
+	dir := ginkgo.GinkgoT().TempDir()
+	caCert, caKey := newTestCert(nil, nil, true)
+	serverCert, serverKey := newTestCert(caCert, caKey, false)
+	clientCert, clientKey := newTestCert(caCert, caKey, false)
+	caFile := writeTestPEM(dir, "ca.crt", "CERTIFICATE", caCert.Raw)
+	certFile := writeTestPEM(dir, "client.crt", "CERTIFICATE", clientCert.Raw)
+	keyBytes, _ := x509.MarshalECPrivateKey(clientKey)
+	keyFile := writeTestPEM(dir, "client.key", "EC PRIVATE KEY", keyBytes)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+	var lastAuth string
+	server := newAuthServer(&lastAuth)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	client, err := NewClient(&Options{Endpoint: server.URL, Timeout: 5 * time.Second, TLSCertFile: certFile, TLSKeyFile: keyFile, TLSCAFile: caFile}, clock.RealClock{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// without client cert
+	client, err = NewClient(&Options{Endpoint: server.URL, Timeout: 5 * time.Second, TLSCAFile: caFile}, clock.RealClock{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(err).To(gomega.HaveOccurred())
+})
+
+func newTestCert(parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:         isCA,
+
+		BasicConstraintsValid: true,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	cert, err := x509.ParseCertificate(raw)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	return cert, key
+}
+
+func writeTestPEM(dir, name, blockType string, content []byte) string {
+	path := filepath.Join(dir, name)
+	gomega.Expect(os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: content}), 0600)).To(gomega.Succeed())
+	return path
+}
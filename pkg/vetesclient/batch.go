@@ -0,0 +1,121 @@
+package vetesclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+// batchUpdater updates tasks by the batch endpoint of vetes-api, and falls back
+// to concurrent single updates once the endpoint is found not supported.
+type batchUpdater struct {
+	concurrency int
+	unsupported atomic.Bool
+}
+
+// batchUpdateTasksBody is the request body of batch endpoint
+type batchUpdateTasksBody struct {
+	Tasks []*batchUpdateTask `json:"tasks"`
+}
+
+// batchUpdateTask is UpdateTaskRequest with ID in body
+type batchUpdateTask struct {
+	ID string `json:"id"`
+	*models.UpdateTaskRequest
+}
+
+// BatchUpdateTasks ...
+func (i *impl) BatchUpdateTasks(ctx context.Context, req *models.BatchUpdateTasksRequest) (*models.BatchUpdateTasksResponse, error) {
+	if len(req.Tasks) == 0 {
+		return &models.BatchUpdateTasksResponse{}, nil
+	}
+	if !i.batch.unsupported.Load() {
+		resp, err := i.batchUpdateTasks(ctx, req)
+		if !errors.Is(err, ErrNotFound) && !isMethodNotAllowed(err) {
+			return resp, err
+		}
+		i.batch.unsupported.Store(true)
+	}
+	return updateTasksConcurrently(ctx, req, i.batch.concurrency, i.UpdateTask), nil
+}
+
+// batchUpdateTasks is not retried on 5xx responses as a POST, since some tasks may
+// have been updated, and replaying them fails with conflicts of ExpectedState.
+func (i *impl) batchUpdateTasks(ctx context.Context, req *models.BatchUpdateTasksRequest) (*models.BatchUpdateTasksResponse, error) {
+	body := &batchUpdateTasksBody{Tasks: make([]*batchUpdateTask, 0, len(req.Tasks))}
+	for _, task := range req.Tasks {
+		body.Tasks = append(body.Tasks, &batchUpdateTask{ID: task.ID, UpdateTaskRequest: task})
+	}
+	resp := new(models.BatchUpdateTasksResponse)
+	if err := i.doRequest(ctx, http.MethodPost, fmt.Sprintf("%s%s/tasks/batch_update", i.endpoint, otherAPIPrefix), body, resp); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*models.BatchUpdateTaskResult, len(resp.Results))
+	for _, result := range resp.Results {
+		if result.StatusCode < http.StatusOK || result.StatusCode >= http.StatusMultipleChoices {
+			result.Error = &APIError{
+				StatusCode: result.StatusCode,
+				Body:       result.Message,
+				Method:     http.MethodPatch,
+				Path:       fmt.Sprintf("%s/tasks/%s", otherAPIPrefix, result.ID),
+				Retryable:  isRetryableStatus(result.StatusCode),
+			}
+		}
+		results[result.ID] = result
+	}
+	// results in the order of request, missing ones are failed
+	resp.Results = make([]*models.BatchUpdateTaskResult, 0, len(req.Tasks))
+	for _, task := range req.Tasks {
+		result, ok := results[task.ID]
+		if !ok {
+			result = &models.BatchUpdateTaskResult{ID: task.ID, Error: fmt.Errorf("no result of task %s in batch update response", task.ID)}
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+// updateTasksConcurrently updates tasks one by one by updateTask, with at most concurrency in flight
+func updateTasksConcurrently(ctx context.Context, req *models.BatchUpdateTasksRequest, concurrency int,
+	updateTask func(ctx context.Context, req *models.UpdateTaskRequest) (*models.UpdateTaskResponse, error)) *models.BatchUpdateTasksResponse {
+	resp := &models.BatchUpdateTasksResponse{Results: make([]*models.BatchUpdateTaskResult, len(req.Tasks))}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for index, task := range req.Tasks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(index int, task *models.UpdateTaskRequest) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result := &models.BatchUpdateTaskResult{ID: task.ID, StatusCode: http.StatusOK}
+			if _, err := updateTask(ctx, task); err != nil {
+				result.Error = err
+				result.StatusCode = 0
+				var apiErr *APIError
+				if errors.As(err, &apiErr) {
+					result.StatusCode = apiErr.StatusCode
+					result.Message = apiErr.Body
+				}
+			}
+			resp.Results[index] = result
+		}(index, task)
+	}
+	wg.Wait()
+	return resp
+}
+
+func isMethodNotAllowed(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusMethodNotAllowed
+}
@@ -0,0 +1,125 @@
+package vetesclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"k8s.io/utils/clock"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	"github.com/GBA-BI/tes-scheduler/pkg/utils"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+func newBatchClient() Client {
+	cli, err := NewClient(&Options{
+		Endpoint:               fakeEndpoint,
+		Timeout:                5 * time.Second,
+		BatchUpdateConcurrency: 2,
+	}, clock.RealClock{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	httpmock.ActivateNonDefault(cli.(*impl).cli)
+	return cli
+}
+
+var batchUpdateReq = &models.BatchUpdateTasksRequest{Tasks: []*models.UpdateTaskRequest{
+	{ID: "task-01", ClusterID: utils.Point("cluster-01"), ExpectedState: utils.Point(consts.TaskQueued)},
+	{ID: "task-02", ClusterID: utils.Point("cluster-01"), ExpectedState: utils.Point(consts.TaskQueued)},
+	{ID: "task-03", ClusterID: utils.Point("cluster-01"), ExpectedState: utils.Point(consts.TaskQueued)},
+}}
+
+var _ = ginkgo.It("BatchUpdateTasks", func() {
+	client := newBatchClient()
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("%s%s/tasks/batch_update", fakeEndpoint, otherAPIPrefix),
+		func(req *http.Request) (*http.Response, error) {
+			body := new(batchUpdateTasksBody)
+			if err := json.NewDecoder(req.Body).Decode(body); err != nil {
+				return nil, err
+			}
+			gomega.Expect(body.Tasks).To(gomega.HaveLen(3))
+			gomega.Expect(body.Tasks[0].ID).To(gomega.Equal("task-01"))
+			gomega.Expect(body.Tasks[0].ExpectedState).To(gomega.Equal(utils.Point(consts.TaskQueued)))
+			// task-03 is missing in response
+			return httpmock.NewJsonResponse(http.StatusOK, &models.BatchUpdateTasksResponse{Results: []*models.BatchUpdateTaskResult{
+				{ID: "task-02", StatusCode: http.StatusConflict, Message: "state changed"},
+				{ID: "task-01", StatusCode: http.StatusOK},
+			}})
+		})
+	resp, err := client.BatchUpdateTasks(context.Background(), batchUpdateReq)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(resp.Results).To(gomega.HaveLen(3))
+	gomega.Expect(resp.Results[0].ID).To(gomega.Equal("task-01"))
+	gomega.Expect(resp.Results[0].Error).NotTo(gomega.HaveOccurred())
+	gomega.Expect(errors.Is(resp.Results[1].Error, ErrConflict)).To(gomega.BeTrue())
+	gomega.Expect(resp.Results[2].Error).To(gomega.HaveOccurred())
+})
+
+var _ = ginkgo.It("BatchUpdateTasks results outside 2xx", func() {
+	client := newBatchClient()
+	responder, _ := httpmock.NewJsonResponder(http.StatusOK, &models.BatchUpdateTasksResponse{Results: []*models.BatchUpdateTaskResult{
+		// missing status code
+		{ID: "task-01"},
+		{ID: "task-02", StatusCode: http.StatusFound},
+		{ID: "task-03", StatusCode: http.StatusNoContent},
+	}})
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("%s%s/tasks/batch_update", fakeEndpoint, otherAPIPrefix), responder)
+	resp, err := client.BatchUpdateTasks(context.Background(), batchUpdateReq)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(resp.Results).To(gomega.HaveLen(3))
+	gomega.Expect(resp.Results[0].Error).To(gomega.HaveOccurred())
+	gomega.Expect(resp.Results[1].Error).To(gomega.HaveOccurred())
+	gomega.Expect(resp.Results[2].Error).NotTo(gomega.HaveOccurred())
+})
+
+var _ = ginkgo.It("BatchUpdateTasks fallback", func() {
+	client := newBatchClient()
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("%s%s/tasks/batch_update", fakeEndpoint, otherAPIPrefix),
+		httpmock.NewStringResponder(http.StatusNotFound, "404 page not found"))
+	httpmock.RegisterResponder(http.MethodPatch, fmt.Sprintf("%s%s/tasks/task-01", fakeEndpoint, otherAPIPrefix),
+		httpmock.NewStringResponder(http.StatusOK, "{}"))
+	httpmock.RegisterResponder(http.MethodPatch, fmt.Sprintf("%s%s/tasks/task-02", fakeEndpoint, otherAPIPrefix),
+		httpmock.NewStringResponder(http.StatusConflict, "state changed"))
+	httpmock.RegisterResponder(http.MethodPatch, fmt.Sprintf("%s%s/tasks/task-03", fakeEndpoint, otherAPIPrefix),
+		httpmock.NewStringResponder(http.StatusBadRequest, "invalid cluster"))
+
+	resp, err := client.BatchUpdateTasks(context.Background(), batchUpdateReq)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(resp.Results).To(gomega.HaveLen(3))
+	gomega.Expect(resp.Results[0].Error).NotTo(gomega.HaveOccurred())
+	gomega.Expect(resp.Results[0].StatusCode).To(gomega.Equal(http.StatusOK))
+	gomega.Expect(errors.Is(resp.Results[1].Error, ErrConflict)).To(gomega.BeTrue())
+	gomega.Expect(resp.Results[2].StatusCode).To(gomega.Equal(http.StatusBadRequest))
+	gomega.Expect(resp.Results[2].Message).To(gomega.Equal("invalid cluster"))
+	gomega.Expect(httpmock.GetTotalCallCount()).To(gomega.Equal(4))
+
+	// bulk endpoint is not tried again
+	httpmock.ZeroCallCounters()
+	_, err = client.BatchUpdateTasks(context.Background(), batchUpdateReq)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(httpmock.GetTotalCallCount()).To(gomega.Equal(3))
+})
+
+var _ = ginkgo.It("BatchUpdateTasks error", func() {
+	client := newBatchClient()
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("%s%s/tasks/batch_update", fakeEndpoint, otherAPIPrefix),
+		httpmock.NewStringResponder(http.StatusUnauthorized, "unauthorized"))
+	_, err := client.BatchUpdateTasks(context.Background(), batchUpdateReq)
+	gomega.Expect(errors.Is(err, ErrUnauthorized)).To(gomega.BeTrue())
+})
+
+var _ = ginkgo.It("BatchUpdateTasks not retried on 5xx", func() {
+	client := newRetryClient(0)
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("%s%s/tasks/batch_update", fakeEndpoint, otherAPIPrefix),
+		httpmock.NewStringResponder(http.StatusBadGateway, "bad gateway").
+			Then(httpmock.NewStringResponder(http.StatusOK, `{"results":[]}`)))
+	_, err := client.BatchUpdateTasks(context.Background(), batchUpdateReq)
+	gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("502")))
+	gomega.Expect(httpmock.GetTotalCallCount()).To(gomega.Equal(1))
+})
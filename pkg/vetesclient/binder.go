@@ -0,0 +1,189 @@
+package vetesclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// binders
+const (
+	FileBinder    = "File"
+	WebhookBinder = "Webhook"
+)
+
+// webhookTimeout is the timeout of notifying BinderWebhookURL
+const webhookTimeout = 10 * time.Second
+
+// Binder records assignments of tasks to clusters in GA4GH compat mode, since a
+// plain GA4GH TES server has no cluster of task.
+type Binder interface {
+	// Bind assigns the task to the cluster, empty clusterID unassigns it.
+	Bind(ctx context.Context, taskID, clusterID string) error
+	// ClusterID returns the assigned cluster of task, empty if unassigned.
+	ClusterID(taskID string) string
+}
+
+// BinderFactory creates a Binder by compat options
+type BinderFactory func(opts *CompatOptions) (Binder, error)
+
+var binders = map[string]BinderFactory{
+	FileBinder:    newFileBinder,
+	WebhookBinder: newWebhookBinder,
+}
+
+// RegisterBinder registers a binder to be selected by CompatOptions.Binder.
+// It must be called before options are validated.
+func RegisterBinder(name string, factory BinderFactory) {
+	binders[name] = factory
+}
+
+// fileBinder keeps assignments in memory and persists them to file if set
+type fileBinder struct {
+	path string
+
+	mutex       sync.RWMutex
+	assignments map[string]string
+}
+
+var _ Binder = (*fileBinder)(nil)
+
+func newFileBinder(opts *CompatOptions) (Binder, error) {
+	return loadFileBinder(opts.BinderFile)
+}
+
+func loadFileBinder(path string) (*fileBinder, error) {
+	res := &fileBinder{path: path, assignments: make(map[string]string)}
+	if path == "" {
+		return res, nil
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(content, &res.assignments); err != nil {
+		return nil, fmt.Errorf("invalid binder file: %w", err)
+	}
+	return res, nil
+}
+
+// Bind ...
+func (b *fileBinder) Bind(_ context.Context, taskID, clusterID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	previous, ok := b.assignments[taskID]
+	if previous == clusterID {
+		return nil
+	}
+	if clusterID == "" {
+		delete(b.assignments, taskID)
+	} else {
+		b.assignments[taskID] = clusterID
+	}
+	if err := b.save(); err != nil {
+		// keep memory consistent with file
+		if ok {
+			b.assignments[taskID] = previous
+		} else {
+			delete(b.assignments, taskID)
+		}
+		return err
+	}
+	return nil
+}
+
+// ClusterID ...
+func (b *fileBinder) ClusterID(taskID string) string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.assignments[taskID]
+}
+
+// save must be called with mutex held. It writes to a temp file and renames it,
+// so the file is never partially written.
+func (b *fileBinder) save() error {
+	if b.path == "" {
+		return nil
+	}
+	content, err := json.Marshal(b.assignments)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(b.path), filepath.Base(b.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }() // no-op after rename
+	if _, err = tmpFile.Write(content); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), b.path)
+}
+
+// webhookBinder notifies the webhook of each assignment before recording it, so
+// an external dispatcher can submit the task to the backend of cluster.
+type webhookBinder struct {
+	*fileBinder
+	url string
+	cli *http.Client
+}
+
+var _ Binder = (*webhookBinder)(nil)
+
+// webhookRequest is the body POSTed to webhook, empty ClusterID means unassigned
+type webhookRequest struct {
+	TaskID    string `json:"task_id"`
+	ClusterID string `json:"cluster_id"`
+}
+
+func newWebhookBinder(opts *CompatOptions) (Binder, error) {
+	store, err := loadFileBinder(opts.BinderFile)
+	if err != nil {
+		return nil, err
+	}
+	return &webhookBinder{
+		fileBinder: store,
+		url:        opts.BinderWebhookURL,
+		cli:        &http.Client{Timeout: webhookTimeout},
+	}, nil
+}
+
+// Bind ...
+func (b *webhookBinder) Bind(ctx context.Context, taskID, clusterID string) error {
+	content, err := json.Marshal(&webhookRequest{TaskID: taskID, ClusterID: clusterID})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	request.Header.Add("Content-Type", "application/json")
+	response, err := b.cli.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode > 399 {
+		return newAPIError(response, body)
+	}
+	return b.fileBinder.Bind(ctx, taskID, clusterID)
+}
@@ -0,0 +1,64 @@
+package vetesclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.It("fileBinder", func() {
+	path := filepath.Join(ginkgo.GinkgoT().TempDir(), "assignments.json")
+	binder, err := newFileBinder(&CompatOptions{BinderFile: path})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(binder.Bind(context.Background(), "task-01", "cluster-01")).To(gomega.Succeed())
+	gomega.Expect(binder.Bind(context.Background(), "task-02", "cluster-02")).To(gomega.Succeed())
+	gomega.Expect(binder.Bind(context.Background(), "task-02", "")).To(gomega.Succeed())
+
+	// restored from file
+	binder, err = newFileBinder(&CompatOptions{BinderFile: path})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(binder.ClusterID("task-01")).To(gomega.Equal("cluster-01"))
+	gomega.Expect(binder.ClusterID("task-02")).To(gomega.BeEmpty())
+})
+
+var _ = ginkgo.It("fileBinder in memory", func() {
+	binder, err := newFileBinder(&CompatOptions{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(binder.Bind(context.Background(), "task-01", "cluster-01")).To(gomega.Succeed())
+	gomega.Expect(binder.ClusterID("task-01")).To(gomega.Equal("cluster-01"))
+})
+
+var _ = ginkgo.It("webhookBinder", func() {
+	binder, err := newWebhookBinder(&CompatOptions{BinderWebhookURL: "http://dispatcher:8080/bind"})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	httpmock.ActivateNonDefault(binder.(*webhookBinder).cli)
+
+	var received []*webhookRequest
+	httpmock.RegisterResponder(http.MethodPost, "http://dispatcher:8080/bind", func(req *http.Request) (*http.Response, error) {
+		body := new(webhookRequest)
+		if err := json.NewDecoder(req.Body).Decode(body); err != nil {
+			return nil, err
+		}
+		received = append(received, body)
+		if body.ClusterID == "cluster-invalid" {
+			return httpmock.NewStringResponse(http.StatusBadRequest, "unknown cluster"), nil
+		}
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	gomega.Expect(binder.Bind(context.Background(), "task-01", "cluster-01")).To(gomega.Succeed())
+	gomega.Expect(binder.ClusterID("task-01")).To(gomega.Equal("cluster-01"))
+	err = binder.Bind(context.Background(), "task-02", "cluster-invalid")
+	gomega.Expect(errors.Is(err, ErrBadRequest)).To(gomega.BeTrue())
+	gomega.Expect(binder.ClusterID("task-02")).To(gomega.BeEmpty())
+	gomega.Expect(received).To(gomega.Equal([]*webhookRequest{
+		{TaskID: "task-01", ClusterID: "cluster-01"},
+		{TaskID: "task-02", ClusterID: "cluster-invalid"},
+	}))
+})
@@ -0,0 +1,76 @@
+package vetesclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// ErrCircuitOpen is returned without requesting while vetes-api is regarded as down
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// circuitBreaker opens after threshold consecutive failures, and rejects requests
+// until cooldown passes. Then one request is let through as a probe, which closes
+// the breaker if succeeds, or opens it again.
+type circuitBreaker struct {
+	clock     clock.PassiveClock
+	threshold int
+	cooldown  time.Duration
+
+	mutex               sync.Mutex
+	consecutiveFailures int
+	openUntil           time.Time
+	probing             bool
+}
+
+// allow returns ErrCircuitOpen if the request should fail fast
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.consecutiveFailures < b.threshold {
+		return nil
+	}
+	if b.clock.Now().Before(b.openUntil) || b.probing {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+func (b *circuitBreaker) recordSuccess() {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.consecutiveFailures = 0
+	b.probing = false
+}
+
+func (b *circuitBreaker) recordFailure() {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.consecutiveFailures++
+	b.probing = false
+	if b.consecutiveFailures >= b.threshold {
+		b.openUntil = b.clock.Now().Add(b.cooldown)
+	}
+}
+
+// abort is called when the request is canceled by caller, which says nothing about vetes-api
+func (b *circuitBreaker) abort() {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.probing = false
+}
@@ -0,0 +1,37 @@
+package vetesclient
+
+import (
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+var _ = ginkgo.It("circuit breaker", func() {
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	breaker := &circuitBreaker{clock: fakeClock, threshold: 2, cooldown: time.Hour}
+	gomega.Expect(breaker.allow()).To(gomega.Succeed())
+	breaker.recordFailure()
+	gomega.Expect(breaker.allow()).To(gomega.Succeed())
+	breaker.recordFailure()
+	gomega.Expect(breaker.allow()).To(gomega.MatchError(ErrCircuitOpen))
+
+	fakeClock.Step(time.Minute)
+	gomega.Expect(breaker.allow()).To(gomega.MatchError(ErrCircuitOpen))
+
+	// only one probe after cooldown
+	fakeClock.Step(time.Hour)
+	gomega.Expect(breaker.allow()).To(gomega.Succeed())
+	gomega.Expect(breaker.allow()).To(gomega.MatchError(ErrCircuitOpen))
+	breaker.recordFailure()
+	gomega.Expect(breaker.allow()).To(gomega.MatchError(ErrCircuitOpen))
+
+	fakeClock.Step(time.Hour)
+	gomega.Expect(breaker.allow()).To(gomega.Succeed())
+	breaker.recordSuccess()
+	gomega.Expect(breaker.allow()).To(gomega.Succeed())
+
+	var nilBreaker *circuitBreaker
+	gomega.Expect(nilBreaker.allow()).To(gomega.Succeed())
+})
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +11,8 @@ import (
 	"reflect"
 	"strconv"
 
+	"k8s.io/utils/clock"
+
 	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
 )
 
@@ -20,34 +21,88 @@ const (
 	otherAPIPrefix = "/api/v1"
 )
 
-// ErrNotFound ...
-var ErrNotFound = errors.New("not found")
-
 // Client ...
 type Client interface {
 	ListTasks(ctx context.Context, req *models.ListTasksRequest) (*models.ListTasksResponse, error)
 	GetTask(ctx context.Context, req *models.GetTaskRequest) (*models.GetTaskResponse, error)
 	UpdateTask(ctx context.Context, req *models.UpdateTaskRequest) (*models.UpdateTaskResponse, error)
+	// BatchUpdateTasks uses the batch endpoint of vetes-api if supported, or updates tasks concurrently.
+	// Error of each task is in the result, and error is only returned if the whole batch fails.
+	BatchUpdateTasks(ctx context.Context, req *models.BatchUpdateTasksRequest) (*models.BatchUpdateTasksResponse, error)
 	GatherTasksResources(ctx context.Context, req *models.GatherTasksResourcesRequest) (*models.GatherTasksResourcesResponse, error)
 
 	ListClusters(ctx context.Context, req *models.ListClustersRequest) (*models.ListClustersResponse, error)
 	GetQuota(ctx context.Context, req *models.GetQuotaRequest) (*models.GetQuotaResponse, error)
+	ListQuotas(ctx context.Context, req *models.ListQuotasRequest) (*models.ListQuotasResponse, error)
 	ListExtraPriority(ctx context.Context, req *models.ListExtraPriorityRequest) (*models.ListExtraPriorityResponse, error)
 }
 
 type impl struct {
 	endpoint string
+	clock    clock.WithTicker
 	cli      *http.Client
+	retry    *retryPolicy
+	breaker  *circuitBreaker
+	batch    *batchUpdater
 }
 
 // NewClient ...
-func NewClient(opts *Options) Client {
-	cli := &http.Client{Timeout: opts.Timeout}
-	return &impl{endpoint: opts.Endpoint, cli: cli}
+func NewClient(opts *Options, clk clock.WithTicker) (Client, error) {
+	transport, err := newTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	cli := &http.Client{Timeout: opts.Timeout, Transport: transport}
+	res := &impl{
+		endpoint: opts.Endpoint,
+		clock:    clk,
+		cli:      cli,
+		retry: &retryPolicy{
+			clock:      clk,
+			maxRetries: opts.MaxRetries,
+			baseDelay:  opts.RetryBaseDelay,
+			maxDelay:   opts.RetryMaxDelay,
+		},
+		batch: &batchUpdater{concurrency: opts.BatchUpdateConcurrency},
+	}
+	if opts.CircuitBreakerThreshold > 0 {
+		res.breaker = &circuitBreaker{
+			clock:     clk,
+			threshold: opts.CircuitBreakerThreshold,
+			cooldown:  opts.CircuitBreakerCooldown,
+		}
+	}
+	if opts.Compat != nil && opts.Compat.Enable {
+		return newCompatClient(res, opts)
+	}
+	return res, nil
 }
 
 var _ Client = (*impl)(nil)
 
+// NewClients returns a client of each endpoint name in opts.Endpoints, or the
+// client of opts.Endpoint with empty name if not federated.
+func NewClients(opts *Options, clk clock.WithTicker) (map[string]Client, error) {
+	if len(opts.Endpoints) == 0 {
+		client, err := NewClient(opts, clk)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]Client{"": client}, nil
+	}
+	res := make(map[string]Client, len(opts.Endpoints))
+	for name, endpoint := range opts.Endpoints {
+		endpointOpts := *opts
+		endpointOpts.Endpoint = endpoint
+		client, err := NewClient(&endpointOpts, clk)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %s: %w", name, err)
+		}
+		res[name] = client
+	}
+	return res, nil
+}
+
 // ListTasks ...
 func (i *impl) ListTasks(ctx context.Context, req *models.ListTasksRequest) (*models.ListTasksResponse, error) {
 	resp := new(models.ListTasksResponse)
@@ -102,6 +157,15 @@ func (i *impl) GetQuota(ctx context.Context, req *models.GetQuotaRequest) (*mode
 	return resp, nil
 }
 
+// ListQuotas ...
+func (i *impl) ListQuotas(ctx context.Context, req *models.ListQuotasRequest) (*models.ListQuotasResponse, error) {
+	resp := new(models.ListQuotasResponse)
+	if err := i.doRequest(ctx, http.MethodGet, fmt.Sprintf("%s%s/quotas", i.endpoint, otherAPIPrefix), req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 // ListExtraPriority ...
 func (i *impl) ListExtraPriority(ctx context.Context, req *models.ListExtraPriorityRequest) (*models.ListExtraPriorityResponse, error) {
 	resp := new(models.ListExtraPriorityResponse)
@@ -112,44 +176,78 @@ func (i *impl) ListExtraPriority(ctx context.Context, req *models.ListExtraPrior
 }
 
 func (i *impl) doRequest(ctx context.Context, method, url string, req, resp interface{}) error {
-	request, err := http.NewRequestWithContext(ctx, method, url, nil)
+	query, err := parseQuery(req)
 	if err != nil {
 		return err
 	}
-	request.Header.Add("Accept", "application/json")
+	var content []byte
+	if method == http.MethodPatch || method == http.MethodPost || method == http.MethodPut {
+		if content, err = json.Marshal(req); err != nil {
+			return err
+		}
+	}
 
-	query, err := parseQuery(req)
+	for attempt := 0; ; attempt++ {
+		response, err := i.attempt(ctx, method, url, query, content)
+		if !i.retry.shouldRetry(attempt, method, response, err) {
+			if err != nil {
+				return err
+			}
+			return handleResponse(response, resp)
+		}
+		delay := i.retry.delay(attempt, response)
+		if response != nil {
+			_, _ = io.Copy(io.Discard, response.Body)
+			_ = response.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-i.clock.After(delay):
+		}
+	}
+}
+
+// attempt sends the request once, and records the result to circuit breaker
+func (i *impl) attempt(ctx context.Context, method, url string, query url.Values, content []byte) (*http.Response, error) {
+	if err := i.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
-		return err
+		i.breaker.abort()
+		return nil, err
 	}
+	request.Header.Add("Accept", "application/json")
 	mergeQuery(request, query)
-
-	if method == http.MethodPatch || method == http.MethodPost || method == http.MethodPut {
+	if content != nil {
 		request.Header.Add("Content-Type", "application/json")
-		content, err := json.Marshal(req)
-		if err != nil {
-			return err
-		}
 		request.Body = io.NopCloser(bytes.NewReader(content))
 	}
 
-	var response *http.Response
-	response, err = i.cli.Do(request)
-	if err != nil {
-		return err
+	response, err := i.cli.Do(request)
+	switch {
+	case err != nil && ctx.Err() != nil:
+		i.breaker.abort()
+	case err != nil || isRetryableStatus(response.StatusCode):
+		i.breaker.recordFailure()
+	default:
+		i.breaker.recordSuccess()
 	}
+	return response, err
+}
+
+func handleResponse(response *http.Response, resp interface{}) error {
 	defer response.Body.Close()
 
 	if response.StatusCode > 399 {
-		message, _ := io.ReadAll(response.Body)
-		if response.StatusCode == http.StatusNotFound {
-			return fmt.Errorf("%s: %w", message, ErrNotFound)
-		}
-		return fmt.Errorf("%d: %s", response.StatusCode, message)
+		body, _ := io.ReadAll(response.Body)
+		return newAPIError(response, body)
 	}
 
 	decoder := json.NewDecoder(response.Body)
-	if err = decoder.Decode(resp); err != nil {
+	if err := decoder.Decode(resp); err != nil {
 		return err
 	}
 	return nil
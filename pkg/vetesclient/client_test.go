@@ -2,6 +2,7 @@ package vetesclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/jarcoal/httpmock"
 	"github.com/onsi/ginkgo/v2"
 	"github.com/onsi/gomega"
+	"k8s.io/utils/clock"
 
 	"github.com/GBA-BI/tes-scheduler/pkg/consts"
 	"github.com/GBA-BI/tes-scheduler/pkg/utils"
@@ -22,10 +24,10 @@ func TestClient(t *testing.T) {
 }
 
 var fakeEndpoint = "http://vetes-api:8080"
-var fakeClient = NewClient(&Options{
+var fakeClient, _ = NewClient(&Options{
 	Endpoint: fakeEndpoint,
 	Timeout:  5 * time.Second,
-})
+}, clock.RealClock{})
 var fakeTaskID = "task-xxxx"
 
 var _ = ginkgo.BeforeSuite(func() {
@@ -85,6 +87,13 @@ var _ = ginkgo.It("UpdateTask", func() {
 	gomega.Expect(err).NotTo(gomega.HaveOccurred())
 })
 
+var _ = ginkgo.It("UpdateTask conflict", func() {
+	httpmock.RegisterResponder(http.MethodPatch, fmt.Sprintf("%s%s/tasks/%s", fakeEndpoint, otherAPIPrefix, fakeTaskID),
+		httpmock.NewStringResponder(http.StatusConflict, "state changed"))
+	_, err := fakeClient.UpdateTask(context.Background(), &models.UpdateTaskRequest{ID: fakeTaskID, ExpectedState: utils.Point(consts.TaskQueued)})
+	gomega.Expect(errors.Is(err, ErrConflict)).To(gomega.BeTrue())
+})
+
 var _ = ginkgo.It("GatherTasksResources", func() {
 	fakeResp := &models.GatherTasksResourcesResponse{
 		Count:    10,
@@ -157,6 +166,27 @@ var _ = ginkgo.It("GetQuota", func() {
 	gomega.Expect(resp).To(gomega.BeEquivalentTo(fakeResp))
 })
 
+var _ = ginkgo.It("ListQuotas", func() {
+	fakeResp := &models.ListQuotasResponse{{
+		Global: true,
+		ResourceQuota: &models.ResourceQuota{
+			Count: utils.Point(100),
+		},
+	}, {
+		AccountID: "account-01",
+		UserID:    "user-01",
+		ResourceQuota: &models.ResourceQuota{
+			CPUCores: utils.Point(10),
+			GPUQuota: &models.GPUQuota{GPU: map[string]float64{"type-01": 5}},
+		},
+	}}
+	responder, _ := httpmock.NewJsonResponder(200, fakeResp)
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/quotas", fakeEndpoint, otherAPIPrefix), responder)
+	resp, err := fakeClient.ListQuotas(context.Background(), &models.ListQuotasRequest{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(resp).To(gomega.BeEquivalentTo(fakeResp))
+})
+
 var _ = ginkgo.It("ListExtraPriority", func() {
 	fakeResp := &models.ListExtraPriorityResponse{{
 		AccountID:          "account-01",
@@ -176,3 +206,18 @@ var _ = ginkgo.It("ListExtraPriority", func() {
 	gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	gomega.Expect(resp).To(gomega.BeEquivalentTo(fakeResp))
 })
+
+var _ = ginkgo.It("NewClients", func() {
+	clients, err := NewClients(&Options{Endpoint: fakeEndpoint, BatchUpdateConcurrency: 1}, clock.RealClock{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(clients).To(gomega.HaveKey(""))
+
+	clients, err = NewClients(&Options{
+		Endpoint:               fakeEndpoint,
+		Endpoints:              map[string]string{"endpoint-a": "http://a", "endpoint-b": "http://b"},
+		BatchUpdateConcurrency: 1,
+	}, clock.RealClock{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(clients).To(gomega.HaveLen(2))
+	gomega.Expect(clients["endpoint-b"].(*impl).endpoint).To(gomega.Equal("http://b"))
+})
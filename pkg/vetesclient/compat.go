@@ -0,0 +1,366 @@
+package vetesclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	"github.com/GBA-BI/tes-scheduler/pkg/log"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+// compatClient talks to a plain GA4GH TES server. Clusters, quotas and extra
+// priorities are read from local files, and assignments are recorded by binder
+// instead of the Bio-OS-only PATCH endpoint.
+type compatClient struct {
+	ga4gh       *impl
+	binder      Binder
+	concurrency int
+
+	clusters        *configFile[models.ListClustersResponse]
+	quotas          *configFile[models.ListQuotasResponse]
+	extraPriorities *configFile[models.ListExtraPriorityResponse]
+
+	// finishedBound are IDs of finished tasks still bound, seen when reading
+	// tasks. They are unbound on the next update instead of on reads.
+	mutex         sync.Mutex
+	finishedBound map[string]struct{}
+}
+
+var _ Client = (*compatClient)(nil)
+
+// ga4ghListTasksRequest only has the query parameters of GA4GH TES
+type ga4ghListTasksRequest struct {
+	NamePrefix string `query:"name_prefix"`
+	State      string `query:"state"`
+	View       string `query:"view"`
+	PageSize   int    `query:"page_size"`
+	PageToken  string `query:"page_token"`
+}
+
+// ga4ghCancelTaskRequest ...
+type ga4ghCancelTaskRequest struct{}
+
+func newCompatClient(ga4gh *impl, opts *Options) (Client, error) {
+	factory, ok := binders[opts.Compat.Binder]
+	if !ok {
+		return nil, fmt.Errorf("invalid binder: %s", opts.Compat.Binder)
+	}
+	binder, err := factory(opts.Compat)
+	if err != nil {
+		return nil, err
+	}
+	return &compatClient{
+		ga4gh:           ga4gh,
+		binder:          binder,
+		concurrency:     opts.BatchUpdateConcurrency,
+		clusters:        &configFile[models.ListClustersResponse]{path: opts.Compat.ClustersFile},
+		quotas:          &configFile[models.ListQuotasResponse]{path: opts.Compat.QuotasFile},
+		extraPriorities: &configFile[models.ListExtraPriorityResponse]{path: opts.Compat.ExtraPrioritiesFile},
+		finishedBound:   make(map[string]struct{}),
+	}, nil
+}
+
+// ListTasks filters tasks by the Bio-OS-only parameters locally. ModifiedSince is
+// ignored, so delta sync lists all tasks.
+func (c *compatClient) ListTasks(ctx context.Context, req *models.ListTasksRequest) (*models.ListTasksResponse, error) {
+	ga4ghReq := &ga4ghListTasksRequest{
+		NamePrefix: req.NamePrefix,
+		View:       req.View,
+		PageSize:   req.PageSize,
+		PageToken:  req.PageToken,
+	}
+	var resp *models.ListTasksResponse
+	if len(req.State) <= 1 {
+		if len(req.State) == 1 {
+			ga4ghReq.State = req.State[0]
+		}
+		var err error
+		if resp, err = c.listGA4GHTasks(ctx, ga4ghReq); err != nil {
+			return nil, err
+		}
+	} else {
+		// GA4GH TES filters by only one state, so all pages of each state are
+		// listed, and page tokens of them cannot be returned
+		resp = &models.ListTasksResponse{}
+		for _, state := range req.State {
+			stateReq := *ga4ghReq
+			stateReq.State = state
+			stateReq.PageToken = ""
+			for {
+				stateResp, err := c.listGA4GHTasks(ctx, &stateReq)
+				if err != nil {
+					return nil, err
+				}
+				resp.Tasks = append(resp.Tasks, stateResp.Tasks...)
+				if stateResp.NextPageToken == "" {
+					break
+				}
+				stateReq.PageToken = stateResp.NextPageToken
+			}
+		}
+	}
+
+	tasks := make([]*models.Task, 0, len(resp.Tasks))
+	for _, task := range resp.Tasks {
+		c.fillTask(task)
+		if req.ClusterID != "" && task.ClusterID != req.ClusterID {
+			continue
+		}
+		if req.WithoutCluster && task.ClusterID != "" {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	resp.Tasks = tasks
+	return resp, nil
+}
+
+func (c *compatClient) listGA4GHTasks(ctx context.Context, req *ga4ghListTasksRequest) (*models.ListTasksResponse, error) {
+	resp := new(models.ListTasksResponse)
+	if err := c.ga4gh.doRequest(ctx, http.MethodGet, fmt.Sprintf("%s%s/tasks", c.ga4gh.endpoint, ga4ghAPIPrefix), req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetTask ...
+func (c *compatClient) GetTask(ctx context.Context, req *models.GetTaskRequest) (*models.GetTaskResponse, error) {
+	resp, err := c.ga4gh.GetTask(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.fillTask(resp.Task)
+	return resp, nil
+}
+
+// fillTask fills ClusterID from binder and BioosInfo from tags. Finished tasks
+// still bound are recorded to be unbound on the next update.
+func (c *compatClient) fillTask(task *models.Task) {
+	if task == nil {
+		return
+	}
+	task.ClusterID = c.binder.ClusterID(task.ID)
+	if task.ClusterID != "" && isFinishedState(task.State) {
+		c.mutex.Lock()
+		c.finishedBound[task.ID] = struct{}{}
+		c.mutex.Unlock()
+	}
+	if task.BioosInfo == nil && len(task.Tags) > 0 {
+		bioosInfo := &models.BioosInfo{
+			AccountID:    task.Tags["account_id"],
+			UserID:       task.Tags["user_id"],
+			SubmissionID: task.Tags["submission_id"],
+			RunID:        task.Tags["run_id"],
+		}
+		if *bioosInfo != (models.BioosInfo{}) {
+			task.BioosInfo = bioosInfo
+		}
+	}
+}
+
+// UpdateTask cancels the task if it is to be finished, and binds it otherwise.
+// ExpectedState is checked by getting the task first, which is not atomic.
+func (c *compatClient) UpdateTask(ctx context.Context, req *models.UpdateTaskRequest) (*models.UpdateTaskResponse, error) {
+	c.unbindFinishedTasks(ctx)
+	return c.updateTask(ctx, req)
+}
+
+func (c *compatClient) updateTask(ctx context.Context, req *models.UpdateTaskRequest) (*models.UpdateTaskResponse, error) {
+	if req.ExpectedState != nil {
+		resp, err := c.GetTask(ctx, &models.GetTaskRequest{ID: req.ID, View: consts.MinimalView})
+		if err != nil {
+			return nil, err
+		}
+		if resp.Task == nil || resp.Task.State != *req.ExpectedState {
+			return nil, &APIError{
+				StatusCode: http.StatusConflict,
+				Body:       fmt.Sprintf("task is not in state %s", *req.ExpectedState),
+				Method:     http.MethodPatch,
+				Path:       fmt.Sprintf("%s/tasks/%s", ga4ghAPIPrefix, req.ID),
+			}
+		}
+	}
+
+	clusterID := req.ClusterID
+	if req.State != nil {
+		switch {
+		case isFinishedState(*req.State):
+			if err := c.cancelTask(ctx, req); err != nil {
+				return nil, err
+			}
+			clusterID = new(string)
+		case *req.State != consts.TaskQueued:
+			return nil, &APIError{
+				StatusCode: http.StatusBadRequest,
+				Body:       fmt.Sprintf("cannot set state %s in GA4GH compat mode", *req.State),
+				Method:     http.MethodPatch,
+				Path:       fmt.Sprintf("%s/tasks/%s", ga4ghAPIPrefix, req.ID),
+			}
+		}
+	}
+	if clusterID != nil {
+		if err := c.binder.Bind(ctx, req.ID, *clusterID); err != nil {
+			return nil, err
+		}
+	}
+	return &models.UpdateTaskResponse{}, nil
+}
+
+// cancelTask is the only way to finish a task of GA4GH TES, logs of request are
+// not recorded by the server.
+func (c *compatClient) cancelTask(ctx context.Context, req *models.UpdateTaskRequest) error {
+	for _, taskLog := range req.Logs {
+		log.CtxInfow(ctx, "cancel task in GA4GH compat mode", "task", req.ID, "state", *req.State, "systemLogs", taskLog.SystemLogs)
+	}
+	return c.ga4gh.doRequest(ctx, http.MethodPost, fmt.Sprintf("%s%s/tasks/%s:cancel", c.ga4gh.endpoint, ga4ghAPIPrefix, req.ID),
+		&ga4ghCancelTaskRequest{}, &ga4ghCancelTaskRequest{})
+}
+
+// BatchUpdateTasks ...
+func (c *compatClient) BatchUpdateTasks(ctx context.Context, req *models.BatchUpdateTasksRequest) (*models.BatchUpdateTasksResponse, error) {
+	c.unbindFinishedTasks(ctx)
+	return updateTasksConcurrently(ctx, req, c.concurrency, c.updateTask), nil
+}
+
+// unbindFinishedTasks drops assignments of finished tasks seen when reading.
+// Tasks failed to unbind are retried on the next update.
+func (c *compatClient) unbindFinishedTasks(ctx context.Context) {
+	c.mutex.Lock()
+	ids := c.finishedBound
+	c.finishedBound = make(map[string]struct{})
+	c.mutex.Unlock()
+
+	for id := range ids {
+		if err := c.binder.Bind(ctx, id, ""); err != nil {
+			log.CtxWarnw(ctx, "failed to unbind finished task", "task", id, "err", err)
+			c.mutex.Lock()
+			c.finishedBound[id] = struct{}{}
+			c.mutex.Unlock()
+		}
+	}
+}
+
+// GatherTasksResources ...
+func (c *compatClient) GatherTasksResources(_ context.Context, _ *models.GatherTasksResourcesRequest) (*models.GatherTasksResourcesResponse, error) {
+	return nil, fmt.Errorf("gather tasks resources is not supported in GA4GH compat mode")
+}
+
+// ListClusters regards clusters without heartbeat as always alive
+func (c *compatClient) ListClusters(_ context.Context, _ *models.ListClustersRequest) (*models.ListClustersResponse, error) {
+	clusters, err := c.clusters.get()
+	if err != nil {
+		return nil, err
+	}
+	now := c.ga4gh.clock.Now().UTC().Format(time.RFC3339)
+	resp := make(models.ListClustersResponse, 0, len(clusters))
+	for _, cluster := range clusters {
+		cluster := *cluster
+		if cluster.HeartbeatTimestamp == "" {
+			cluster.HeartbeatTimestamp = now
+		}
+		resp = append(resp, &cluster)
+	}
+	return &resp, nil
+}
+
+// GetQuota responds 404 if there is no such quota
+func (c *compatClient) GetQuota(_ context.Context, req *models.GetQuotaRequest) (*models.GetQuotaResponse, error) {
+	quotas, err := c.quotas.get()
+	if err != nil {
+		return nil, err
+	}
+	for _, quota := range quotas {
+		if quota.Global != req.Global {
+			continue
+		}
+		if !req.Global && (quota.AccountID != req.AccountID || quota.UserID != req.UserID) {
+			continue
+		}
+		return &models.GetQuotaResponse{
+			Global:        quota.Global,
+			AccountID:     quota.AccountID,
+			UserID:        quota.UserID,
+			ResourceQuota: quota.ResourceQuota,
+		}, nil
+	}
+	return nil, &APIError{StatusCode: http.StatusNotFound, Body: "quota not found", Method: http.MethodGet, Path: otherAPIPrefix + "/quota"}
+}
+
+// ListQuotas ...
+func (c *compatClient) ListQuotas(_ context.Context, _ *models.ListQuotasRequest) (*models.ListQuotasResponse, error) {
+	quotas, err := c.quotas.get()
+	if err != nil {
+		return nil, err
+	}
+	return &quotas, nil
+}
+
+// ListExtraPriority ...
+func (c *compatClient) ListExtraPriority(_ context.Context, req *models.ListExtraPriorityRequest) (*models.ListExtraPriorityResponse, error) {
+	extraPriorities, err := c.extraPriorities.get()
+	if err != nil {
+		return nil, err
+	}
+	resp := make(models.ListExtraPriorityResponse, 0, len(extraPriorities))
+	for _, extraPriority := range extraPriorities {
+		if (req.AccountID != "" && extraPriority.AccountID != req.AccountID) ||
+			(req.SubmissionID != "" && extraPriority.SubmissionID != req.SubmissionID) ||
+			(req.RunID != "" && extraPriority.RunID != req.RunID) {
+			continue
+		}
+		resp = append(resp, extraPriority)
+	}
+	return &resp, nil
+}
+
+func isFinishedState(state string) bool {
+	switch state {
+	case consts.TaskQueued, consts.TaskInitializing, consts.TaskRunning, consts.TaskCanceling:
+		return false
+	default:
+		return true
+	}
+}
+
+// configFile is a JSON or YAML file re-read once modified, empty path means the zero value
+type configFile[T any] struct {
+	path string
+
+	mutex   sync.Mutex
+	value   T
+	modTime time.Time
+}
+
+func (f *configFile[T]) get() (T, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.path == "" {
+		return f.value, nil
+	}
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return f.value, fmt.Errorf("stat config file: %w", err)
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return f.value, nil
+	}
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		return f.value, fmt.Errorf("read config file: %w", err)
+	}
+	var value T
+	if err = yaml.Unmarshal(content, &value); err != nil {
+		return f.value, fmt.Errorf("invalid config file %s: %w", f.path, err)
+	}
+	f.value = value
+	f.modTime = info.ModTime()
+	return f.value, nil
+}
@@ -0,0 +1,229 @@
+package vetesclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"k8s.io/utils/clock"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	"github.com/GBA-BI/tes-scheduler/pkg/utils"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+const fakeClustersYAML = `
+- id: cluster-01
+  capacity:
+    cpu_cores: 100
+- id: cluster-02
+  heartbeat_timestamp: "2023-01-01T00:00:00Z"
+`
+
+const fakeQuotasJSON = `[
+  {"global": true, "resource_quota": {"count": 100}},
+  {"account_id": "account-01", "resource_quota": {"count": 10}},
+  {"account_id": "account-01", "user_id": "user-01", "resource_quota": {"count": 1}}
+]`
+
+const fakeExtraPrioritiesYAML = `
+- account_id: account-01
+  extra_priority_value: 10
+- account_id: account-02
+  run_id: run-01
+  extra_priority_value: 20
+`
+
+func newCompatTestClient(dir string) Client {
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		gomega.Expect(os.WriteFile(path, []byte(content), 0600)).To(gomega.Succeed())
+		return path
+	}
+	opts := &Options{
+		Endpoint:               fakeEndpoint,
+		Timeout:                5 * time.Second,
+		BatchUpdateConcurrency: 2,
+		Compat: &CompatOptions{
+			Enable:              true,
+			ClustersFile:        writeFile("clusters.yaml", fakeClustersYAML),
+			QuotasFile:          writeFile("quotas.json", fakeQuotasJSON),
+			ExtraPrioritiesFile: writeFile("extra_priorities.yaml", fakeExtraPrioritiesYAML),
+			Binder:              FileBinder,
+			BinderFile:          filepath.Join(dir, "assignments.json"),
+		},
+	}
+	gomega.Expect(opts.Validate()).To(gomega.Succeed())
+	cli, err := NewClient(opts, clock.RealClock{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	httpmock.ActivateNonDefault(cli.(*compatClient).ga4gh.cli)
+	return cli
+}
+
+var _ = ginkgo.It("compat ListTasks and UpdateTask", func() {
+	dir := ginkgo.GinkgoT().TempDir()
+	client := newCompatTestClient(dir)
+
+	// only GA4GH parameters are sent, one list per state
+	queuedResponder, _ := httpmock.NewJsonResponder(http.StatusOK, &models.ListTasksResponse{Tasks: []*models.Task{
+		{ID: "task-01", State: consts.TaskQueued, Tags: map[string]string{"account_id": "account-01", "user_id": "user-01"}},
+	}})
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/tasks?page_size=%d&state=%s&view=%s",
+		fakeEndpoint, ga4ghAPIPrefix, consts.MaximumPageSize, consts.TaskQueued, consts.MinimalView), queuedResponder)
+	runningResponder, _ := httpmock.NewJsonResponder(http.StatusOK, &models.ListTasksResponse{Tasks: []*models.Task{
+		{ID: "task-02", State: consts.TaskRunning},
+	}, NextPageToken: "next"})
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/tasks?page_size=%d&state=%s&view=%s",
+		fakeEndpoint, ga4ghAPIPrefix, consts.MaximumPageSize, consts.TaskRunning, consts.MinimalView), runningResponder)
+	runningNextResponder, _ := httpmock.NewJsonResponder(http.StatusOK, &models.ListTasksResponse{Tasks: []*models.Task{
+		{ID: "task-04", State: consts.TaskRunning},
+	}})
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/tasks?page_size=%d&page_token=next&state=%s&view=%s",
+		fakeEndpoint, ga4ghAPIPrefix, consts.MaximumPageSize, consts.TaskRunning, consts.MinimalView), runningNextResponder)
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/tasks/task-01?view=%s", fakeEndpoint, ga4ghAPIPrefix, consts.MinimalView),
+		httpmock.NewStringResponder(http.StatusOK, `{"id": "task-01", "state": "QUEUED"}`))
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/tasks/task-02?view=%s", fakeEndpoint, ga4ghAPIPrefix, consts.MinimalView),
+		httpmock.NewStringResponder(http.StatusOK, `{"id": "task-02", "state": "RUNNING"}`))
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("%s%s/tasks/task-02:cancel", fakeEndpoint, ga4ghAPIPrefix),
+		httpmock.NewStringResponder(http.StatusOK, "{}"))
+
+	listReq := &models.ListTasksRequest{
+		State:    []string{consts.TaskQueued, consts.TaskRunning},
+		View:     consts.MinimalView,
+		PageSize: consts.MaximumPageSize,
+	}
+	resp, err := client.ListTasks(context.Background(), listReq)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(resp.Tasks).To(gomega.HaveLen(3))
+	gomega.Expect(resp.NextPageToken).To(gomega.BeEmpty())
+	gomega.Expect(resp.Tasks[0].BioosInfo).To(gomega.Equal(&models.BioosInfo{AccountID: "account-01", UserID: "user-01"}))
+	gomega.Expect(resp.Tasks[0].ClusterID).To(gomega.BeEmpty())
+
+	// assignment is recorded by binder
+	_, err = client.UpdateTask(context.Background(), &models.UpdateTaskRequest{
+		ID: "task-01", ClusterID: utils.Point("cluster-01"), ExpectedState: utils.Point(consts.TaskQueued),
+	})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	listReq.ClusterID = "cluster-01"
+	resp, err = client.ListTasks(context.Background(), listReq)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(resp.Tasks).To(gomega.HaveLen(1))
+	gomega.Expect(resp.Tasks[0].ClusterID).To(gomega.Equal("cluster-01"))
+	content, err := os.ReadFile(filepath.Join(dir, "assignments.json"))
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(string(content)).To(gomega.Equal(`{"task-01":"cluster-01"}`))
+
+	// expected state is checked
+	_, err = client.UpdateTask(context.Background(), &models.UpdateTaskRequest{
+		ID: "task-02", ClusterID: utils.Point("cluster-01"), ExpectedState: utils.Point(consts.TaskQueued),
+	})
+	gomega.Expect(errors.Is(err, ErrConflict)).To(gomega.BeTrue())
+
+	// finishing is canceling
+	_, err = client.UpdateTask(context.Background(), &models.UpdateTaskRequest{
+		ID: "task-02", State: utils.Point(consts.TaskSystemError), ExpectedState: utils.Point(consts.TaskRunning),
+		Logs: []*models.TaskLog{{ClusterID: "scheduler", SystemLogs: []string{"no cluster"}}},
+	})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(httpmock.GetCallCountInfo()[fmt.Sprintf("POST %s%s/tasks/task-02:cancel", fakeEndpoint, ga4ghAPIPrefix)]).To(gomega.Equal(1))
+
+	// other states cannot be set
+	_, err = client.UpdateTask(context.Background(), &models.UpdateTaskRequest{ID: "task-01", State: utils.Point(consts.TaskRunning)})
+	gomega.Expect(errors.Is(err, ErrBadRequest)).To(gomega.BeTrue())
+
+	// rescheduling unbinds
+	_, err = client.UpdateTask(context.Background(), &models.UpdateTaskRequest{
+		ID: "task-01", State: utils.Point(consts.TaskQueued), ClusterID: utils.Point(""),
+	})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(client.(*compatClient).binder.ClusterID("task-01")).To(gomega.BeEmpty())
+})
+
+var _ = ginkgo.It("compat unbinds finished tasks on update", func() {
+	dir := ginkgo.GinkgoT().TempDir()
+	client := newCompatTestClient(dir)
+	binder := client.(*compatClient).binder
+	gomega.Expect(binder.Bind(context.Background(), "task-01", "cluster-01")).To(gomega.Succeed())
+
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/tasks/task-01?view=%s", fakeEndpoint, ga4ghAPIPrefix, consts.BasicView),
+		httpmock.NewStringResponder(http.StatusOK, `{"id": "task-01", "state": "COMPLETE"}`))
+
+	// reading does not write the binder
+	resp, err := client.GetTask(context.Background(), &models.GetTaskRequest{ID: "task-01", View: consts.BasicView})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(resp.Task.ClusterID).To(gomega.Equal("cluster-01"))
+	gomega.Expect(binder.ClusterID("task-01")).To(gomega.Equal("cluster-01"))
+
+	_, err = client.UpdateTask(context.Background(), &models.UpdateTaskRequest{ID: "task-02", ClusterID: utils.Point("cluster-01")})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(binder.ClusterID("task-01")).To(gomega.BeEmpty())
+	content, err := os.ReadFile(filepath.Join(dir, "assignments.json"))
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(string(content)).To(gomega.Equal(`{"task-02":"cluster-01"}`))
+})
+
+var _ = ginkgo.It("compat config files", func() {
+	client := newCompatTestClient(ginkgo.GinkgoT().TempDir())
+
+	clusters, err := client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(*clusters).To(gomega.HaveLen(2))
+	gomega.Expect((*clusters)[0].ID).To(gomega.Equal("cluster-01"))
+	gomega.Expect((*clusters)[0].Capacity.CPUCores).To(gomega.Equal(utils.Point(100)))
+	heartbeat, err := time.Parse(time.RFC3339, (*clusters)[0].HeartbeatTimestamp)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(heartbeat).To(gomega.BeTemporally("~", time.Now(), time.Minute))
+	gomega.Expect((*clusters)[1].HeartbeatTimestamp).To(gomega.Equal("2023-01-01T00:00:00Z"))
+
+	quota, err := client.GetQuota(context.Background(), &models.GetQuotaRequest{AccountID: "account-01"})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(quota.ResourceQuota.Count).To(gomega.Equal(utils.Point(10)))
+	quota, err = client.GetQuota(context.Background(), &models.GetQuotaRequest{Global: true})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(quota.ResourceQuota.Count).To(gomega.Equal(utils.Point(100)))
+	_, err = client.GetQuota(context.Background(), &models.GetQuotaRequest{AccountID: "account-02"})
+	gomega.Expect(errors.Is(err, ErrNotFound)).To(gomega.BeTrue())
+	quotas, err := client.ListQuotas(context.Background(), &models.ListQuotasRequest{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(*quotas).To(gomega.HaveLen(3))
+
+	extraPriorities, err := client.ListExtraPriority(context.Background(), &models.ListExtraPriorityRequest{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(*extraPriorities).To(gomega.HaveLen(2))
+	extraPriorities, err = client.ListExtraPriority(context.Background(), &models.ListExtraPriorityRequest{RunID: "run-01"})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(*extraPriorities).To(gomega.HaveLen(1))
+	gomega.Expect((*extraPriorities)[0].ExtraPriorityValue).To(gomega.Equal(20))
+
+	_, err = client.GatherTasksResources(context.Background(), &models.GatherTasksResourcesRequest{})
+	gomega.Expect(err).To(gomega.HaveOccurred())
+})
+
+var _ = ginkgo.It("configFile reload", func() {
+	path := filepath.Join(ginkgo.GinkgoT().TempDir(), "clusters.yaml")
+	gomega.Expect(os.WriteFile(path, []byte(`[{"id": "cluster-01"}]`), 0600)).To(gomega.Succeed())
+	file := &configFile[models.ListClustersResponse]{path: path}
+	clusters, err := file.get()
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(clusters).To(gomega.HaveLen(1))
+
+	gomega.Expect(os.WriteFile(path, []byte(`[{"id": "cluster-01"}, {"id": "cluster-02"}]`), 0600)).To(gomega.Succeed())
+	gomega.Expect(os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))).To(gomega.Succeed())
+	clusters, err = file.get()
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(clusters).To(gomega.HaveLen(2))
+
+	// the last valid value is kept
+	gomega.Expect(os.WriteFile(path, []byte(`invalid: [`), 0600)).To(gomega.Succeed())
+	gomega.Expect(os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute))).To(gomega.Succeed())
+	clusters, err = file.get()
+	gomega.Expect(err).To(gomega.HaveOccurred())
+	gomega.Expect(clusters).To(gomega.HaveLen(2))
+})
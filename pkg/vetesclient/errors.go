@@ -0,0 +1,84 @@
+package vetesclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// sentinel errors to check APIError by errors.Is
+var (
+	// ErrBadRequest is 400
+	ErrBadRequest = errors.New("bad request")
+	// ErrUnauthorized is 401 or 403
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrNotFound is 404
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is 409, returned when the precondition of request fails, such as ExpectedState of UpdateTaskRequest
+	ErrConflict = errors.New("conflict")
+	// ErrTooManyRequests is 429
+	ErrTooManyRequests = errors.New("too many requests")
+	// ErrServerError is 5xx
+	ErrServerError = errors.New("server error")
+)
+
+// APIError is returned when vetes-api responds an error status
+type APIError struct {
+	StatusCode int
+	Body       string
+	Method     string
+	Path       string
+	// Retryable is whether the same request may succeed later, such as throttling or outage
+	Retryable bool
+}
+
+var _ error = (*APIError)(nil)
+
+// newAPIError ...
+func newAPIError(response *http.Response, body []byte) *APIError {
+	res := &APIError{
+		StatusCode: response.StatusCode,
+		Body:       string(body),
+		Retryable:  isRetryableStatus(response.StatusCode),
+	}
+	if response.Request != nil {
+		res.Method = response.Request.Method
+		res.Path = response.Request.URL.Path
+	}
+	return res
+}
+
+// Error ...
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// Is makes errors.Is(err, ErrXXX) work
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrTooManyRequests:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServerError:
+		return e.StatusCode >= http.StatusInternalServerError
+	default:
+		return false
+	}
+}
+
+// IsRetryable returns whether err is an APIError which is retryable
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Retryable
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
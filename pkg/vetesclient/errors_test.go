@@ -0,0 +1,55 @@
+package vetesclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+var _ = ginkgo.It("APIError", func() {
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/clusters", fakeEndpoint, otherAPIPrefix),
+		httpmock.NewStringResponder(http.StatusTooManyRequests, "slow down"))
+	_, err := fakeClient.ListClusters(context.Background(), &models.ListClustersRequest{})
+	var apiErr *APIError
+	gomega.Expect(errors.As(err, &apiErr)).To(gomega.BeTrue())
+	gomega.Expect(apiErr).To(gomega.Equal(&APIError{
+		StatusCode: http.StatusTooManyRequests,
+		Body:       "slow down",
+		Method:     http.MethodGet,
+		Path:       otherAPIPrefix + "/clusters",
+		Retryable:  true,
+	}))
+	gomega.Expect(err.Error()).To(gomega.Equal("GET /api/v1/clusters: 429: slow down"))
+	gomega.Expect(IsRetryable(err)).To(gomega.BeTrue())
+	gomega.Expect(IsRetryable(errors.New("other"))).To(gomega.BeFalse())
+})
+
+var _ = ginkgo.It("APIError sentinels", func() {
+	tests := []struct {
+		statusCode int
+		sentinel   error
+	}{
+		{http.StatusBadRequest, ErrBadRequest},
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrUnauthorized},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusConflict, ErrConflict},
+		{http.StatusTooManyRequests, ErrTooManyRequests},
+		{http.StatusInternalServerError, ErrServerError},
+		{http.StatusBadGateway, ErrServerError},
+	}
+	sentinels := []error{ErrBadRequest, ErrUnauthorized, ErrNotFound, ErrConflict, ErrTooManyRequests, ErrServerError}
+	for _, test := range tests {
+		err := fmt.Errorf("wrapped: %w", &APIError{StatusCode: test.statusCode})
+		for _, sentinel := range sentinels {
+			gomega.Expect(errors.Is(err, sentinel)).To(gomega.Equal(sentinel == test.sentinel), "status %d, sentinel %s", test.statusCode, sentinel)
+		}
+	}
+})
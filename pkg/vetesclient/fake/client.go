@@ -35,6 +35,21 @@ func (m *FakeClient) EXPECT() *FakeClientMockRecorder {
 	return m.recorder
 }
 
+// BatchUpdateTasks mocks base method.
+func (m *FakeClient) BatchUpdateTasks(ctx context.Context, req *models.BatchUpdateTasksRequest) (*models.BatchUpdateTasksResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchUpdateTasks", ctx, req)
+	ret0, _ := ret[0].(*models.BatchUpdateTasksResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchUpdateTasks indicates an expected call of BatchUpdateTasks.
+func (mr *FakeClientMockRecorder) BatchUpdateTasks(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchUpdateTasks", reflect.TypeOf((*FakeClient)(nil).BatchUpdateTasks), ctx, req)
+}
+
 // GatherTasksResources mocks base method.
 func (m *FakeClient) GatherTasksResources(ctx context.Context, req *models.GatherTasksResourcesRequest) (*models.GatherTasksResourcesResponse, error) {
 	m.ctrl.T.Helper()
@@ -110,6 +125,21 @@ func (mr *FakeClientMockRecorder) ListExtraPriority(ctx, req interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExtraPriority", reflect.TypeOf((*FakeClient)(nil).ListExtraPriority), ctx, req)
 }
 
+// ListQuotas mocks base method.
+func (m *FakeClient) ListQuotas(ctx context.Context, req *models.ListQuotasRequest) (*models.ListQuotasResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListQuotas", ctx, req)
+	ret0, _ := ret[0].(*models.ListQuotasResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListQuotas indicates an expected call of ListQuotas.
+func (mr *FakeClientMockRecorder) ListQuotas(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListQuotas", reflect.TypeOf((*FakeClient)(nil).ListQuotas), ctx, req)
+}
+
 // ListTasks mocks base method.
 func (m *FakeClient) ListTasks(ctx context.Context, req *models.ListTasksRequest) (*models.ListTasksResponse, error) {
 	m.ctrl.T.Helper()
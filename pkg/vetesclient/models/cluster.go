@@ -13,6 +13,7 @@ type Cluster struct {
 	HeartbeatTimestamp string    `json:"heartbeat_timestamp"`
 	Capacity           *Capacity `json:"capacity,omitempty"`
 	Limits             *Limits   `json:"limits,omitempty"`
+	Price              *Price    `json:"price,omitempty"`
 }
 
 // Capacity ...
@@ -40,3 +41,11 @@ type Limits struct {
 type GPULimit struct {
 	GPU map[string]float64 `json:"gpu,omitempty"`
 }
+
+// Price is the price of resources per hour
+type Price struct {
+	CPUCoreHour float64            `json:"cpu_core_hour,omitempty"`
+	RamGBHour   float64            `json:"ram_gb_hour,omitempty"` // nolint
+	DiskGBHour  float64            `json:"disk_gb_hour,omitempty"`
+	GPUHour     map[string]float64 `json:"gpu_hour,omitempty"`
+}
@@ -17,4 +17,8 @@ type ExtraPriority struct {
 	SubmissionID       string `json:"submission_id,omitempty"`
 	RunID              string `json:"run_id,omitempty"`
 	ExtraPriorityValue int    `json:"extra_priority_value"`
+	// StartTime and ExpireTime are RFC3339 timestamps bounding the validity, empty means unbounded
+	StartTime  string `json:"start_time,omitempty"`
+	ExpireTime string `json:"expire_time,omitempty"`
+	Reason     string `json:"reason,omitempty"`
 }
@@ -16,6 +16,20 @@ type GetQuotaResponse struct {
 	ResourceQuota *ResourceQuota `json:"resource_quota,omitempty"`
 }
 
+// ListQuotasRequest ...
+type ListQuotasRequest struct{}
+
+// ListQuotasResponse contains the global quota and all quotas of accounts and users
+type ListQuotasResponse []*Quota
+
+// Quota ...
+type Quota struct {
+	Global        bool           `json:"global"`
+	AccountID     string         `json:"account_id,omitempty"`
+	UserID        string         `json:"user_id,omitempty"`
+	ResourceQuota *ResourceQuota `json:"resource_quota,omitempty"`
+}
+
 // ResourceQuota ...
 type ResourceQuota struct {
 	Count    *int      `json:"count,omitempty"`
@@ -9,6 +9,8 @@ type ListTasksRequest struct {
 	View           string   `query:"view"`
 	PageSize       int      `query:"page_size"`
 	PageToken      string   `query:"page_token"`
+	// ModifiedSince is a RFC3339 timestamp, only tasks modified since then are listed
+	ModifiedSince string `query:"modified_since"`
 }
 
 // ListTasksResponse ...
@@ -34,11 +36,33 @@ type UpdateTaskRequest struct {
 	ClusterID *string    `json:"cluster_id,omitempty"`
 	State     *string    `json:"state,omitempty"`
 	Logs      []*TaskLog `json:"logs,omitempty"`
+	// ExpectedState makes the update conditional, vetes-api responds 409 if the
+	// current state of task is not ExpectedState
+	ExpectedState *string `json:"expected_state,omitempty"`
 }
 
 // UpdateTaskResponse ...
 type UpdateTaskResponse struct{}
 
+// BatchUpdateTasksRequest ...
+type BatchUpdateTasksRequest struct {
+	Tasks []*UpdateTaskRequest
+}
+
+// BatchUpdateTasksResponse has a result for each task of request
+type BatchUpdateTasksResponse struct {
+	Results []*BatchUpdateTaskResult `json:"results"`
+}
+
+// BatchUpdateTaskResult ...
+type BatchUpdateTaskResult struct {
+	ID         string `json:"id"`
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"message,omitempty"`
+	// Error is filled by client, nil means succeeded
+	Error error `json:"-"`
+}
+
 // GatherTasksResourcesRequest ...
 type GatherTasksResourcesRequest struct {
 	State       []string `query:"state"`
@@ -59,20 +83,21 @@ type GatherTasksResourcesResponse struct {
 
 // Task ...
 type Task struct {
-	ID            string      `json:"id"`
-	State         string      `json:"state"`
-	Name          string      `json:"name,omitempty"`
-	Description   string      `json:"description,omitempty"`
-	Inputs        []*Input    `json:"inputs,omitempty"`
-	Outputs       []*Output   `json:"outputs,omitempty"`
-	Resources     *Resources  `json:"resources,omitempty"`
-	Executors     []*Executor `json:"executors,omitempty"`
-	Volumes       []string    `json:"volumes,omitempty"`
-	Logs          []*TaskLog  `json:"logs,omitempty"`
-	CreationTime  string      `json:"creation_time,omitempty"`
-	BioosInfo     *BioosInfo  `json:"bioos_info,omitempty"`
-	PriorityValue int         `json:"priority_value,omitempty"`
-	ClusterID     string      `json:"cluster_id,omitempty"`
+	ID            string            `json:"id"`
+	State         string            `json:"state"`
+	Name          string            `json:"name,omitempty"`
+	Description   string            `json:"description,omitempty"`
+	Inputs        []*Input          `json:"inputs,omitempty"`
+	Outputs       []*Output         `json:"outputs,omitempty"`
+	Resources     *Resources        `json:"resources,omitempty"`
+	Executors     []*Executor       `json:"executors,omitempty"`
+	Volumes       []string          `json:"volumes,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+	Logs          []*TaskLog        `json:"logs,omitempty"`
+	CreationTime  string            `json:"creation_time,omitempty"`
+	BioosInfo     *BioosInfo        `json:"bioos_info,omitempty"`
+	PriorityValue int               `json:"priority_value,omitempty"`
+	ClusterID     string            `json:"cluster_id,omitempty"`
 }
 
 // Input ...
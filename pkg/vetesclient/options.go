@@ -1,15 +1,49 @@
 package vetesclient
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
 )
 
 // Options ...
 type Options struct {
-	Endpoint string        `mapstructure:"endpoint"`
-	Timeout  time.Duration `mapstructure:"timeout"`
+	Endpoint string `mapstructure:"endpoint"`
+	// Endpoints is name -> endpoint of vetes-api instances to federate, Endpoint is
+	// ignored if set. Other options are shared by all endpoints.
+	Endpoints map[string]string `mapstructure:"endpoints"`
+	Timeout   time.Duration     `mapstructure:"timeout"`
+	// MaxRetries is the max retries of a request, with jittered exponential backoff from
+	// RetryBaseDelay to RetryMaxDelay. Only 429 responses, and 5xx responses and transport
+	// errors of idempotent requests are retried. Retry-After of response is respected.
+	MaxRetries     int           `mapstructure:"maxRetries"`
+	RetryBaseDelay time.Duration `mapstructure:"retryBaseDelay"`
+	RetryMaxDelay  time.Duration `mapstructure:"retryMaxDelay"`
+	// CircuitBreakerThreshold is the consecutive failures to open the circuit breaker, then
+	// requests fail fast in CircuitBreakerCooldown. 0 means disabled.
+	CircuitBreakerThreshold int           `mapstructure:"circuitBreakerThreshold"`
+	CircuitBreakerCooldown  time.Duration `mapstructure:"circuitBreakerCooldown"`
+	// BatchUpdateConcurrency is the max concurrent UpdateTask of BatchUpdateTasks,
+	// if vetes-api has no batch update endpoint.
+	BatchUpdateConcurrency int `mapstructure:"batchUpdateConcurrency"`
+
+	// BearerToken, BearerTokenFile and Username/Password are exclusive. BearerTokenFile
+	// is re-read once modified, such as a mounted kubernetes secret.
+	BearerToken     string `mapstructure:"bearerToken"`
+	BearerTokenFile string `mapstructure:"bearerTokenFile"`
+	Username        string `mapstructure:"username"`
+	Password        string `mapstructure:"password"`
+	// TLSCertFile and TLSKeyFile are the client cert for mTLS, TLSCAFile is the CA bundle
+	// to verify vetes-api, system CAs are used if empty.
+	TLSCertFile string `mapstructure:"tlsCertFile"`
+	TLSKeyFile  string `mapstructure:"tlsKeyFile"`
+	TLSCAFile   string `mapstructure:"tlsCAFile"`
+
+	Compat *CompatOptions `mapstructure:"compat"`
 }
 
 // NewOptions ...
@@ -17,16 +51,129 @@ func NewOptions() *Options {
 	return &Options{
 		Endpoint: "http://vetes-api.vetes-system:8080",
 		Timeout:  10 * time.Second,
+
+		MaxRetries:              3,
+		RetryBaseDelay:          200 * time.Millisecond,
+		RetryMaxDelay:           5 * time.Second,
+		CircuitBreakerThreshold: 10,
+		CircuitBreakerCooldown:  30 * time.Second,
+		BatchUpdateConcurrency:  8,
+
+		Compat: &CompatOptions{Binder: FileBinder},
 	}
 }
 
 // Validate ...
 func (o *Options) Validate() error {
-	return nil
+	for name, endpoint := range o.Endpoints {
+		if name == "" || strings.Contains(name, consts.FederatedIDSeparator) {
+			return fmt.Errorf("invalid endpoint name %q", name)
+		}
+		if endpoint == "" {
+			return fmt.Errorf("endpoint %s cannot be empty", name)
+		}
+	}
+	if len(o.Endpoints) > 0 && o.Compat != nil && o.Compat.Enable {
+		return fmt.Errorf("GA4GH compat mode does not support multiple endpoints")
+	}
+	if o.MaxRetries < 0 {
+		return fmt.Errorf("max retries cannot be negative")
+	}
+	if o.MaxRetries > 0 && (o.RetryBaseDelay <= 0 || o.RetryMaxDelay < o.RetryBaseDelay) {
+		return fmt.Errorf("retry base delay must be positive and not greater than retry max delay")
+	}
+	if o.CircuitBreakerThreshold < 0 {
+		return fmt.Errorf("circuit breaker threshold cannot be negative")
+	}
+	if o.CircuitBreakerThreshold > 0 && o.CircuitBreakerCooldown <= 0 {
+		return fmt.Errorf("circuit breaker cooldown must be positive")
+	}
+	if o.BatchUpdateConcurrency <= 0 {
+		return fmt.Errorf("batch update concurrency must be positive")
+	}
+	authMethods := 0
+	for _, set := range []bool{o.BearerToken != "", o.BearerTokenFile != "", o.Username != ""} {
+		if set {
+			authMethods++
+		}
+	}
+	if authMethods > 1 {
+		return fmt.Errorf("bearer token, bearer token file and basic auth are exclusive")
+	}
+	if o.Password != "" && o.Username == "" {
+		return fmt.Errorf("username must be set with password")
+	}
+	if (o.TLSCertFile == "") != (o.TLSKeyFile == "") {
+		return fmt.Errorf("tls cert file and key file must be set together")
+	}
+	return o.Compat.Validate()
 }
 
 // AddFlags ...
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.Endpoint, "vetes-client-endpoint", o.Endpoint, "endpoint of the vetes-client")
+	fs.StringToStringVar(&o.Endpoints, "vetes-client-endpoints", o.Endpoints, "name=endpoint pairs of vetes-api instances to federate, overriding vetes-client-endpoint")
 	fs.DurationVar(&o.Timeout, "vetes-client-timeout", o.Timeout, "timeout of the vetes-client")
+	fs.IntVar(&o.MaxRetries, "vetes-client-max-retries", o.MaxRetries, "max retries of a request on 429 responses, or 5xx responses and transport errors of idempotent requests")
+	fs.DurationVar(&o.RetryBaseDelay, "vetes-client-retry-base-delay", o.RetryBaseDelay, "base delay of exponential backoff between retries")
+	fs.DurationVar(&o.RetryMaxDelay, "vetes-client-retry-max-delay", o.RetryMaxDelay, "max delay between retries, including Retry-After of response")
+	fs.IntVar(&o.CircuitBreakerThreshold, "vetes-client-circuit-breaker-threshold", o.CircuitBreakerThreshold, "consecutive failures to open the circuit breaker, 0 means disabled")
+	fs.DurationVar(&o.CircuitBreakerCooldown, "vetes-client-circuit-breaker-cooldown", o.CircuitBreakerCooldown, "duration requests fail fast after the circuit breaker opens")
+	fs.IntVar(&o.BatchUpdateConcurrency, "vetes-client-batch-update-concurrency", o.BatchUpdateConcurrency, "max concurrent task updates of a batch if vetes-api has no batch update endpoint")
+	fs.StringVar(&o.BearerToken, "vetes-client-bearer-token", o.BearerToken, "static bearer token to authenticate to vetes-api")
+	fs.StringVar(&o.BearerTokenFile, "vetes-client-bearer-token-file", o.BearerTokenFile, "file of bearer token to authenticate to vetes-api, re-read once modified")
+	fs.StringVar(&o.Username, "vetes-client-username", o.Username, "username of basic auth to vetes-api")
+	fs.StringVar(&o.Password, "vetes-client-password", o.Password, "password of basic auth to vetes-api")
+	fs.StringVar(&o.TLSCertFile, "vetes-client-tls-cert-file", o.TLSCertFile, "client cert file for mTLS to vetes-api")
+	fs.StringVar(&o.TLSKeyFile, "vetes-client-tls-key-file", o.TLSKeyFile, "client key file for mTLS to vetes-api")
+	fs.StringVar(&o.TLSCAFile, "vetes-client-tls-ca-file", o.TLSCAFile, "CA bundle file to verify vetes-api, system CAs are used if empty")
+	for _, name := range []string{"vetes-client-bearer-token", "vetes-client-password"} {
+		_ = fs.SetAnnotation(name, consts.SensitiveFlagAnnotation, []string{"true"})
+	}
+	o.Compat.AddFlags(fs)
+}
+
+// CompatOptions is the GA4GH compatibility mode, to schedule tasks of a plain GA4GH
+// TES server instead of vetes-api.
+type CompatOptions struct {
+	Enable bool `mapstructure:"enable"`
+	// ClustersFile, QuotasFile and ExtraPrioritiesFile are JSON or YAML files in the
+	// same format as the responses of vetes-api, re-read once modified.
+	ClustersFile        string `mapstructure:"clustersFile"`
+	QuotasFile          string `mapstructure:"quotasFile"`
+	ExtraPrioritiesFile string `mapstructure:"extraPrioritiesFile"`
+	// Binder records assignments of tasks, one of the registered binders.
+	Binder string `mapstructure:"binder"`
+	// BinderFile persists assignments, empty means in memory only.
+	BinderFile string `mapstructure:"binderFile"`
+	// BinderWebhookURL is notified of each assignment by Webhook binder.
+	BinderWebhookURL string `mapstructure:"binderWebhookURL"`
+}
+
+// Validate ...
+func (o *CompatOptions) Validate() error {
+	if o == nil || !o.Enable {
+		return nil
+	}
+	if o.ClustersFile == "" {
+		return fmt.Errorf("clusters file must be set in GA4GH compat mode")
+	}
+	if _, ok := binders[o.Binder]; !ok {
+		return fmt.Errorf("invalid binder: %s", o.Binder)
+	}
+	if o.Binder == WebhookBinder && o.BinderWebhookURL == "" {
+		return fmt.Errorf("binder webhook url must be set for %s binder", WebhookBinder)
+	}
+	return nil
+}
+
+// AddFlags ...
+func (o *CompatOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Enable, "vetes-client-compat-enable", o.Enable, "talk to a plain GA4GH TES server instead of vetes-api")
+	fs.StringVar(&o.ClustersFile, "vetes-client-compat-clusters-file", o.ClustersFile, "JSON or YAML file of clusters in GA4GH compat mode")
+	fs.StringVar(&o.QuotasFile, "vetes-client-compat-quotas-file", o.QuotasFile, "JSON or YAML file of quotas in GA4GH compat mode")
+	fs.StringVar(&o.ExtraPrioritiesFile, "vetes-client-compat-extra-priorities-file", o.ExtraPrioritiesFile, "JSON or YAML file of extra priorities in GA4GH compat mode")
+	fs.StringVar(&o.Binder, "vetes-client-compat-binder", o.Binder, "binder recording assignments of tasks in GA4GH compat mode, one of File and Webhook")
+	fs.StringVar(&o.BinderFile, "vetes-client-compat-binder-file", o.BinderFile, "file persisting assignments of tasks in GA4GH compat mode, empty means in memory only")
+	fs.StringVar(&o.BinderWebhookURL, "vetes-client-compat-binder-webhook-url", o.BinderWebhookURL, "url notified of each assignment by Webhook binder")
 }
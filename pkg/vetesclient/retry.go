@@ -0,0 +1,87 @@
+package vetesclient
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// retryPolicy retries requests with jittered exponential backoff
+type retryPolicy struct {
+	clock      clock.PassiveClock
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// shouldRetry returns whether the attempt is worth retrying. Transport errors and
+// 5xx responses are only retried for idempotent methods, since the request may
+// have been processed. 429 responses are retried for all methods.
+func (p *retryPolicy) shouldRetry(attempt int, method string, response *http.Response, err error) bool {
+	if attempt >= p.maxRetries {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen) && isIdempotent(method)
+	}
+	if response.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return isRetryableStatus(response.StatusCode) && isIdempotent(method)
+}
+
+// delay returns the wait before next attempt, Retry-After of response is respected
+// if present, and all are capped by maxDelay.
+func (p *retryPolicy) delay(attempt int, response *http.Response) time.Duration {
+	if response != nil {
+		if retryAfter, ok := parseRetryAfter(response.Header.Get("Retry-After"), p.clock.Now()); ok {
+			if retryAfter > p.maxDelay {
+				return p.maxDelay
+			}
+			return retryAfter
+		}
+	}
+
+	backoff := p.baseDelay << attempt
+	if backoff > p.maxDelay || backoff <= 0 {
+		backoff = p.maxDelay
+	}
+	// equal jitter, in [backoff/2, backoff]
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter parses Retry-After in delay-seconds or HTTP-date, which is
+// relative to now
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := date.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
@@ -0,0 +1,142 @@
+package vetesclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"k8s.io/utils/clock"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+func newRetryClient(circuitBreakerThreshold int) Client {
+	cli, err := NewClient(&Options{
+		Endpoint:                fakeEndpoint,
+		Timeout:                 5 * time.Second,
+		MaxRetries:              2,
+		RetryBaseDelay:          time.Millisecond,
+		RetryMaxDelay:           10 * time.Millisecond,
+		CircuitBreakerThreshold: circuitBreakerThreshold,
+		CircuitBreakerCooldown:  time.Hour,
+	}, clock.RealClock{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	httpmock.ActivateNonDefault(cli.(*impl).cli)
+	return cli
+}
+
+var _ = ginkgo.It("retry on 5xx and 429", func() {
+	client := newRetryClient(0)
+	responder := httpmock.NewStringResponder(http.StatusBadGateway, "bad gateway").
+		Then(httpmock.NewStringResponder(http.StatusTooManyRequests, "slow down").HeaderSet(http.Header{"Retry-After": {"0"}})).
+		Then(httpmock.NewStringResponder(http.StatusOK, "[]"))
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/clusters", fakeEndpoint, otherAPIPrefix), responder)
+	_, err := client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(httpmock.GetTotalCallCount()).To(gomega.Equal(3))
+})
+
+var _ = ginkgo.It("retry backoff waits on the clock", func() {
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	cli, err := NewClient(&Options{
+		Endpoint:       fakeEndpoint,
+		Timeout:        5 * time.Second,
+		MaxRetries:     1,
+		RetryBaseDelay: time.Hour,
+		RetryMaxDelay:  time.Hour,
+	}, fakeClock)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	httpmock.ActivateNonDefault(cli.(*impl).cli)
+	responder := httpmock.NewStringResponder(http.StatusBadGateway, "bad gateway").
+		Then(httpmock.NewStringResponder(http.StatusOK, "[]"))
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/clusters", fakeEndpoint, otherAPIPrefix), responder)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cli.ListClusters(context.Background(), &models.ListClustersRequest{})
+		done <- err
+	}()
+	gomega.Eventually(fakeClock.HasWaiters).Should(gomega.BeTrue())
+	gomega.Consistently(done).ShouldNot(gomega.Receive())
+	fakeClock.Step(time.Hour)
+	gomega.Eventually(done).Should(gomega.Receive(gomega.BeNil()))
+	gomega.Expect(httpmock.GetTotalCallCount()).To(gomega.Equal(2))
+})
+
+var _ = ginkgo.It("retry 5xx only if idempotent", func() {
+	client := newRetryClient(0)
+	responder := httpmock.NewStringResponder(http.StatusTooManyRequests, "slow down").HeaderSet(http.Header{"Retry-After": {"0"}}).
+		Then(httpmock.NewStringResponder(http.StatusBadGateway, "bad gateway")).
+		Then(httpmock.NewStringResponder(http.StatusOK, "{}"))
+	httpmock.RegisterResponder(http.MethodPatch, fmt.Sprintf("%s%s/tasks/%s", fakeEndpoint, otherAPIPrefix, fakeTaskID), responder)
+	_, err := client.UpdateTask(context.Background(), &models.UpdateTaskRequest{ID: fakeTaskID})
+	gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("502")))
+	gomega.Expect(httpmock.GetTotalCallCount()).To(gomega.Equal(2))
+})
+
+var _ = ginkgo.It("retry exhausted", func() {
+	client := newRetryClient(0)
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/clusters", fakeEndpoint, otherAPIPrefix),
+		httpmock.NewStringResponder(http.StatusServiceUnavailable, "unavailable"))
+	_, err := client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("503")))
+	gomega.Expect(httpmock.GetTotalCallCount()).To(gomega.Equal(3))
+})
+
+var _ = ginkgo.It("not retry on 4xx", func() {
+	client := newRetryClient(0)
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/clusters", fakeEndpoint, otherAPIPrefix),
+		httpmock.NewStringResponder(http.StatusNotFound, "not found"))
+	_, err := client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(errors.Is(err, ErrNotFound)).To(gomega.BeTrue())
+	gomega.Expect(httpmock.GetTotalCallCount()).To(gomega.Equal(1))
+})
+
+var _ = ginkgo.It("retry transport error only if idempotent", func() {
+	client := newRetryClient(0)
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/clusters", fakeEndpoint, otherAPIPrefix),
+		httpmock.NewErrorResponder(errors.New("connection reset")).Then(httpmock.NewStringResponder(http.StatusOK, "[]")))
+	_, err := client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(httpmock.GetTotalCallCount()).To(gomega.Equal(2))
+
+	httpmock.ZeroCallCounters()
+	httpmock.RegisterResponder(http.MethodPatch, fmt.Sprintf("%s%s/tasks/%s", fakeEndpoint, otherAPIPrefix, fakeTaskID),
+		httpmock.NewErrorResponder(errors.New("connection reset")))
+	_, err = client.UpdateTask(context.Background(), &models.UpdateTaskRequest{ID: fakeTaskID})
+	gomega.Expect(err).To(gomega.HaveOccurred())
+	gomega.Expect(httpmock.GetTotalCallCount()).To(gomega.Equal(1))
+})
+
+var _ = ginkgo.It("circuit breaker fails fast", func() {
+	client := newRetryClient(3)
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s%s/clusters", fakeEndpoint, otherAPIPrefix),
+		httpmock.NewStringResponder(http.StatusInternalServerError, "internal error"))
+	_, err := client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("500")))
+	gomega.Expect(httpmock.GetTotalCallCount()).To(gomega.Equal(3))
+
+	_, err = client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	gomega.Expect(errors.Is(err, ErrCircuitOpen)).To(gomega.BeTrue())
+	gomega.Expect(httpmock.GetTotalCallCount()).To(gomega.Equal(3))
+})
+
+var _ = ginkgo.It("retry delay", func() {
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	policy := &retryPolicy{clock: fakeClock, maxRetries: 10, baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+	for attempt, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		delay := policy.delay(attempt, nil)
+		gomega.Expect(delay).To(gomega.BeNumerically(">=", max/2))
+		gomega.Expect(delay).To(gomega.BeNumerically("<=", max))
+	}
+	gomega.Expect(policy.delay(0, &http.Response{Header: http.Header{"Retry-After": {"0"}}})).To(gomega.BeZero())
+	gomega.Expect(policy.delay(0, &http.Response{Header: http.Header{"Retry-After": {"120"}}})).To(gomega.Equal(time.Second))
+	gomega.Expect(policy.delay(0, &http.Response{Header: http.Header{"Retry-After": {fakeClock.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)}}})).To(gomega.BeZero())
+	gomega.Expect(policy.delay(0, &http.Response{Header: http.Header{"Retry-After": {fakeClock.Now().Add(time.Second * 2).UTC().Format(http.TimeFormat)}}})).To(gomega.Equal(time.Second))
+})
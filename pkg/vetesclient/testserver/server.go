@@ -0,0 +1,656 @@
+// Package testserver is an in-memory stand-in of vetes-api for integration tests.
+// It holds tasks, clusters, quotas and extra priorities in memory, and serves the
+// APIs used by vetesclient, so that the scheduler, caches and controller can be
+// run against it as a whole. Agents of clusters are simulated by PickUpTasks and
+// FinishTask.
+package testserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+const (
+	ga4ghAPIPrefix = "/api/ga4gh/tes/v1"
+	otherAPIPrefix = "/api/v1"
+)
+
+// Server ...
+type Server struct {
+	*httptest.Server
+	// clock stamps creation, modification, heartbeats and logs of tasks
+	clock clock.PassiveClock
+
+	mutex sync.Mutex
+	tasks map[string]*task
+	// seq orders tasks by creation for pagination
+	seq             int
+	clusters        map[string]*models.Cluster
+	quotas          []*models.Quota
+	extraPriorities []*models.ExtraPriority
+}
+
+// task is models.Task with bookkeeping of the server
+type task struct {
+	*models.Task
+	seq          int
+	modifiedTime time.Time
+}
+
+// New starts a server, which should be closed by Close. Times are stamped by clk,
+// so that it can share a fake clock with the scheduler.
+func New(clk clock.PassiveClock) *Server {
+	s := &Server{
+		clock:    clk,
+		tasks:    make(map[string]*task),
+		clusters: make(map[string]*models.Cluster),
+	}
+	s.Server = httptest.NewServer(s.handler())
+	return s
+}
+
+// Endpoint is the endpoint of vetesclient
+func (s *Server) Endpoint() string {
+	return s.URL
+}
+
+// AddTask adds a copy of task and returns its ID. ID is generated if empty, state
+// defaults to QUEUED and creation time defaults to now.
+func (s *Server) AddTask(t *models.Task) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addTask(t)
+}
+
+func (s *Server) addTask(t *models.Task) string {
+	s.seq++
+	res := copyTask(t)
+	if res.ID == "" {
+		res.ID = fmt.Sprintf("task-%08d", s.seq)
+	}
+	if res.State == "" {
+		res.State = consts.TaskQueued
+	}
+	if res.CreationTime == "" {
+		res.CreationTime = s.clock.Now().UTC().Format(time.RFC3339)
+	}
+	s.tasks[res.ID] = &task{Task: res, seq: s.seq, modifiedTime: s.clock.Now()}
+	return res.ID
+}
+
+// GetTask returns a copy of task, nil if not found
+func (s *Server) GetTask(id string) *models.Task {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return nil
+	}
+	return copyTask(t.Task)
+}
+
+// ListTasks returns copies of all tasks in the order of creation
+func (s *Server) ListTasks() []*models.Task {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	res := make([]*models.Task, 0, len(s.tasks))
+	for _, t := range s.sortedTasks() {
+		res = append(res, copyTask(t.Task))
+	}
+	return res
+}
+
+// SetCluster adds or replaces a cluster, heartbeat defaults to now.
+func (s *Server) SetCluster(cluster *models.Cluster) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	res := *cluster
+	if res.HeartbeatTimestamp == "" {
+		res.HeartbeatTimestamp = s.clock.Now().UTC().Format(time.RFC3339)
+	}
+	s.clusters[res.ID] = &res
+}
+
+// Heartbeat refreshes heartbeat of cluster to now
+func (s *Server) Heartbeat(clusterID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if cluster, ok := s.clusters[clusterID]; ok {
+		cluster.HeartbeatTimestamp = s.clock.Now().UTC().Format(time.RFC3339)
+	}
+}
+
+// RemoveCluster ...
+func (s *Server) RemoveCluster(clusterID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.clusters, clusterID)
+}
+
+// SetQuotas replaces all quotas
+func (s *Server) SetQuotas(quotas ...*models.Quota) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.quotas = quotas
+}
+
+// SetExtraPriorities replaces all extra priorities
+func (s *Server) SetExtraPriorities(extraPriorities ...*models.ExtraPriority) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.extraPriorities = extraPriorities
+}
+
+// PickUpTasks simulates the agent of cluster: at most limit QUEUED tasks assigned
+// to the cluster become RUNNING, and CANCELING ones become CANCELED. limit <= 0
+// means unlimited. It returns IDs of tasks become RUNNING.
+func (s *Server) PickUpTasks(clusterID string, limit int) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	res := make([]string, 0)
+	now := s.clock.Now().UTC().Format(time.RFC3339)
+	for _, t := range s.sortedTasks() {
+		if t.ClusterID != clusterID {
+			continue
+		}
+		switch {
+		case t.State == consts.TaskCanceling:
+			s.setState(t, consts.TaskCanceled)
+		case t.State == consts.TaskQueued && (limit <= 0 || len(res) < limit):
+			s.setState(t, consts.TaskRunning)
+			t.Logs = append(t.Logs, &models.TaskLog{ClusterID: clusterID, StartTime: &now})
+			res = append(res, t.ID)
+		}
+	}
+	return res
+}
+
+// FinishTask simulates the agent finishing a RUNNING task with state
+func (s *Server) FinishTask(id, state string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return fmt.Errorf("task %s not found", id)
+	}
+	if t.State != consts.TaskRunning && t.State != consts.TaskInitializing {
+		return fmt.Errorf("task %s is %s, not running", id, t.State)
+	}
+	if !isFinishedState(state) {
+		return fmt.Errorf("invalid finished state: %s", state)
+	}
+	s.setState(t, state)
+	if len(t.Logs) > 0 {
+		now := s.clock.Now().UTC().Format(time.RFC3339)
+		t.Logs[len(t.Logs)-1].EndTime = &now
+	}
+	return nil
+}
+
+// CancelTask simulates the user canceling a task. Tasks not picked up are canceled
+// at once, others are CANCELING until the agent picks them up.
+func (s *Server) CancelTask(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return fmt.Errorf("task %s not found", id)
+	}
+	return s.cancelTask(t)
+}
+
+func (s *Server) cancelTask(t *task) error {
+	switch {
+	case isFinishedState(t.State):
+		return fmt.Errorf("task %s is already %s", t.ID, t.State)
+	case t.State == consts.TaskQueued:
+		s.setState(t, consts.TaskCanceled)
+	default:
+		s.setState(t, consts.TaskCanceling)
+	}
+	return nil
+}
+
+// setState must be called with mutex held
+func (s *Server) setState(t *task, state string) {
+	t.State = state
+	t.modifiedTime = s.clock.Now()
+}
+
+// sortedTasks must be called with mutex held
+func (s *Server) sortedTasks() []*task {
+	res := make([]*task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		res = append(res, t)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].seq < res[j].seq })
+	return res
+}
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ga4ghAPIPrefix+"/tasks", s.handleTasks)
+	mux.HandleFunc(ga4ghAPIPrefix+"/tasks/", s.handleTask)
+	mux.HandleFunc(otherAPIPrefix+"/tasks/", s.handleUpdateTask)
+	mux.HandleFunc(otherAPIPrefix+"/tasks/batch_update", s.handleBatchUpdateTasks)
+	mux.HandleFunc(otherAPIPrefix+"/tasks/resources", s.handleGatherTasksResources)
+	mux.HandleFunc(otherAPIPrefix+"/clusters", s.handleListClusters)
+	mux.HandleFunc(otherAPIPrefix+"/quota", s.handleGetQuota)
+	mux.HandleFunc(otherAPIPrefix+"/quotas", s.handleListQuotas)
+	mux.HandleFunc(otherAPIPrefix+"/extra_priority", s.handleListExtraPriority)
+	return mux
+}
+
+// handleTasks lists tasks, or creates a task by GA4GH TES
+func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listTasks(w, r)
+	case http.MethodPost:
+		t := new(models.Task)
+		if err := json.NewDecoder(r.Body).Decode(t); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		t.ID, t.State, t.ClusterID = "", "", ""
+		writeJSON(w, http.StatusOK, map[string]string{"id": s.AddTask(t)})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, r.Method)
+	}
+}
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	view, ok := parseView(query.Get("view"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid view: %s", query.Get("view")))
+		return
+	}
+	pageSize := consts.DefaultPageSize
+	if value := query.Get("page_size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid page_size: %s", value))
+			return
+		}
+		pageSize = size
+	}
+	if pageSize > consts.MaximumPageSize {
+		pageSize = consts.MaximumPageSize
+	}
+	// page token is the seq of the last task of previous page
+	var after int
+	if value := query.Get("page_token"); value != "" {
+		seq, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid page_token: %s", value))
+			return
+		}
+		after = seq
+	}
+	var modifiedSince time.Time
+	if value := query.Get("modified_since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid modified_since: %s", value))
+			return
+		}
+		modifiedSince = since
+	}
+	states := make(map[string]struct{})
+	for _, state := range query["state"] {
+		states[state] = struct{}{}
+	}
+	namePrefix, clusterID, withoutCluster := query.Get("name_prefix"), query.Get("cluster_id"), query.Get("without_cluster") == "true"
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	resp := &models.ListTasksResponse{Tasks: make([]*models.Task, 0)}
+	for _, t := range s.sortedTasks() {
+		if t.seq <= after {
+			continue
+		}
+		if _, ok := states[t.State]; len(states) > 0 && !ok {
+			continue
+		}
+		if !strings.HasPrefix(t.Name, namePrefix) ||
+			(clusterID != "" && t.ClusterID != clusterID) ||
+			(withoutCluster && t.ClusterID != "") ||
+			t.modifiedTime.Before(modifiedSince) {
+			continue
+		}
+		if len(resp.Tasks) == pageSize {
+			resp.NextPageToken = strconv.Itoa(after)
+			break
+		}
+		resp.Tasks = append(resp.Tasks, view(t.Task))
+		after = t.seq
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleTask gets a task, or cancels it by GA4GH TES
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, ga4ghAPIPrefix+"/tasks/")
+	if r.Method == http.MethodPost && strings.HasSuffix(id, ":cancel") {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		t, ok := s.tasks[strings.TrimSuffix(id, ":cancel")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "task not found")
+			return
+		}
+		if !isFinishedState(t.State) {
+			_ = s.cancelTask(t)
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, r.Method)
+		return
+	}
+	view, ok := parseView(r.URL.Query().Get("view"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid view: %s", r.URL.Query().Get("view")))
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "task not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, view(t.Task))
+}
+
+func (s *Server) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		writeError(w, http.StatusMethodNotAllowed, r.Method)
+		return
+	}
+	req := new(models.UpdateTaskRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ID = strings.TrimPrefix(r.URL.Path, otherAPIPrefix+"/tasks/")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if code, message := s.updateTask(req); code != http.StatusOK {
+		writeError(w, code, message)
+		return
+	}
+	writeJSON(w, http.StatusOK, &models.UpdateTaskResponse{})
+}
+
+// batchUpdateTasksBody is the request body of batch endpoint
+type batchUpdateTasksBody struct {
+	Tasks []*batchUpdateTask `json:"tasks"`
+}
+
+// batchUpdateTask is UpdateTaskRequest with ID in body
+type batchUpdateTask struct {
+	ID string `json:"id"`
+	*models.UpdateTaskRequest
+}
+
+func (s *Server) handleBatchUpdateTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, r.Method)
+		return
+	}
+	body := new(batchUpdateTasksBody)
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	resp := &models.BatchUpdateTasksResponse{Results: make([]*models.BatchUpdateTaskResult, 0, len(body.Tasks))}
+	for _, item := range body.Tasks {
+		req := item.UpdateTaskRequest
+		if req == nil {
+			req = &models.UpdateTaskRequest{}
+		}
+		req.ID = item.ID
+		code, message := s.updateTask(req)
+		resp.Results = append(resp.Results, &models.BatchUpdateTaskResult{ID: item.ID, StatusCode: code, Message: message})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// updateTask must be called with mutex held. Finished tasks cannot be updated,
+// and only QUEUED tasks can be assigned to another cluster.
+func (s *Server) updateTask(req *models.UpdateTaskRequest) (int, string) {
+	t, ok := s.tasks[req.ID]
+	if !ok {
+		return http.StatusNotFound, "task not found"
+	}
+	if req.ExpectedState != nil && t.State != *req.ExpectedState {
+		return http.StatusConflict, fmt.Sprintf("task is %s, not %s", t.State, *req.ExpectedState)
+	}
+	if isFinishedState(t.State) {
+		return http.StatusConflict, fmt.Sprintf("task is already %s", t.State)
+	}
+	if req.State != nil && !isValidState(*req.State) {
+		return http.StatusBadRequest, fmt.Sprintf("invalid state: %s", *req.State)
+	}
+	state := t.State
+	if req.State != nil {
+		state = *req.State
+	}
+	if req.ClusterID != nil && *req.ClusterID != t.ClusterID && state != consts.TaskQueued {
+		return http.StatusBadRequest, fmt.Sprintf("cannot assign %s task to cluster", state)
+	}
+	if req.ClusterID != nil {
+		t.ClusterID = *req.ClusterID
+	}
+	t.Logs = append(t.Logs, req.Logs...)
+	s.setState(t, state)
+	return http.StatusOK, ""
+}
+
+func (s *Server) handleGatherTasksResources(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, r.Method)
+		return
+	}
+	query := r.URL.Query()
+	states := make(map[string]struct{})
+	for _, state := range query["state"] {
+		states[state] = struct{}{}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	resp := &models.GatherTasksResourcesResponse{GPU: make(map[string]float64)}
+	for _, t := range s.tasks {
+		if _, ok := states[t.State]; len(states) > 0 && !ok {
+			continue
+		}
+		if (query.Get("cluster_id") != "" && t.ClusterID != query.Get("cluster_id")) ||
+			(query.Get("with_cluster") == "true" && t.ClusterID == "") {
+			continue
+		}
+		if query.Get("account_id") != "" && (t.BioosInfo == nil || t.BioosInfo.AccountID != query.Get("account_id")) {
+			continue
+		}
+		if query.Get("user_id") != "" && (t.BioosInfo == nil || t.BioosInfo.UserID != query.Get("user_id")) {
+			continue
+		}
+		resp.Count++
+		if t.Resources == nil {
+			continue
+		}
+		resp.CPUCores += t.Resources.CPUCores
+		resp.RamGB += t.Resources.RamGB
+		resp.DiskGB += t.Resources.DiskGB
+		if t.Resources.GPU != nil {
+			resp.GPU[t.Resources.GPU.Type] += t.Resources.GPU.Count
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleListClusters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, r.Method)
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	resp := make(models.ListClustersResponse, 0, len(s.clusters))
+	for _, cluster := range s.clusters {
+		cluster := *cluster
+		resp = append(resp, &cluster)
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].ID < resp[j].ID })
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleGetQuota responds 404 if there is no such quota
+func (s *Server) handleGetQuota(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, r.Method)
+		return
+	}
+	query := r.URL.Query()
+	global, accountID, userID := query.Get("global") == "true", query.Get("account_id"), query.Get("user_id")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, quota := range s.quotas {
+		if quota.Global != global || (!global && (quota.AccountID != accountID || quota.UserID != userID)) {
+			continue
+		}
+		writeJSON(w, http.StatusOK, &models.GetQuotaResponse{
+			Global:        quota.Global,
+			AccountID:     quota.AccountID,
+			UserID:        quota.UserID,
+			ResourceQuota: quota.ResourceQuota,
+		})
+		return
+	}
+	writeError(w, http.StatusNotFound, "quota not found")
+}
+
+func (s *Server) handleListQuotas(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, r.Method)
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	resp := make(models.ListQuotasResponse, 0, len(s.quotas))
+	resp = append(resp, s.quotas...)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleListExtraPriority(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, r.Method)
+		return
+	}
+	query := r.URL.Query()
+	accountID, submissionID, runID := query.Get("account_id"), query.Get("submission_id"), query.Get("run_id")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	resp := make(models.ListExtraPriorityResponse, 0, len(s.extraPriorities))
+	for _, extraPriority := range s.extraPriorities {
+		if (accountID != "" && extraPriority.AccountID != accountID) ||
+			(submissionID != "" && extraPriority.SubmissionID != submissionID) ||
+			(runID != "" && extraPriority.RunID != runID) {
+			continue
+		}
+		resp = append(resp, extraPriority)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parseView returns the function to render a task in view, empty view means MINIMAL
+func parseView(view string) (func(*models.Task) *models.Task, bool) {
+	switch view {
+	case "", consts.MinimalView:
+		return func(t *models.Task) *models.Task {
+			return &models.Task{ID: t.ID, State: t.State}
+		}, true
+	case consts.BasicView:
+		return func(t *models.Task) *models.Task {
+			res := copyTask(t)
+			res.Logs = nil
+			for i, input := range res.Inputs {
+				input := *input
+				input.Content = ""
+				res.Inputs[i] = &input
+			}
+			return res
+		}, true
+	case consts.FullView:
+		return copyTask, true
+	default:
+		return nil, false
+	}
+}
+
+// copyTask copies task deep enough that the copy can be modified by callers
+func copyTask(t *models.Task) *models.Task {
+	res := *t
+	res.Inputs = append([]*models.Input(nil), t.Inputs...)
+	res.Logs = append([]*models.TaskLog(nil), t.Logs...)
+	if t.Tags != nil {
+		res.Tags = make(map[string]string, len(t.Tags))
+		for k, v := range t.Tags {
+			res.Tags[k] = v
+		}
+	}
+	if t.BioosInfo != nil {
+		bioosInfo := *t.BioosInfo
+		res.BioosInfo = &bioosInfo
+	}
+	return &res
+}
+
+func isValidState(state string) bool {
+	switch state {
+	case consts.TaskQueued, consts.TaskInitializing, consts.TaskRunning, consts.TaskCanceling:
+		return true
+	default:
+		return isFinishedState(state)
+	}
+}
+
+func isFinishedState(state string) bool {
+	switch state {
+	case consts.TaskComplete, consts.TaskSystemError, consts.TaskExecutorError, consts.TaskCanceled:
+		return true
+	default:
+		return false
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"message": message})
+}
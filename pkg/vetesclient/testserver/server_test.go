@@ -0,0 +1,127 @@
+package testserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"k8s.io/utils/clock"
+
+	"github.com/GBA-BI/tes-scheduler/pkg/consts"
+	"github.com/GBA-BI/tes-scheduler/pkg/utils"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient"
+	"github.com/GBA-BI/tes-scheduler/pkg/vetesclient/models"
+)
+
+func newTestClient(g *gomega.WithT, s *Server) vetesclient.Client {
+	opts := vetesclient.NewOptions()
+	opts.Endpoint = s.Endpoint()
+	opts.MaxRetries = 0
+	client, err := vetesclient.NewClient(opts, clock.RealClock{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	return client
+}
+
+func TestListTasks(t *testing.T) {
+	g := gomega.NewWithT(t)
+	s := New(clock.RealClock{})
+	defer s.Close()
+	client := newTestClient(g, s)
+
+	for i := 0; i < 5; i++ {
+		s.AddTask(&models.Task{Name: "task", Resources: &models.Resources{CPUCores: 1}})
+	}
+	s.AddTask(&models.Task{ID: "task-finished", State: consts.TaskComplete})
+
+	resp, err := client.ListTasks(context.Background(), &models.ListTasksRequest{State: []string{consts.TaskQueued}, PageSize: 2, View: consts.BasicView})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp.Tasks).To(gomega.HaveLen(2))
+	g.Expect(resp.Tasks[0].Resources).To(gomega.Equal(&models.Resources{CPUCores: 1}))
+	ids := []string{resp.Tasks[0].ID, resp.Tasks[1].ID}
+	for resp.NextPageToken != "" {
+		resp, err = client.ListTasks(context.Background(), &models.ListTasksRequest{State: []string{consts.TaskQueued}, PageSize: 2, PageToken: resp.NextPageToken})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		for _, task := range resp.Tasks {
+			g.Expect(task.Resources).To(gomega.BeNil()) // minimal view
+			ids = append(ids, task.ID)
+		}
+	}
+	g.Expect(ids).To(gomega.HaveLen(5))
+	g.Expect(ids).NotTo(gomega.ContainElement("task-finished"))
+
+	resp, err = client.ListTasks(context.Background(), &models.ListTasksRequest{
+		ModifiedSince: time.Now().Add(time.Minute).UTC().Format(time.RFC3339),
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp.Tasks).To(gomega.BeEmpty())
+
+	task, err := client.GetTask(context.Background(), &models.GetTaskRequest{ID: "task-finished"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(task.State).To(gomega.Equal(consts.TaskComplete))
+	_, err = client.GetTask(context.Background(), &models.GetTaskRequest{ID: "task-unknown"})
+	g.Expect(errors.Is(err, vetesclient.ErrNotFound)).To(gomega.BeTrue())
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	g := gomega.NewWithT(t)
+	s := New(clock.RealClock{})
+	defer s.Close()
+	client := newTestClient(g, s)
+
+	s.SetCluster(&models.Cluster{ID: "cluster-01"})
+	id := s.AddTask(&models.Task{})
+	canceledID := s.AddTask(&models.Task{})
+
+	resp, err := client.BatchUpdateTasks(context.Background(), &models.BatchUpdateTasksRequest{Tasks: []*models.UpdateTaskRequest{
+		{ID: id, ClusterID: utils.Point("cluster-01"), ExpectedState: utils.Point(consts.TaskQueued)},
+		{ID: canceledID, ClusterID: utils.Point("cluster-01"), ExpectedState: utils.Point(consts.TaskRunning)},
+	}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resp.Results[0].Error).NotTo(gomega.HaveOccurred())
+	g.Expect(errors.Is(resp.Results[1].Error, vetesclient.ErrConflict)).To(gomega.BeTrue())
+
+	g.Expect(s.PickUpTasks("cluster-01", 0)).To(gomega.Equal([]string{id}))
+	// running task cannot be assigned to another cluster
+	_, err = client.UpdateTask(context.Background(), &models.UpdateTaskRequest{ID: id, ClusterID: utils.Point("cluster-02")})
+	g.Expect(errors.Is(err, vetesclient.ErrBadRequest)).To(gomega.BeTrue())
+
+	g.Expect(s.FinishTask(id, consts.TaskComplete)).To(gomega.Succeed())
+	g.Expect(s.GetTask(id).State).To(gomega.Equal(consts.TaskComplete))
+	g.Expect(s.GetTask(id).Logs[0].EndTime).NotTo(gomega.BeNil())
+	// finished task cannot be updated
+	_, err = client.UpdateTask(context.Background(), &models.UpdateTaskRequest{ID: id, State: utils.Point(consts.TaskQueued)})
+	g.Expect(errors.Is(err, vetesclient.ErrConflict)).To(gomega.BeTrue())
+
+	g.Expect(s.CancelTask(canceledID)).To(gomega.Succeed())
+	g.Expect(s.GetTask(canceledID).State).To(gomega.Equal(consts.TaskCanceled))
+}
+
+func TestClustersQuotasAndExtraPriorities(t *testing.T) {
+	g := gomega.NewWithT(t)
+	s := New(clock.RealClock{})
+	defer s.Close()
+	client := newTestClient(g, s)
+
+	s.SetCluster(&models.Cluster{ID: "cluster-02"})
+	s.SetCluster(&models.Cluster{ID: "cluster-01", HeartbeatTimestamp: "2023-01-01T00:00:00Z"})
+	s.Heartbeat("cluster-01")
+	clusters, err := client.ListClusters(context.Background(), &models.ListClustersRequest{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(*clusters).To(gomega.HaveLen(2))
+	g.Expect((*clusters)[0].ID).To(gomega.Equal("cluster-01"))
+	g.Expect((*clusters)[0].HeartbeatTimestamp).NotTo(gomega.Equal("2023-01-01T00:00:00Z"))
+
+	s.SetQuotas(&models.Quota{Global: true, ResourceQuota: &models.ResourceQuota{Count: utils.Point(10)}})
+	quota, err := client.GetQuota(context.Background(), &models.GetQuotaRequest{Global: true})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(quota.ResourceQuota.Count).To(gomega.Equal(utils.Point(10)))
+	_, err = client.GetQuota(context.Background(), &models.GetQuotaRequest{AccountID: "account-01"})
+	g.Expect(errors.Is(err, vetesclient.ErrNotFound)).To(gomega.BeTrue())
+
+	s.SetExtraPriorities(&models.ExtraPriority{AccountID: "account-01", ExtraPriorityValue: 10}, &models.ExtraPriority{AccountID: "account-02"})
+	extraPriorities, err := client.ListExtraPriority(context.Background(), &models.ListExtraPriorityRequest{AccountID: "account-01"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(*extraPriorities).To(gomega.HaveLen(1))
+}